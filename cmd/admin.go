@@ -0,0 +1,451 @@
+package cmd
+
+import (
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+)
+
+// adminPath is the directory of the admin command, whose subcommands run
+// usecases from the command line.
+func adminPath() string {
+	return filepath.Join("cmd", "admin")
+}
+
+// commandsPath is the file registering every admin command.
+func commandsPath() string {
+	return filepath.Join(adminPath(), "commands.go")
+}
+
+// adminCommand is a command of cmd/admin bound to the Execute method of a
+// usecase.
+type adminCommand struct {
+	Func    string
+	Name    string
+	Use     string
+	Service string
+	Usecase mapperType
+	Flags   []string
+	// Sets are the statements storing the values of flags that are not
+	// bound to the request directly in it
+	Sets    []string
+	imports map[string]bool
+}
+
+func (c *adminCommand) qualifier(p *types.Package) string {
+	c.imports[p.Path()] = true
+	return p.Name()
+}
+
+// Imports renders the import block of the command.
+func (c *adminCommand) Imports() string {
+	var paths []string
+	for p := range c.imports {
+		paths = append(paths, p)
+	}
+	return renderImports(paths)
+}
+
+// UsecaseImport is the import path of the usecase package.
+func (c *adminCommand) UsecaseImport() string {
+	return c.Usecase.pkg.Path()
+}
+
+// Test is the name of the test of the command.
+func (c *adminCommand) Test() string {
+	return "Test" + capitalize(c.Func)
+}
+
+// UsecasePkg is the name the command refers to the usecase package by.
+func (c *adminCommand) UsecasePkg() string {
+	return c.qualifier(c.Usecase.pkg)
+}
+
+// generateCommand writes the admin command names, which reads the flags of
+// the usecase's Request, executes it and prints the Response, with a test.
+// The command is registered in commands.go, and cmd/admin and bootstrap.go
+// are created first if needed.
+func generateCommand(names, usecase Names) {
+	req, service := loadUsecase(importer.ForCompiler(token.NewFileSet(), "source", nil), usecase)
+	ensureAdminCommand()
+
+	c := &adminCommand{
+		Func:    "new" + names.Pascal + "Command",
+		Name:    usecase.Pascal,
+		Use:     names.Kebab,
+		Service: service,
+		Usecase: req,
+		imports: map[string]bool{"errors": true, "github.com/spf13/cobra": true, req.pkg.Path(): true},
+	}
+	for i := 0; i < req.fields.NumFields(); i++ {
+		field := req.fields.Field(i)
+		tag := req.fields.Tag(i)
+		name := jsonName(field, tag)
+		if !field.Exported() || name == "-" {
+			continue
+		}
+		c.flag(field, toKebab(name), tag)
+	}
+
+	path := filepath.Join(adminPath(), names.Snake+".go")
+	generateFile(path, commandTemplate, c)
+	removeUnusedImports(path)
+	testPath := filepath.Join(adminPath(), names.Snake+"_test.go")
+	generateFile(testPath, commandTestTemplate, c)
+	removeUnusedImports(testPath)
+
+	reg := usecaseRegistration(req.pkg, usecase, "", c.Func, "root.AddCommand(%s)")
+	reg.Imports = []string{req.pkg.Path()}
+	if reg.Field != "" {
+		bootstrapField("Commands", reg.Field, reg.Type, req.pkg.Path())
+		wireUsecase("Commands", reg.Field, reg.NewService)
+		reg.Field = ""
+		reg.Imports = nil
+	}
+	replaceRegistration(commandsPath(), "registerCommands", c.Func, reg)
+	register(commandsPath(), "registerCommands", reg)
+	tidyModule()
+}
+
+// flag adds the flag name reading field to the command. Fields of types a
+// flag cannot hold are left to be set by hand.
+func (c *adminCommand) flag(field *types.Var, name, tag string) {
+	t := field.Type()
+	ptr, isPtr := t.(*types.Pointer)
+	if isPtr {
+		t = ptr.Elem()
+	}
+	usage := "Request." + field.Name()
+	if strings.Contains(reflect.StructTag(tag).Get("validate"), "required") {
+		usage += " (required)"
+	}
+	typeName := types.TypeString(t, c.qualifier)
+	local := toCamel(field.Name())
+	if token.Lookup(local).IsKeyword() || strings.Contains(" req cmd args usecase resp err t value ", " "+local+" ") {
+		local += "Flag"
+	}
+	changed := fmt.Sprintf("cmd.Flags().Changed(%q)", name)
+
+	// time.Time is read from an RFC 3339 string and set only when given
+	if isTime(t) {
+		c.imports["fmt"], c.imports["time"] = true, true
+		c.Flags = append(c.Flags, fmt.Sprintf("%s := cmd.Flags().String(%q, \"\", %q)", local, name, usage+" as an RFC 3339 time"))
+		assign := fmt.Sprintf("req.%s = t", field.Name())
+		if isPtr {
+			assign = fmt.Sprintf("req.%s = &t", field.Name())
+		}
+		c.Sets = append(c.Sets, fmt.Sprintf("if %s {\nt, err := time.Parse(time.RFC3339, *%s)\nif err != nil {\nreturn fmt.Errorf(\"--%s must be an RFC 3339 time: %%w\", err)\n}\n%s\n}",
+			changed, local, name, assign))
+		return
+	}
+
+	fn, flagType := flagFunc(t)
+	if fn == "" {
+		fmt.Printf("Set %s (%s) by hand: its type cannot be read from a flag\n", field.Name(), types.TypeString(field.Type(), c.qualifier))
+		c.Sets = append(c.Sets, fmt.Sprintf("// Set req.%s (%s) by hand", field.Name(), types.TypeString(field.Type(), c.qualifier)))
+		return
+	}
+	zero := "0"
+	switch {
+	case fn == "String":
+		zero = `""`
+	case fn == "Bool":
+		zero = "false"
+	case strings.HasSuffix(fn, "Slice") || strings.HasPrefix(fn, "StringTo"):
+		zero = "nil"
+	}
+	// Fields of the flag's own type are bound directly; the others are
+	// converted, and pointers set only when the flag is given
+	if !isPtr && types.Identical(t, flagType) {
+		c.Flags = append(c.Flags, fmt.Sprintf("cmd.Flags().%sVar(&req.%s, %q, %s, %q)", fn, field.Name(), name, zero, usage))
+		return
+	}
+	c.Flags = append(c.Flags, fmt.Sprintf("%s := cmd.Flags().%s(%q, %s, %q)", local, fn, name, zero, usage))
+	value := "*" + local
+	if !types.Identical(t, flagType) {
+		value = typeName + "(" + value + ")"
+	}
+	if !isPtr {
+		c.Sets = append(c.Sets, fmt.Sprintf("req.%s = %s", field.Name(), value))
+		return
+	}
+	if types.Identical(t, flagType) {
+		c.Sets = append(c.Sets, fmt.Sprintf("if %s {\nreq.%s = %s\n}", changed, field.Name(), local))
+		return
+	}
+	c.Sets = append(c.Sets, fmt.Sprintf("if %s {\nvalue := %s\nreq.%s = &value\n}", changed, value, field.Name()))
+}
+
+// flagFunc returns the pflag function reading values of type t, e.g.
+// Int64, and the type of the values it reads, of which t must be the type
+// or a named type.
+func flagFunc(t types.Type) (string, types.Type) {
+	if named, ok := t.(*types.Named); ok && named.Obj().Pkg() != nil && named.Obj().Pkg().Path() == "time" && named.Obj().Name() == "Duration" {
+		return "Duration", t
+	}
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		switch u.Kind() {
+		case types.String, types.Bool, types.Int, types.Int8, types.Int16, types.Int32, types.Int64,
+			types.Uint, types.Uint8, types.Uint16, types.Uint32, types.Uint64, types.Float32, types.Float64:
+			return capitalize(types.Typ[u.Kind()].Name()), types.Typ[u.Kind()]
+		}
+	case *types.Slice:
+		if elem, ok := u.Elem().(*types.Basic); ok {
+			switch elem.Kind() {
+			case types.String, types.Bool, types.Int, types.Int32, types.Int64, types.Uint, types.Float32, types.Float64:
+				return capitalize(elem.Name()) + "Slice", types.NewSlice(elem)
+			}
+		}
+	case *types.Map:
+		key, okKey := u.Key().(*types.Basic)
+		elem, okElem := u.Elem().(*types.Basic)
+		if okKey && okElem && key.Kind() == types.String {
+			switch elem.Kind() {
+			case types.String, types.Int, types.Int64:
+				return "StringTo" + capitalize(elem.Name()), types.NewMap(key, elem)
+			}
+		}
+	}
+	return "", nil
+}
+
+// isTime reports whether t is time.Time.
+func isTime(t types.Type) bool {
+	named, ok := t.(*types.Named)
+	return ok && named.Obj().Pkg() != nil && named.Obj().Pkg().Path() == "time" && named.Obj().Name() == "Time"
+}
+
+// ensureAdminCommand writes cmd/admin with its root command, the output
+// of responses and commands.go unless they exist.
+func ensureAdminCommand() {
+	ensureBootstrap()
+	bootstrap := importPath(filepath.Dir(bootstrapPath()))
+	writeIfMissing(filepath.Join(adminPath(), "main.go"), adminMainTemplate, bootstrap)
+	writeIfMissing(filepath.Join(adminPath(), "output.go"), adminOutputTemplate, nil)
+	if _, err := os.Stat(commandsPath()); err != nil {
+		writeIfMissing(commandsPath(), commandsTemplate, bootstrap)
+		fmt.Printf("Created %s; cmd/admin runs the commands it registers\n", commandsPath())
+	}
+}
+
+// removeCommand removes the admin command names and its registration. It
+// reports whether there was one.
+func removeCommand(names Names) bool {
+	path := filepath.Join(adminPath(), names.Snake+".go")
+	if _, err := os.Stat(path); err != nil {
+		return false
+	}
+	for _, p := range []string{path, filepath.Join(adminPath(), names.Snake+"_test.go")} {
+		if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+			fmt.Printf("Error removing %s: %v\n", p, err)
+			os.Exit(1)
+		}
+	}
+	if _, err := os.Stat(commandsPath()); err == nil {
+		removed := removeStatements(commandsPath(), "registerCommands", func(s ast.Stmt, _ string) bool {
+			return refersTo(s, "new"+names.Pascal+"Command")
+		})
+		removeUnusedImports(commandsPath())
+		if removed > 0 {
+			fmt.Printf("Removed %d registrations from %s\n", removed, commandsPath())
+		}
+	}
+	fmt.Printf("Successfully removed command %s\n", names.Kebab)
+	return true
+}
+
+const adminMainTemplate = `// Command admin runs operational tasks with the usecases of the
+// application, wired by internal/bootstrap as for the servers. The
+// subcommands are registered in commands.go.
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+
+	"{{.}}"
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	app, err := bootstrap.New(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	root := &cobra.Command{
+		Use:          "admin",
+		Short:        "Run operational tasks",
+		SilenceUsage: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if output != "text" && output != "json" {
+				return fmt.Errorf("unsupported output %q, use text or json", output)
+			}
+			return nil
+		},
+	}
+	root.PersistentFlags().StringVarP(&output, "output", "o", "text", "Output format (text|json)")
+	registerCommands(root, app.Commands)
+
+	err = root.ExecuteContext(ctx)
+	app.Close()
+	if err != nil {
+		os.Exit(1)
+	}
+}
+`
+
+const adminOutputTemplate = `package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+// output is the format responses are printed in, text or json.
+var output = "text"
+
+// printResponse prints resp as JSON or, as text, the fields of a struct one
+// per line.
+func printResponse(cmd *cobra.Command, resp interface{}) error {
+	w := cmd.OutOrStdout()
+	if output == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(resp)
+	}
+	v := reflect.Indirect(reflect.ValueOf(resp))
+	if v.Kind() != reflect.Struct {
+		_, err := fmt.Fprintln(w, resp)
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for i := 0; i < v.NumField(); i++ {
+		if field := v.Type().Field(i); field.IsExported() {
+			fmt.Fprintf(tw, "%s:\t%v\n", field.Name, v.Field(i).Interface())
+		}
+	}
+	return tw.Flush()
+}
+`
+
+const commandsTemplate = `package main
+
+import (
+	"github.com/spf13/cobra"
+
+	"{{.}}"
+)
+
+// registerCommands adds every admin command to root. Those whose usecase
+// is nil in usecases fail when run. The handler command adds new commands
+// here.
+func registerCommands(root *cobra.Command, usecases bootstrap.Commands) {
+}
+`
+
+const commandTemplate = `package main
+
+{{.Imports}}
+// {{.Func}} executes the {{.Name}} usecase. Its flags make
+// up the Request, and the Response is printed.
+func {{.Func}}(usecase {{.UsecasePkg}}.{{.Service}}) *cobra.Command {
+	var req {{.UsecasePkg}}.Request
+	cmd := &cobra.Command{
+		Use:   "{{.Use}}",
+		Short: "Run the {{.Name}} usecase",
+		Args:  cobra.NoArgs,
+	}
+{{- range .Flags}}
+	{{.}}
+{{- end}}
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		if usecase == nil {
+			return errors.New("{{.Use}} is not implemented: set app.Commands.{{.Name}} in bootstrap.New")
+		}
+{{- range .Sets}}
+		{{.}}
+{{- end}}
+		resp, err := usecase.Execute(cmd.Context(), &req)
+		if err != nil {
+			return err
+		}
+		return printResponse(cmd, resp)
+	}
+	return cmd
+}
+`
+
+const commandTestTemplate = `package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"{{.UsecaseImport}}"
+)
+
+type fake{{.Service}} struct {
+	req *{{.UsecasePkg}}.Request
+	err error
+}
+
+func (f *fake{{.Service}}) Execute(ctx context.Context, req *{{.UsecasePkg}}.Request) (*{{.UsecasePkg}}.Response, error) {
+	f.req = req
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &{{.UsecasePkg}}.Response{}, nil
+}
+
+func {{.Test}}(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		wantErr bool
+	}{
+		{name: "executes the usecase"},
+		{name: "returns usecase errors", err: errors.New("failed"), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			usecase := &fake{{.Service}}{err: tt.err}
+			cmd := {{.Func}}(usecase)
+			cmd.SetArgs([]string{})
+			cmd.SetOut(&bytes.Buffer{})
+			cmd.SetErr(io.Discard)
+
+			err := cmd.Execute()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Execute() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if usecase.req == nil {
+				t.Fatal("usecase was not executed")
+			}
+		})
+	}
+}
+`
@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"go/token"
+	"go/types"
+	"testing"
+)
+
+func TestFlagFunc(t *testing.T) {
+	timePkg := types.NewPackage("time", "time")
+	duration := types.NewNamed(types.NewTypeName(token.NoPos, timePkg, "Duration", nil), types.Typ[types.Int64], nil)
+	timeType := types.NewNamed(types.NewTypeName(token.NoPos, timePkg, "Time", nil), types.NewStruct(nil, nil), nil)
+	status := types.NewNamed(types.NewTypeName(token.NoPos, nil, "Status", nil), types.Typ[types.String], nil)
+	tests := []struct {
+		t        types.Type
+		want     string
+		flagType types.Type
+	}{
+		{types.Typ[types.String], "String", types.Typ[types.String]},
+		{types.Typ[types.Uint16], "Uint16", types.Typ[types.Uint16]},
+		{status, "String", types.Typ[types.String]},
+		{duration, "Duration", duration},
+		{types.NewSlice(types.Typ[types.Int64]), "Int64Slice", types.NewSlice(types.Typ[types.Int64])},
+		{types.NewMap(types.Typ[types.String], types.Typ[types.Int]), "StringToInt", types.NewMap(types.Typ[types.String], types.Typ[types.Int])},
+		{types.NewSlice(types.Typ[types.Uint8]), "", nil},
+		{types.NewMap(types.Typ[types.Int], types.Typ[types.String]), "", nil},
+		{timeType, "", nil},
+	}
+	for _, tt := range tests {
+		got, flagType := flagFunc(tt.t)
+		if got != tt.want {
+			t.Errorf("flagFunc(%s) = %q, want %q", tt.t, got, tt.want)
+		}
+		if (flagType == nil) != (tt.flagType == nil) || flagType != nil && !types.Identical(flagType, tt.flagType) {
+			t.Errorf("flagFunc(%s) type = %v, want %v", tt.t, flagType, tt.flagType)
+		}
+	}
+	if !isTime(timeType) || isTime(duration) {
+		t.Errorf("isTime() does not tell time.Time from time.Duration")
+	}
+}
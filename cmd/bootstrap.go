@@ -0,0 +1,450 @@
+package cmd
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// bootstrapPath is the file wiring the usecases of every entrypoint.
+func bootstrapPath() string {
+	return filepath.Join("internal", "bootstrap", "bootstrap.go")
+}
+
+// bootstrapData is the data of bootstrapTemplate, the import paths of the
+// registration packages App has Usecases of.
+type bootstrapData struct {
+	HTTP      string
+	GRPC      string
+	Messaging string
+}
+
+// ensureBootstrap writes bootstrap.go unless the project has it, with the
+// Usecases of the registration files that exist.
+func ensureBootstrap() {
+	path := bootstrapPath()
+	if _, err := os.Stat(path); err == nil {
+		return
+	}
+	var data bootstrapData
+	if _, err := os.Stat(routesPath()); err == nil {
+		data.HTTP = importPath(filepath.Dir(routesPath()))
+	}
+	if _, err := os.Stat(servicesPath()); err == nil {
+		data.GRPC = importPath(filepath.Dir(servicesPath()))
+	}
+	if _, err := os.Stat(consumersPath()); err == nil {
+		data.Messaging = importPath(filepath.Dir(consumersPath()))
+	}
+	writeIfMissing(path, bootstrapTemplate, data)
+	fmt.Printf("Created %s; have main.go take its usecases from bootstrap.New as well\n", path)
+}
+
+// bootstrapField adds the field name of type typ, from the package imp, to
+// the struct typeName of bootstrap.go. The package is imported by the name
+// typ refers to it by, e.g. httpapi.
+func bootstrapField(typeName, name, typ, imp string) {
+	ensureBootstrap()
+	pkg, _, _ := strings.Cut(strings.TrimLeft(typ, "*[]"), ".")
+	if pkg == path.Base(imp) {
+		pkg = ""
+	}
+	addImportAs(bootstrapPath(), pkg, imp)
+	addStructField(bootstrapPath(), typeName, name, typ)
+}
+
+// usecaseHint tells where the usecase held by the field of the Usecases of
+// the App field entrypoint is set, as wireUsecase cannot for the reason
+// given. Its handler is not implemented until then.
+func usecaseHint(entrypoint, field, reason string) {
+	fmt.Printf("%s: set app.%s.%s in bootstrap.New (%s) to serve it; it is not implemented while nil\n",
+		reason, entrypoint, field, bootstrapPath())
+}
+
+// wireUsecase sets the usecase held by the field of the Usecases of the App
+// field entrypoint in bootstrap.New, e.g. app.HTTP.CreateUser, calling its
+// newService with an adapter for each dependency:
+//
+//   - a domain repository gets the SQL repository of its persistence
+//     package when the project uses a SQL database and the in-memory one
+//     otherwise
+//   - a UnitOfWork gets sqltx, or memtx over the in-memory repositories
+//   - any other interface gets the System<Name>, NewLocal<Name> or
+//     New<Name> its package declares, such as SystemClock,
+//     NewLocalEventBus and a domain's NewIDGenerator
+//
+// The adapters are created once and shared by every usecase. When one of
+// the dependencies has no adapter, nothing is added and the usecase is left
+// to set by hand. A field New already sets is kept as it is.
+func wireUsecase(entrypoint, field string, newService *types.Func) {
+	ensureBootstrap()
+	w := newBootstrapWiring(bootstrapPath())
+	target := "app." + entrypoint + "." + field
+	if w.assigned[target] {
+		return
+	}
+	if newService == nil {
+		usecaseHint(entrypoint, field, "No NewService constructs it")
+		return
+	}
+
+	params := newService.Type().(*types.Signature).Params()
+	args := make([]string, params.Len())
+	var missing []string
+	var uow []int
+	for i := range args {
+		t := params.At(i).Type()
+		named, ok := t.(*types.Named)
+		switch {
+		case !ok:
+			missing = append(missing, types.TypeString(t, (*types.Package).Name))
+		case named.Obj().Name() == "UnitOfWork":
+			// After the repositories it rolls back
+			uow = append(uow, i)
+		default:
+			if args[i], ok = w.adapter(named.Obj()); !ok {
+				missing = append(missing, named.Obj().Name())
+			}
+		}
+	}
+	for _, i := range uow {
+		var ok bool
+		if args[i], ok = w.unitOfWork(params.At(i).Type().(*types.Named).Obj()); !ok {
+			missing = append(missing, "UnitOfWork")
+		}
+	}
+	if len(missing) > 0 {
+		usecaseHint(entrypoint, field, "No adapter for "+strings.Join(missing, ", "))
+		return
+	}
+
+	pkg := newService.Pkg()
+	w.stmts = append(w.stmts, fmt.Sprintf("%s = %s.NewService(%s)", target, w.importAs(pkg.Path(), pkg.Name(), pkg.Name()), strings.Join(args, ", ")))
+	w.apply()
+	fmt.Printf("Set %s in bootstrap.New (%s)\n", target, bootstrapPath())
+}
+
+// unwireUsecase removes what sets the usecase held by the field of the
+// Usecases of the App field entrypoint from bootstrap.New, along with the
+// adapters and imports nothing uses any more.
+func unwireUsecase(entrypoint, field string) {
+	path := bootstrapPath()
+	if _, err := os.Stat(path); err != nil {
+		return
+	}
+	target := "app." + entrypoint + "." + field
+	removed := removeStatements(path, "New", func(s ast.Stmt, _ string) bool {
+		assign, ok := s.(*ast.AssignStmt)
+		return ok && len(assign.Lhs) == 1 && types.ExprString(assign.Lhs[0]) == target
+	})
+	if removed == 0 {
+		return
+	}
+	// Dropping an adapter may leave those it was created with unused
+	for removeStatements(path, "New", unusedDeclaration(path)) > 0 {
+	}
+	removeUnusedImports(path)
+	fmt.Printf("Removed %s from %s\n", target, path)
+}
+
+// unusedDeclaration matches the statements of bootstrap.New declaring a
+// single variable, such as an adapter, that the function does not use.
+func unusedDeclaration(path string) func(s ast.Stmt, _ string) bool {
+	_, _, file := parseGoFile(path)
+	uses := map[string]int{}
+	ast.Inspect(findFunc(file, path, "New").Body, func(n ast.Node) bool {
+		if ident, ok := n.(*ast.Ident); ok {
+			uses[ident.Name]++
+		}
+		return true
+	})
+	return func(s ast.Stmt, _ string) bool {
+		assign, ok := s.(*ast.AssignStmt)
+		if !ok || assign.Tok != token.DEFINE || len(assign.Lhs) != 1 {
+			return false
+		}
+		ident, ok := assign.Lhs[0].(*ast.Ident)
+		return ok && uses[ident.Name] == 1
+	}
+}
+
+// bootstrapWiring collects what wireUsecase adds to bootstrap.New.
+type bootstrapWiring struct {
+	path string
+	sql  bool
+	// imports are the names bootstrap.go refers to packages by
+	imports map[string]string
+	// vars are the variables New declares and assigned the fields it sets
+	vars     map[string]bool
+	assigned map[string]bool
+	added    [][2]string
+	stmts    []string
+	// closers is set once New adds to App.closers
+	closers bool
+	// memory are the in-memory repositories given to the usecase, which
+	// its unit of work rolls back
+	memory []string
+}
+
+func newBootstrapWiring(path string) *bootstrapWiring {
+	database := loadProject().Database
+	w := &bootstrapWiring{
+		path:     path,
+		sql:      database == "postgres" || database == "mysql",
+		imports:  map[string]string{},
+		vars:     map[string]bool{},
+		assigned: map[string]bool{},
+	}
+	_, _, file := parseGoFile(path)
+	for _, imp := range file.Imports {
+		p, _ := strconv.Unquote(imp.Path.Value)
+		w.imports[p] = importName(imp)
+	}
+	for _, s := range findFunc(file, path, "New").Body.List {
+		switch s := s.(type) {
+		case *ast.AssignStmt:
+			for _, lhs := range s.Lhs {
+				if ident, ok := lhs.(*ast.Ident); ok && s.Tok == token.DEFINE {
+					w.vars[ident.Name] = true
+				} else {
+					w.assigned[types.ExprString(lhs)] = true
+				}
+			}
+		case *ast.DeclStmt:
+			ast.Inspect(s, func(n ast.Node) bool {
+				if spec, ok := n.(*ast.ValueSpec); ok {
+					for _, name := range spec.Names {
+						w.vars[name.Name] = true
+					}
+				}
+				return true
+			})
+		}
+	}
+	return w
+}
+
+// adapter returns the expression of the adapter of the dependency obj.
+func (w *bootstrapWiring) adapter(obj *types.TypeName) (string, bool) {
+	name := obj.Name()
+	dir, ok := projectDir(obj.Pkg().Path())
+	if !ok {
+		return "", false
+	}
+	core := filepath.Base(filepath.Dir(dir)) == "core"
+	if entity := strings.TrimSuffix(name, "Repository"); entity != name && core {
+		return w.repository(dir, entity)
+	}
+	pkg := obj.Pkg()
+	if _, ok := pkg.Scope().Lookup("System" + name).(*types.TypeName); ok {
+		return w.importAs(pkg.Path(), pkg.Name(), pkg.Name()) + ".System" + name + "{}", true
+	}
+	local := toCamel(name)
+	if core {
+		// Each domain has its own, e.g. an IDGenerator
+		local = toCamel(pkg.Name() + name)
+	}
+	for _, ctor := range []string{"NewLocal" + name, "New" + name} {
+		if fn, ok := pkg.Scope().Lookup(ctor).(*types.Func); ok && fn.Type().(*types.Signature).Params().Len() == 0 {
+			w.declare(local, w.importAs(pkg.Path(), pkg.Name(), pkg.Name())+"."+ctor+"()")
+			return local, true
+		}
+	}
+	return "", false
+}
+
+// repository returns the repository of entity for the domain in coreDir,
+// from the persistence package of the same name.
+func (w *bootstrapWiring) repository(coreDir, entity string) (string, bool) {
+	dir := filepath.Join(filepath.Dir(filepath.Dir(coreDir)), "adapters", "persistence", filepath.Base(coreDir))
+	pkg, err := parseSourcePackage(dir)
+	if err != nil {
+		return "", false
+	}
+	ctor := "NewInMemory" + entity + "Repository"
+	if w.sql {
+		ctor = "New" + entity + "Repository"
+	}
+	if !pkg.declares(ctor) {
+		return "", false
+	}
+	local := toCamel(entity) + "Repository"
+	ctor = w.importAs(importPath(dir), pkg.Name, filepath.Base(coreDir)+"persistence") + "." + ctor
+	if w.sql {
+		w.declare(local, ctor+"("+w.openDB()+")")
+	} else {
+		w.declare(local, ctor+"()")
+		w.memory = append(w.memory, local)
+	}
+	return local, true
+}
+
+// unitOfWork returns the unit of work implementing the port obj, from the
+// persistence packages next to the ports.
+func (w *bootstrapWiring) unitOfWork(obj *types.TypeName) (string, bool) {
+	portsDir, ok := projectDir(obj.Pkg().Path())
+	if !ok {
+		return "", false
+	}
+	pkg := "memtx"
+	if w.sql {
+		pkg = "sqltx"
+	}
+	dir := filepath.Join(filepath.Dir(portsDir), "persistence", pkg)
+	if _, err := os.Stat(dir); err != nil {
+		return "", false
+	}
+	ctor := w.importAs(importPath(dir), pkg, pkg) + ".NewUnitOfWork("
+	if w.sql {
+		return ctor + w.openDB() + ")", true
+	}
+	return ctor + strings.Join(w.memory, ", ") + ")", true
+}
+
+// openDB opens the database of DATABASE_URL, closed by App.Close, unless
+// New has, and returns its variable.
+func (w *bootstrapWiring) openDB() string {
+	if w.vars["db"] {
+		return "db"
+	}
+	w.vars["db"] = true
+	driver, driverImport := sqlDriver(migrationDialect())
+	if _, ok := w.imports[driverImport]; !ok {
+		w.added = append(w.added, [2]string{"_", driverImport})
+	}
+	w.stmts = append(w.stmts,
+		fmt.Sprintf("db, err := %s.Open(%q, %s.Getenv(\"DATABASE_URL\"))", w.importAs("database/sql", "sql", "sql"), driver, w.importAs("os", "os", "os")),
+		fmt.Sprintf("if err != nil {\nreturn nil, %s.Errorf(\"opening database: %%w\", err)\n}", w.importAs("fmt", "fmt", "fmt")),
+		"app.closers = append(app.closers, db)")
+	w.importAs("io", "io", "io")
+	w.closers = true
+	return "db"
+}
+
+// declare has New declare the variable name as expr unless it does.
+func (w *bootstrapWiring) declare(name, expr string) {
+	if !w.vars[name] {
+		w.vars[name] = true
+		w.stmts = append(w.stmts, name+" := "+expr)
+	}
+}
+
+// importAs returns the name bootstrap.go refers to the package imp, named
+// pkgName, by, importing it as name unless it does already. A name taken by
+// another package is prefixed with the bounded context of imp.
+func (w *bootstrapWiring) importAs(imp, pkgName, name string) string {
+	if local, ok := w.imports[imp]; ok {
+		return local
+	}
+	for _, local := range w.imports {
+		if local == name {
+			if _, rest, ok := strings.Cut(imp, "/contexts/"); ok {
+				context, _, _ := strings.Cut(rest, "/")
+				name = toPackage(context) + name
+			}
+			break
+		}
+	}
+	w.imports[imp] = name
+	alias := name
+	if name == pkgName {
+		alias = ""
+	}
+	w.added = append(w.added, [2]string{alias, imp})
+	return name
+}
+
+// apply adds the imports and statements to bootstrap.go.
+func (w *bootstrapWiring) apply() {
+	if w.closers {
+		addStructField(w.path, "App", "closers", "[]io.Closer")
+	}
+	for _, imp := range w.added {
+		addImportAs(w.path, imp[0], imp[1])
+	}
+	for _, stmt := range w.stmts {
+		addStatement(w.path, "New", stmt, "return app, nil")
+	}
+}
+
+// projectDir returns the directory of the package imp of the project.
+func projectDir(imp string) (string, bool) {
+	rel, ok := strings.CutPrefix(imp, modulePath()+"/")
+	return filepath.FromSlash(rel), ok
+}
+
+const bootstrapTemplate = `// Package bootstrap wires the application for its entrypoints. main.go,
+// cmd/grpc, cmd/consumer and cmd/admin all start from New, so they run the
+// same usecases on the same adapters.
+package bootstrap
+
+import (
+	"context"
+	"io"
+{{if or .HTTP .GRPC .Messaging}}
+{{end}}
+{{- if .GRPC}}
+	grpcapi "{{.GRPC}}"
+{{- end}}
+{{- if .HTTP}}
+	httpapi "{{.HTTP}}"
+{{- end}}
+{{- if .Messaging}}
+	"{{.Messaging}}"
+{{- end}}
+)
+
+// App holds the usecases each entrypoint serves. Handlers of those left
+// nil answer that they are not implemented.
+type App struct {
+{{- if .HTTP}}
+	HTTP httpapi.Usecases
+{{- end}}
+{{- if .GRPC}}
+	GRPC grpcapi.Usecases
+{{- end}}
+{{- if .Messaging}}
+	Consumers messaging.Usecases
+{{- end}}
+	Commands Commands
+	// closers are the adapters Close releases
+	closers []io.Closer
+}
+
+// Commands are the usecases the commands of cmd/admin run.
+type Commands struct {
+}
+
+// New connects the adapters the usecases depend on and sets the usecases
+// of App that need them. The generators add those whose dependencies have
+// adapters; set the others here, e.g.
+//
+//	orders := postgres.NewOrderRepository(db)
+{{- if .HTTP}}
+//	app.HTTP.PlaceOrder = placeorder.NewService(orders)
+//	app.Commands.PlaceOrder = app.HTTP.PlaceOrder
+{{- else}}
+//	app.Commands.PlaceOrder = placeorder.NewService(orders)
+{{- end}}
+func New(ctx context.Context) (*App, error) {
+	app := &App{}
+	return app, nil
+}
+
+// Close releases the adapters New connected.
+func (a *App) Close() error {
+	var err error
+	for _, c := range a.closers {
+		if cerr := c.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+`
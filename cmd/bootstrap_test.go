@@ -0,0 +1,171 @@
+package cmd
+
+import (
+	"go/importer"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeWiringProject writes a product domain with an in-memory and a SQL
+// repository, the ports and units of work, and the restock and charge
+// usecases, which charge needs a PaymentGateway nothing adapts.
+func writeWiringProject(t *testing.T, database string) {
+	t.Helper()
+	files := map[string]string{
+		projectFile: `{"database": "` + database + `"}`,
+		"internal/core/product/product.go": `package product
+
+type Product struct{}
+
+type ProductRepository interface {
+	Save(p *Product) error
+}
+
+type IDGenerator interface {
+	NewID() string
+}
+
+func NewIDGenerator() IDGenerator { return nil }
+`,
+		"internal/adapters/persistence/product/product.go": `package product
+
+import "database/sql"
+
+func NewInMemoryProductRepository() *InMemoryProductRepository { return nil }
+
+type InMemoryProductRepository struct{}
+
+func NewProductRepository(db *sql.DB) *ProductRepository { return nil }
+
+type ProductRepository struct{}
+`,
+		"internal/adapters/persistence/memtx/memtx.go": "package memtx\n",
+		"internal/adapters/persistence/sqltx/sqltx.go": "package sqltx\n",
+		"internal/adapters/ports/ports.go": `package ports
+
+type Clock interface{}
+
+type SystemClock struct{}
+
+type EventBus interface{}
+
+func NewLocalEventBus() EventBus { return nil }
+
+type UnitOfWork interface{}
+
+type PaymentGateway interface{}
+`,
+		"internal/usecase/restock/service.go": `package restock
+
+import (
+	"app/internal/adapters/ports"
+	"app/internal/core/product"
+)
+
+type RestockService interface{}
+
+func NewService(products product.ProductRepository, ids product.IDGenerator, clock ports.Clock, events ports.EventBus, uow ports.UnitOfWork) RestockService {
+	return nil
+}
+`,
+		"internal/usecase/charge/service.go": `package charge
+
+import (
+	"app/internal/adapters/ports"
+	"app/internal/core/product"
+)
+
+type ChargeService interface{}
+
+func NewService(products product.ProductRepository, payments ports.PaymentGateway) ChargeService {
+	return nil
+}
+`,
+	}
+	for name, src := range files {
+		if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(name, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// newServiceOf type-checks the usecase package and returns its NewService.
+func newServiceOf(t *testing.T, usecase string) *types.Func {
+	t.Helper()
+	pkg, err := importer.ForCompiler(token.NewFileSet(), "source", nil).Import("app/internal/usecase/" + usecase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pkg.Scope().Lookup("NewService").(*types.Func)
+}
+
+// newBody returns the statements of bootstrap.New.
+func newBody(t *testing.T) string {
+	t.Helper()
+	src, err := os.ReadFile(bootstrapPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, body, _ := strings.Cut(string(src), "func New(ctx context.Context) (*App, error) {\n")
+	body, _, _ = strings.Cut(body, "}\n\n")
+	return body
+}
+
+func TestWireUsecase(t *testing.T) {
+	tests := []struct {
+		database string
+		want     string
+	}{
+		{"none", `	app := &App{}
+	productRepository := productpersistence.NewInMemoryProductRepository()
+	productIDGenerator := product.NewIDGenerator()
+	eventBus := ports.NewLocalEventBus()
+	app.Commands.Restock = restock.NewService(productRepository, productIDGenerator, ports.SystemClock{}, eventBus, memtx.NewUnitOfWork(productRepository))
+	return app, nil
+`},
+		{"postgres", `	app := &App{}
+	db, err := sql.Open("pgx", os.Getenv("DATABASE_URL"))
+	if err != nil {
+		return nil, fmt.Errorf("opening database: %w", err)
+	}
+	app.closers = append(app.closers, db)
+	productRepository := productpersistence.NewProductRepository(db)
+	productIDGenerator := product.NewIDGenerator()
+	eventBus := ports.NewLocalEventBus()
+	app.Commands.Restock = restock.NewService(productRepository, productIDGenerator, ports.SystemClock{}, eventBus, sqltx.NewUnitOfWork(db))
+	return app, nil
+`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.database, func(t *testing.T) {
+			inModule(t, "app")
+			writeWiringProject(t, tt.database)
+			restock, charge := newServiceOf(t, "restock"), newServiceOf(t, "charge")
+
+			wireUsecase("Commands", "Restock", restock)
+			// Wired again, or missing an adapter, nothing is added
+			wireUsecase("Commands", "Restock", restock)
+			wireUsecase("Commands", "Charge", charge)
+			if got := newBody(t); got != tt.want {
+				t.Errorf("New =\n%s\nwant\n%s", got, tt.want)
+			}
+
+			unwireUsecase("Commands", "Restock")
+			want := "\tapp := &App{}\n"
+			if tt.database == "postgres" {
+				// The database stays open for the usecases set by hand
+				want += "\tdb, err := sql.Open(\"pgx\", os.Getenv(\"DATABASE_URL\"))\n\tif err != nil {\n\t\treturn nil, fmt.Errorf(\"opening database: %w\", err)\n\t}\n\tapp.closers = append(app.closers, db)\n"
+			}
+			if got := newBody(t); got != want+"\treturn app, nil\n" {
+				t.Errorf("New after unwireUsecase() =\n%s\nwant\n%s", got, want+"\treturn app, nil\n")
+			}
+		})
+	}
+}
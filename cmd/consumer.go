@@ -0,0 +1,734 @@
+package cmd
+
+import (
+	"fmt"
+	"go/importer"
+	"go/token"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+var handlerTopic string
+
+// messagingPath is the directory of the consumer runner and the brokers it
+// runs on.
+func messagingPath() string {
+	return filepath.Join("internal", "adapters", "messaging")
+}
+
+// consumerData is the data of the consumer templates.
+type consumerData struct {
+	Package    string
+	Handler    string
+	Topic      string
+	TopicConst string
+	Service    string
+	UsecasePkg string
+	Usecase    string
+	Consumer   string
+	Memory     string
+	Kafka      string
+	Messaging  string
+	Bootstrap  string
+	Validation string
+	Domain     string
+	Group      string
+}
+
+// ensureConsumerRunner writes the consumer runner, its Kafka and in-memory
+// brokers and cmd/consumer, skipping files that exist, and gives App in
+// bootstrap.go the Usecases of the consumers.
+func ensureConsumerRunner() {
+	data := consumerData{
+		Consumer:  importPath(filepath.Join(messagingPath(), "consumer")),
+		Memory:    importPath(filepath.Join(messagingPath(), "memory")),
+		Kafka:     importPath(filepath.Join(messagingPath(), "kafka")),
+		Messaging: importPath(filepath.Dir(consumersPath())),
+		Bootstrap: importPath(filepath.Dir(bootstrapPath())),
+		Group:     path.Base(modulePath()),
+	}
+	writeIfMissing(filepath.Join(messagingPath(), "consumer", "consumer.go"), consumerRunnerTemplate, data)
+	writeIfMissing(filepath.Join(messagingPath(), "consumer", "consumer_test.go"), consumerRunnerTestTemplate, data)
+	writeIfMissing(filepath.Join(messagingPath(), "memory", "memory.go"), memoryBrokerTemplate, data)
+	writeIfMissing(filepath.Join(messagingPath(), "kafka", "kafka.go"), kafkaBrokerTemplate, data)
+	writeIfMissing(filepath.Join("cmd", "consumer", "main.go"), consumerMainTemplate, data)
+	bootstrapField("App", "Consumers", "messaging.Usecases", data.Messaging)
+}
+
+// generateConsumerHandler writes a handler for the messages of topic that
+// executes usecase, creating the usecase if needed, with a test running it
+// on the in-memory broker, and registers it in consumers.go.
+func generateConsumerHandler(dir string, names, usecase Names, topic string) {
+	usecaseDir := filepath.Join(layerPath(boundedContext, "usecase"), usecase.Package)
+	if _, err := os.Stat(usecaseDir); err != nil {
+		createUsecaseStructure(usecase, nil)
+	}
+	req, service := loadUsecase(importer.ForCompiler(token.NewFileSet(), "source", nil), usecase)
+	ensureValidationPackage()
+	ensureConsumerRunner()
+
+	data := consumerData{
+		Package:    names.Package,
+		Handler:    names.Pascal + "Consumer",
+		Topic:      topic,
+		TopicConst: names.Pascal + "Topic",
+		Service:    service,
+		UsecasePkg: req.pkg.Name(),
+		Usecase:    req.pkg.Path(),
+		Consumer:   importPath(filepath.Join(messagingPath(), "consumer")),
+		Memory:     importPath(filepath.Join(messagingPath(), "memory")),
+		Validation: importPath(validationPath()),
+	}
+	generateFile(filepath.Join(dir, names.Snake+"_consumer.go"), consumerHandlerTemplate, data)
+	generateFile(filepath.Join(dir, names.Snake+"_consumer_test.go"), consumerHandlerTestTemplate, data)
+
+	if pkg, err := parseSourcePackage(dir); err != nil || !pkg.declares("decide") {
+		domainDir := filepath.Join(layerPath(boundedContext, "core"), names.Package)
+		if pkg, err := parseSourcePackage(domainDir); err == nil && pkg.declares("ErrNotFound") {
+			data.Domain = importPath(domainDir)
+		}
+		generateFile(filepath.Join(dir, "decide.go"), decideTemplate, data)
+	}
+
+	reg := usecaseRegistration(req.pkg, usecase, importPath(dir), names.Package+".New"+data.Handler,
+		"runner.Register("+names.Package+"."+data.TopicConst+", %s)")
+	if reg.Field != "" {
+		// Unlike a handler, a consumer cannot answer that it is not
+		// implemented, so without its usecase it is not run and the messages
+		// wait in the broker
+		reg.Imports = append(reg.Imports, "log")
+		reg.Stmts = []string{fmt.Sprintf("if usecases.%s != nil {\n%s\n} else {\nlog.Printf(\"%s is not set in bootstrap.New; %s is not consumed\", %s.%s)\n}",
+			reg.Field, reg.Stmts[0], reg.Field, "%s", names.Package, data.TopicConst)}
+	}
+	// The runner takes a topic once, so a consumer generated again for
+	// another usecase replaces its registration
+	replaceRegistration(consumersPath(), "RegisterConsumers", "New"+data.Handler, reg)
+	registerConsumer(reg)
+	tidyModule()
+}
+
+const consumerHandlerTemplate = `package {{.Package}}
+
+import (
+	"context"
+	"encoding/json"
+
+	"{{.Consumer}}"
+	"{{.Usecase}}"
+)
+
+// {{.TopicConst}} is the topic {{.Handler}} consumes.
+const {{.TopicConst}} = "{{.Topic}}"
+
+// {{.Handler}} handles the messages of {{.TopicConst}} with the {{.UsecasePkg}}
+// usecase, whichever broker delivers them.
+type {{.Handler}} struct {
+	usecase {{.UsecasePkg}}.{{.Service}}
+}
+
+func New{{.Handler}}(usecase {{.UsecasePkg}}.{{.Service}}) *{{.Handler}} {
+	return &{{.Handler}}{usecase: usecase}
+}
+
+// Handle decodes the JSON payload into the usecase's Request and executes
+// it. Malformed messages are nacked, since delivering them again cannot
+// help; usecase errors are decided by decide.
+func (h *{{.Handler}}) Handle(ctx context.Context, msg consumer.Message) (consumer.Decision, error) {
+	var req {{.UsecasePkg}}.Request
+	if err := json.Unmarshal(msg.Payload, &req); err != nil {
+		return consumer.Nack, err
+	}
+	if _, err := h.usecase.Execute(ctx, &req); err != nil {
+		return decide(err), err
+	}
+	return consumer.Ack, nil
+}
+`
+
+const decideTemplate = `package {{.Package}}
+
+import (
+	"errors"
+
+	"{{.Consumer}}"
+{{- if .Domain}}
+	domain "{{.Domain}}"
+{{- end}}
+	"{{.Validation}}"
+)
+
+// decide maps usecase errors to the decision on a message. Invalid
+// requests{{if .Domain}} and missing entities{{end}} are nacked; other errors may be
+// transient and are retried.
+func decide(err error) consumer.Decision {
+	var verr *validation.Error
+	switch {
+	case errors.As(err, &verr):
+		return consumer.Nack
+{{- if .Domain}}
+	case errors.Is(err, domain.ErrNotFound):
+		return consumer.Nack
+{{- end}}
+	default:
+		return consumer.Retry
+	}
+}
+`
+
+const consumerHandlerTestTemplate = `package {{.Package}}
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"{{.Consumer}}"
+	"{{.Memory}}"
+	"{{.Usecase}}"
+	"{{.Validation}}"
+)
+
+type fake{{.Service}} struct {
+	err   error
+	calls int
+}
+
+func (f *fake{{.Service}}) Execute(ctx context.Context, req *{{.UsecasePkg}}.Request) (*{{.UsecasePkg}}.Response, error) {
+	f.calls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &{{.UsecasePkg}}.Response{}, nil
+}
+
+func Test{{.Handler}}(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		err     error
+		want    consumer.Decision
+		calls   int
+	}{
+		{"ok", "{}", nil, consumer.Ack, 1},
+		{"malformed payload", "{", nil, consumer.Nack, 0},
+		{"invalid request", "{}", validation.NewError("field", "is required"), consumer.Nack, 1},
+		{"failure", "{}", errors.New("failed"), consumer.Nack, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			usecase := &fake{{.Service}}{err: tt.err}
+			broker := memory.NewBroker()
+			runner := consumer.NewRunner(broker, consumer.WithRetry(3, time.Millisecond))
+			runner.Register({{.TopicConst}}, New{{.Handler}}(usecase))
+			go runner.Run(ctx)
+
+			if err := broker.Publish(ctx, {{.TopicConst}}, consumer.Message{Payload: []byte(tt.payload)}); err != nil {
+				t.Fatal(err)
+			}
+			select {
+			case got := <-broker.Deliveries():
+				if got.Decision != tt.want {
+					t.Errorf("decision = %v, want %v", got.Decision, tt.want)
+				}
+			case <-ctx.Done():
+				t.Fatal("the message was not handled")
+			}
+			if usecase.calls != tt.calls {
+				t.Errorf("usecase executed %d times, want %d", usecase.calls, tt.calls)
+			}
+		})
+	}
+}
+`
+
+const consumerRunnerTemplate = `// Package consumer runs the handlers of messages delivered by a broker.
+// Handlers decide what happens to each message, so they do not depend on
+// the broker.
+package consumer
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// Message is a message of a topic. Attempt counts the times it has been
+// handed to its handler, starting at 1.
+type Message struct {
+	Topic   string
+	Key     []byte
+	Payload []byte
+	Headers map[string]string
+	Attempt int
+}
+
+// Decision is what a handler decides to do with a message.
+type Decision int
+
+const (
+	// Ack marks the message as handled.
+	Ack Decision = iota
+	// Nack rejects a message that handling again cannot help, such as a
+	// malformed one. It goes to the dead-letter topic, if there is one.
+	Nack
+	// Retry hands the message to the handler again after a backoff. It is
+	// nacked after the last attempt.
+	Retry
+)
+
+func (d Decision) String() string {
+	switch d {
+	case Ack:
+		return "ack"
+	case Nack:
+		return "nack"
+	case Retry:
+		return "retry"
+	default:
+		return fmt.Sprintf("Decision(%d)", int(d))
+	}
+}
+
+// Handler handles the messages of a topic. The error explains a Nack or a
+// Retry.
+type Handler interface {
+	Handle(ctx context.Context, msg Message) (Decision, error)
+}
+
+// HandlerFunc adapts a function to a Handler.
+type HandlerFunc func(ctx context.Context, msg Message) (Decision, error)
+
+func (f HandlerFunc) Handle(ctx context.Context, msg Message) (Decision, error) {
+	return f(ctx, msg)
+}
+
+// Subscriber delivers the messages of a topic to handle one at a time
+// until ctx is done. handle returns Ack or Nack once it is done with a
+// message; Retry means ctx ended first and the message must be delivered
+// again later.
+type Subscriber interface {
+	Subscribe(ctx context.Context, topic string, handle func(ctx context.Context, msg Message) Decision) error
+}
+
+// Publisher publishes messages to a topic.
+type Publisher interface {
+	Publish(ctx context.Context, topic string, msg Message) error
+}
+
+// Option configures a Runner.
+type Option func(*Runner)
+
+// WithRetry sets how many times a message is handled before it is nacked,
+// and the backoff before the first retry, which doubles on every retry.
+func WithRetry(attempts int, backoff time.Duration) Option {
+	return func(r *Runner) {
+		r.attempts = attempts
+		r.backoff = backoff
+	}
+}
+
+// WithDeadLetter publishes nacked messages with publisher to their topic
+// suffixed with .dead-letter, with the error in the "error" header.
+func WithDeadLetter(publisher Publisher) Option {
+	return func(r *Runner) {
+		r.deadLetter = publisher
+	}
+}
+
+// WithLogger sets the logger of retries and nacked messages.
+func WithLogger(logger *slog.Logger) Option {
+	return func(r *Runner) {
+		r.logger = logger
+	}
+}
+
+// Runner subscribes the registered handlers to their topics.
+type Runner struct {
+	subscriber Subscriber
+	handlers   map[string]Handler
+	attempts   int
+	backoff    time.Duration
+	deadLetter Publisher
+	logger     *slog.Logger
+}
+
+// NewRunner returns a runner on subscriber that handles a message up to
+// three times.
+func NewRunner(subscriber Subscriber, opts ...Option) *Runner {
+	r := &Runner{
+		subscriber: subscriber,
+		handlers:   map[string]Handler{},
+		attempts:   3,
+		backoff:    100 * time.Millisecond,
+		logger:     slog.Default(),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Register makes h the handler of topic. It panics if topic already has
+// one.
+func (r *Runner) Register(topic string, h Handler) {
+	if _, ok := r.handlers[topic]; ok {
+		panic(fmt.Sprintf("consumer: topic %s already has a handler", topic))
+	}
+	r.handlers[topic] = h
+}
+
+// Run consumes every registered topic until ctx is done or a subscription
+// fails, and returns the first failure.
+func (r *Runner) Run(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	errs := make(chan error, len(r.handlers))
+	for topic, h := range r.handlers {
+		go func(topic string, h Handler) {
+			err := r.subscriber.Subscribe(ctx, topic, func(ctx context.Context, msg Message) Decision {
+				return r.handle(ctx, h, msg)
+			})
+			if err != nil {
+				err = fmt.Errorf("consumer: topic %s: %w", topic, err)
+			}
+			errs <- err
+		}(topic, h)
+	}
+
+	var first error
+	for range r.handlers {
+		if err := <-errs; err != nil && first == nil {
+			first = err
+			cancel()
+		}
+	}
+	return first
+}
+
+// handle runs h on msg, retrying as it decides, and returns Ack or Nack, or
+// Retry if ctx ended before the message was done.
+func (r *Runner) handle(ctx context.Context, h Handler, msg Message) Decision {
+	backoff := r.backoff
+	for attempt := 1; ; attempt++ {
+		msg.Attempt = attempt
+		decision, err := h.Handle(ctx, msg)
+		if decision == Ack {
+			return Ack
+		}
+		if ctx.Err() != nil {
+			return Retry
+		}
+		if decision == Retry && attempt < r.attempts {
+			r.logger.Warn("retrying message", "topic", msg.Topic, "attempt", attempt, "error", err)
+			select {
+			case <-ctx.Done():
+				return Retry
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+			continue
+		}
+
+		r.logger.Error("message nacked", "topic", msg.Topic, "attempt", attempt, "error", err)
+		r.sendToDeadLetter(ctx, msg, err)
+		return Nack
+	}
+}
+
+func (r *Runner) sendToDeadLetter(ctx context.Context, msg Message, err error) {
+	if r.deadLetter == nil {
+		return
+	}
+	headers := make(map[string]string, len(msg.Headers)+1)
+	for k, v := range msg.Headers {
+		headers[k] = v
+	}
+	if err != nil {
+		headers["error"] = err.Error()
+	}
+	topic := msg.Topic + ".dead-letter"
+	dead := Message{Topic: topic, Key: msg.Key, Payload: msg.Payload, Headers: headers}
+	if err := r.deadLetter.Publish(ctx, topic, dead); err != nil {
+		r.logger.Error("publishing dead letter", "topic", topic, "error", err)
+	}
+}
+`
+
+const consumerRunnerTestTemplate = `package consumer_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"{{.Consumer}}"
+	"{{.Memory}}"
+)
+
+func TestRunnerRetriesAndDeadLetters(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	broker := memory.NewBroker()
+	runner := consumer.NewRunner(broker, consumer.WithRetry(2, time.Millisecond), consumer.WithDeadLetter(broker))
+	attempts := 0
+	runner.Register("orders", consumer.HandlerFunc(func(ctx context.Context, msg consumer.Message) (consumer.Decision, error) {
+		attempts = msg.Attempt
+		return consumer.Retry, errors.New("unavailable")
+	}))
+	dead := make(chan consumer.Message, 1)
+	runner.Register("orders.dead-letter", consumer.HandlerFunc(func(ctx context.Context, msg consumer.Message) (consumer.Decision, error) {
+		dead <- msg
+		return consumer.Ack, nil
+	}))
+	go runner.Run(ctx)
+
+	if err := broker.Publish(ctx, "orders", consumer.Message{Payload: []byte("order")}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case msg := <-dead:
+		if string(msg.Payload) != "order" || msg.Headers["error"] != "unavailable" {
+			t.Errorf("dead letter = %q with headers %v", msg.Payload, msg.Headers)
+		}
+	case <-ctx.Done():
+		t.Fatal("no dead letter")
+	}
+	if attempts != 2 {
+		t.Errorf("handled %d times, want 2", attempts)
+	}
+}
+`
+
+const memoryBrokerTemplate = `// Package memory is an in-memory message broker for tests and local runs.
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"{{.Consumer}}"
+)
+
+// Delivery is a delivered message and the decision on it.
+type Delivery struct {
+	Message  consumer.Message
+	Decision consumer.Decision
+}
+
+// Broker delivers the messages published to a topic in order. Messages
+// published before the topic has a subscriber wait for it.
+type Broker struct {
+	mu         sync.Mutex
+	topics     map[string]chan consumer.Message
+	deliveries chan Delivery
+}
+
+var (
+	_ consumer.Subscriber = (*Broker)(nil)
+	_ consumer.Publisher  = (*Broker)(nil)
+)
+
+func NewBroker() *Broker {
+	return &Broker{
+		topics:     map[string]chan consumer.Message{},
+		deliveries: make(chan Delivery, 100),
+	}
+}
+
+func (b *Broker) topic(name string) chan consumer.Message {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	ch, ok := b.topics[name]
+	if !ok {
+		ch = make(chan consumer.Message, 100)
+		b.topics[name] = ch
+	}
+	return ch
+}
+
+// Publish queues msg on topic. It blocks while 100 messages of the topic
+// wait for delivery.
+func (b *Broker) Publish(ctx context.Context, topic string, msg consumer.Message) error {
+	msg.Topic = topic
+	select {
+	case b.topic(topic) <- msg:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Subscribe delivers the messages of topic to handle until ctx is done.
+// Every decision is reported on Deliveries, unless 100 wait unread there.
+func (b *Broker) Subscribe(ctx context.Context, topic string, handle func(ctx context.Context, msg consumer.Message) consumer.Decision) error {
+	ch := b.topic(topic)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case msg := <-ch:
+			decision := handle(ctx, msg)
+			select {
+			case b.deliveries <- Delivery{Message: msg, Decision: decision}:
+			default:
+			}
+		}
+	}
+}
+
+// Deliveries reports the messages handled and the decisions on them, so
+// tests can wait for them.
+func (b *Broker) Deliveries() <-chan Delivery {
+	return b.deliveries
+}
+`
+
+const kafkaBrokerTemplate = `// Package kafka runs consumers on Kafka with github.com/segmentio/kafka-go.
+package kafka
+
+import (
+	"context"
+
+	kafkago "github.com/segmentio/kafka-go"
+
+	"{{.Consumer}}"
+)
+
+// Subscriber consumes topics as a member of a consumer group. Offsets are
+// committed once the runner is done with a message, so messages caught in
+// a shutdown are delivered again.
+type Subscriber struct {
+	brokers []string
+	groupID string
+}
+
+var _ consumer.Subscriber = (*Subscriber)(nil)
+
+func NewSubscriber(brokers []string, groupID string) *Subscriber {
+	return &Subscriber{brokers: brokers, groupID: groupID}
+}
+
+func (s *Subscriber) Subscribe(ctx context.Context, topic string, handle func(ctx context.Context, msg consumer.Message) consumer.Decision) error {
+	reader := kafkago.NewReader(kafkago.ReaderConfig{
+		Brokers: s.brokers,
+		GroupID: s.groupID,
+		Topic:   topic,
+	})
+	defer reader.Close()
+
+	for {
+		m, err := reader.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		headers := make(map[string]string, len(m.Headers))
+		for _, h := range m.Headers {
+			headers[h.Key] = string(h.Value)
+		}
+		msg := consumer.Message{Topic: m.Topic, Key: m.Key, Payload: m.Value, Headers: headers}
+		if handle(ctx, msg) == consumer.Retry {
+			return nil
+		}
+		if err := reader.CommitMessages(ctx, m); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+// Publisher publishes messages, keeping those with the same key in order.
+type Publisher struct {
+	writer *kafkago.Writer
+}
+
+var _ consumer.Publisher = (*Publisher)(nil)
+
+func NewPublisher(brokers []string) *Publisher {
+	return &Publisher{writer: &kafkago.Writer{
+		Addr:     kafkago.TCP(brokers...),
+		Balancer: &kafkago.Hash{},
+	}}
+}
+
+func (p *Publisher) Publish(ctx context.Context, topic string, msg consumer.Message) error {
+	headers := make([]kafkago.Header, 0, len(msg.Headers))
+	for k, v := range msg.Headers {
+		headers = append(headers, kafkago.Header{Key: k, Value: []byte(v)})
+	}
+	return p.writer.WriteMessages(ctx, kafkago.Message{
+		Topic:   topic,
+		Key:     msg.Key,
+		Value:   msg.Payload,
+		Headers: headers,
+	})
+}
+
+func (p *Publisher) Close() error {
+	return p.writer.Close()
+}
+`
+
+const consumerMainTemplate = `// Command consumer runs the message consumers registered in
+// internal/interfaces/messaging on Kafka, with the usecases of
+// internal/bootstrap. KAFKA_BROKERS lists the brokers,
+// separated by commas, and KAFKA_GROUP_ID names the consumer group.
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"{{.Consumer}}"
+	"{{.Kafka}}"
+	"{{.Bootstrap}}"
+	"{{.Messaging}}"
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	app, err := bootstrap.New(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer app.Close()
+
+	brokers := strings.Split(getenv("KAFKA_BROKERS", "localhost:9092"), ",")
+	publisher := kafka.NewPublisher(brokers)
+	defer publisher.Close()
+
+	runner := consumer.NewRunner(kafka.NewSubscriber(brokers, getenv("KAFKA_GROUP_ID", "{{.Group}}")),
+		consumer.WithDeadLetter(publisher))
+	messaging.RegisterConsumers(runner, app.Consumers)
+
+	if err := runner.Run(ctx); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func getenv(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+`
@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenerateConsumerHandler(t *testing.T) {
+	inModule(t, "app")
+	dir := filepath.Join("internal", "interfaces", "orderplaced")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	// A usecase with dependencies is served from Usecases, set by bootstrap.New
+	createUsecaseStructure(mustNames("ReserveStock"), resolveUsecaseDeps("", []string{"PaymentGateway"}))
+	generateConsumerHandler(dir, mustNames("OrderPlaced"), mustNames("ReserveStock"), "orders.placed")
+
+	// The handler, its test and the runner it registers with compile; the
+	// Kafka broker needs its module and is left out
+	for _, d := range []string{
+		filepath.Join(messagingPath(), "consumer"),
+		filepath.Join(messagingPath(), "memory"),
+		dir,
+		filepath.Dir(consumersPath()),
+	} {
+		checkPackage(t, d)
+	}
+	src, err := os.ReadFile(consumersPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "if usecases.ReserveStock != nil {\n\t\trunner.Register(orderplaced.OrderPlacedTopic, orderplaced.NewOrderPlacedConsumer(usecases.ReserveStock))\n\t} else {\n\t\tlog.Printf(\"ReserveStock is not set in bootstrap.New; %s is not consumed\", orderplaced.OrderPlacedTopic)\n\t}"
+	if !strings.Contains(string(src), want) {
+		t.Errorf("RegisterConsumers does not have\n%s\n%s", want, src)
+	}
+	if !strings.Contains(string(src), "ReserveStock reservestock.ReserveStockService") {
+		t.Errorf("Usecases does not have ReserveStock:\n%s", src)
+	}
+}
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	boundedContext string
+)
+
+var contextCmd = &cobra.Command{
+	Use:   "context [name]",
+	Short: "Generate a new bounded context",
+	Long: `Creates a bounded context under internal/contexts/<name> with its own:
+- core/ for domain entities
+- usecase/ for application services
+- interfaces/ for handlers
+- adapters/ for persistence, external services and ports
+
+The context is recorded in ` + projectFile + ` so that domain, usecase,
+handler and tests can target it with --context.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		names := mustNames(args[0])
+		createContextStructure(names)
+	},
+}
+
+func createContextStructure(names Names) {
+	// Create bounded context directories
+	contextPath := filepath.Join("internal", "contexts", names.Package)
+	dirs := []string{
+		"core",
+		"usecase",
+		"interfaces",
+		"adapters/external",
+		"adapters/persistence",
+		"adapters/ports",
+	}
+
+	for _, dir := range dirs {
+		fullPath := filepath.Join(contextPath, dir)
+		if err := os.MkdirAll(fullPath, 0755); err != nil {
+			fmt.Printf("Error creating directory %s: %v\n", fullPath, err)
+			os.Exit(1)
+		}
+	}
+
+	// Generate package documentation
+	docTemplate := `// Package {{.Package}} is the {{.Context}} bounded context. Its domain model
+// lives in core, application services in usecase, handlers in interfaces and
+// infrastructure in adapters. Other contexts should only depend on its usecase
+// and interfaces packages.
+package {{.Package}}
+`
+	generateFile(filepath.Join(contextPath, "doc.go"), docTemplate, map[string]string{
+		"Package": names.Package,
+		"Context": names.Pascal,
+	})
+
+	// Record the context in the project manifest
+	project := loadProject()
+	if !project.hasContext(names.Package) {
+		project.Contexts = append(project.Contexts, names.Package)
+		saveProject(".", project)
+	}
+
+	fmt.Printf("Successfully created bounded context %s in %s\n", names.Pascal, contextPath)
+}
+
+// addContextFlag registers --context on a generator command.
+func addContextFlag(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&boundedContext, "context", "", "Bounded context to generate into (see the context command)")
+}
+
+func InitGenContext(rootCmd *cobra.Command) {
+	rootCmd.AddCommand(contextCmd)
+}
@@ -0,0 +1,277 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// createCQRSHandler writes a command or query, its result and its handler
+// into the commands or queries package, with a test dispatching it through
+// the bus.
+func createCQRSHandler(names Names, kind string, deps []usecaseDep) {
+	pkg := toPlural(kind)
+	dir := filepath.Join(layerPath(boundedContext, "usecase"), pkg)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		fmt.Printf("Error creating %s directory: %v\n", pkg, err)
+		os.Exit(1)
+	}
+	ensureBusPackage()
+	ensureValidationPackage()
+
+	// The message is named c in command handlers and q in query handlers
+	receiver := "c"
+	if kind == "query" {
+		receiver = "q"
+	}
+	data := map[string]interface{}{
+		"Package":  pkg,
+		"Kind":     kind,
+		"Name":     names.Pascal,
+		"Receiver": receiver,
+		"Deps":     deps,
+		"Bus":      importPath(busPath()),
+
+		"Transactional": usecaseTransactional,
+	}
+
+	handlerTemplate := `package {{.Package}}
+
+{{.Imports}}
+// {{.Name}} is a {{.Kind}}. Validate checks its fields against their validate tags.
+type {{.Name}} struct {
+	// Add {{.Kind}} fields here
+}
+
+// Validate returns a *validation.Error listing every invalid field.
+func ({{.Receiver}} {{.Name}}) Validate() error {
+	return validation.Struct({{.Receiver}})
+}
+
+type {{.Name}}Result struct {
+	// Add result fields here
+}
+
+type {{.Name}}Handler struct {
+{{- range .Deps}}
+	{{.Field}} {{.Type}}
+{{- else}}
+	// Add dependencies here
+{{- end}}
+}
+
+func New{{.Name}}Handler({{range $i, $d := .Deps}}{{if $i}}, {{end}}{{$d.Field}} {{$d.Type}}{{end}}) *{{.Name}}Handler {
+	return &{{.Name}}Handler{
+{{- range .Deps}}
+		{{.Field}}: {{.Field}},
+{{- end}}
+	}
+}
+
+// Handle runs the {{.Kind}}. Register it with bus.Register(b, h.Handle).
+func (h *{{.Name}}Handler) Handle(ctx context.Context, {{.Receiver}} {{.Name}}) ({{.Name}}Result, error) {
+	if err := {{.Receiver}}.Validate(); err != nil {
+		return {{.Name}}Result{}, err
+	}
+{{- if .Transactional}}
+
+	var res {{.Name}}Result
+	err := h.unitOfWork.Do(ctx, func(ctx context.Context) error {
+		// Implement {{.Kind}} logic here; repositories called with this ctx
+		// share the transaction, which is rolled back if an error is returned
+		return nil
+	})
+	if err != nil {
+		return {{.Name}}Result{}, err
+	}
+	return res, nil
+{{- else}}
+
+	// Implement {{.Kind}} logic here
+	return {{.Name}}Result{}, nil
+{{- end}}
+}
+`
+	imports := []string{"context", importPath(validationPath())}
+	for _, dep := range deps {
+		imports = append(imports, importPath(dep.pkg.Dir))
+	}
+	data["Imports"] = renderImports(imports)
+	generateFile(filepath.Join(dir, names.Snake+".go"), handlerTemplate, data)
+
+	testTemplate := `package {{.Package}}
+
+import (
+	"context"
+	"testing"
+
+	"{{.Bus}}"
+)
+
+func Test{{.Name}}Handler(t *testing.T) {
+	b := bus.New()
+	bus.Register(b, New{{.Name}}Handler({{range $i, $d := .Deps}}{{if $i}}, {{end}}{{$d.Fake}}{{end}}).Handle)
+
+	if _, err := bus.Dispatch[{{.Name}}Result](context.Background(), b, {{.Name}}{}); err != nil {
+		t.Fatalf("Dispatch() error = %v", err)
+	}
+}
+`
+	generateFile(filepath.Join(dir, names.Snake+"_test.go"), testTemplate, data)
+	ensureFakes(dir, pkg, deps)
+
+	fmt.Printf("Successfully created %s %s in %s\n", kind, names.Pascal, dir)
+}
+
+// busPath is the directory of the command/query bus package.
+func busPath() string {
+	return filepath.Join(layerPath(boundedContext, "usecase"), "bus")
+}
+
+// ensureBusPackage writes the bus package and its test unless they exist.
+func ensureBusPackage() {
+	dir := busPath()
+	path := filepath.Join(dir, "bus.go")
+	if _, err := os.Stat(path); err == nil {
+		return
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		fmt.Printf("Error creating bus directory: %v\n", err)
+		os.Exit(1)
+	}
+	generateFile(path, "{{.}}", busCode)
+	generateFile(filepath.Join(dir, "bus_test.go"), "{{.}}", busTestCode)
+}
+
+const busCode = `// Package bus is a mediator that routes commands and queries to their
+// handlers by type, running middleware around every dispatch. Use one Bus
+// for commands and another for queries.
+package bus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// ErrNoHandler is returned by Dispatch for a message without a handler.
+var ErrNoHandler = errors.New("bus: no handler registered")
+
+// HandlerFunc handles a message of any registered type.
+type HandlerFunc func(ctx context.Context, msg any) (any, error)
+
+// Middleware wraps the handling of every message, e.g. with logging,
+// metrics or a transaction.
+type Middleware func(next HandlerFunc) HandlerFunc
+
+type Bus struct {
+	mu         sync.RWMutex
+	handlers   map[reflect.Type]HandlerFunc
+	middleware []Middleware
+}
+
+// New returns a Bus running middleware in order, the first outermost.
+func New(middleware ...Middleware) *Bus {
+	return &Bus{handlers: map[reflect.Type]HandlerFunc{}, middleware: middleware}
+}
+
+// Use appends middleware, which runs inside the middleware added before it.
+func (b *Bus) Use(middleware ...Middleware) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.middleware = append(b.middleware, middleware...)
+}
+
+// Register makes handle the handler of messages of type M. It panics if M
+// already has one, since a message must have exactly one handler.
+func Register[M, R any](b *Bus, handle func(ctx context.Context, msg M) (R, error)) {
+	t := reflect.TypeOf((*M)(nil)).Elem()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.handlers[t]; ok {
+		panic(fmt.Sprintf("bus: %v already has a handler", t))
+	}
+	b.handlers[t] = func(ctx context.Context, msg any) (any, error) {
+		return handle(ctx, msg.(M))
+	}
+}
+
+// Dispatch sends msg through the middleware to its handler and returns the
+// handler's result, which must be an R.
+func Dispatch[R any](ctx context.Context, b *Bus, msg any) (R, error) {
+	var zero R
+	b.mu.RLock()
+	h, ok := b.handlers[reflect.TypeOf(msg)]
+	middleware := b.middleware
+	b.mu.RUnlock()
+	if !ok {
+		return zero, fmt.Errorf("%w for %T", ErrNoHandler, msg)
+	}
+
+	for i := len(middleware) - 1; i >= 0; i-- {
+		h = middleware[i](h)
+	}
+	res, err := h(ctx, msg)
+	if err != nil || res == nil {
+		return zero, err
+	}
+	r, ok := res.(R)
+	if !ok {
+		return zero, fmt.Errorf("bus: %T is handled with a %T result, not %T", msg, res, zero)
+	}
+	return r, nil
+}
+`
+
+const busTestCode = `package bus
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type greet struct{ Name string }
+
+func TestDispatch(t *testing.T) {
+	var calls []string
+	trace := func(name string) Middleware {
+		return func(next HandlerFunc) HandlerFunc {
+			return func(ctx context.Context, msg any) (any, error) {
+				calls = append(calls, name)
+				return next(ctx, msg)
+			}
+		}
+	}
+	b := New(trace("outer"))
+	b.Use(trace("inner"))
+	Register(b, func(ctx context.Context, g greet) (string, error) {
+		calls = append(calls, "handler")
+		return "hello " + g.Name, nil
+	})
+
+	got, err := Dispatch[string](context.Background(), b, greet{Name: "bus"})
+	if err != nil {
+		t.Fatalf("Dispatch() error = %v", err)
+	}
+	if got != "hello bus" {
+		t.Errorf("Dispatch() = %q, want %q", got, "hello bus")
+	}
+	if want := []string{"outer", "inner", "handler"}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("calls = %v, want %v", calls, want)
+	}
+
+	if _, err := Dispatch[int](context.Background(), b, greet{}); err == nil {
+		t.Error("Dispatch() with the wrong result type succeeded")
+	}
+}
+
+func TestDispatchWithoutHandler(t *testing.T) {
+	if _, err := Dispatch[string](context.Background(), New(), greet{}); !errors.Is(err, ErrNoHandler) {
+		t.Errorf("Dispatch() error = %v, want ErrNoHandler", err)
+	}
+}
+`
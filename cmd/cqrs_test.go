@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// checkPackage type-checks the Go files of dir, its tests included when
+// they are in the same package.
+func checkPackage(t *testing.T, dir string) {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fset := token.NewFileSet()
+	var files []*ast.File
+	for _, entry := range entries {
+		if !strings.HasSuffix(entry.Name(), ".go") {
+			continue
+		}
+		file, err := parser.ParseFile(fset, filepath.Join(dir, entry.Name()), nil, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(files) > 0 && file.Name.Name != files[0].Name.Name {
+			continue
+		}
+		files = append(files, file)
+	}
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	if _, err := conf.Check(importPath(dir), fset, files, nil); err != nil {
+		t.Errorf("%s does not compile: %v", dir, err)
+	}
+}
+
+func TestCreateCQRSHandler(t *testing.T) {
+	inModule(t, "app")
+	createCQRSHandler(mustNames("PlaceOrder"), "command", nil)
+	createCQRSHandler(mustNames("GetOrder"), "query", nil)
+
+	for _, dir := range []string{busPath(), filepath.Join(layerPath(boundedContext, "usecase"), "commands"), filepath.Join(layerPath(boundedContext, "usecase"), "queries")} {
+		checkPackage(t, dir)
+	}
+	src, err := os.ReadFile(filepath.Join(layerPath(boundedContext, "usecase"), "queries", "get_order.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(src), "func (h *GetOrderHandler) Handle(ctx context.Context, q GetOrder) (GetOrderResult, error)") {
+		t.Errorf("query handler does not take q GetOrder:\n%s", src)
+	}
+}
@@ -0,0 +1,1093 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	crudFields     []string
+	crudIDStrategy string
+	crudGRPC       bool
+)
+
+var crudCmd = &cobra.Command{
+	Use:   "crud [name]",
+	Short: "Generate a complete CRUD slice for an entity",
+	Long: `Creates everything needed to create, read, list, update and delete an
+entity, wired together and tested:
+- Domain entity with a typed ID, value objects and a context repository
+- Create, Get, List, Update and Delete usecases with tests
+- In-memory repository, plus a database/sql repository and a migration when
+  the project database is postgres or mysql
+- An HTTP handler per usecase for the project's router, with an end-to-end
+  test of the routes
+- An rpc per usecase with --grpc, with the .proto of the service
+
+Fields are given as name:type, e.g.
+  go-ddd-skel crud Product --field name:string --field price:Money
+
+Types are string, int, int32, int64, float64, bool, time and the value
+objects Email, Decimal and Money. Prefix a primitive type with * to make the
+field optional, e.g. --field note:*string.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if _, ok := idStrategies[crudIDStrategy]; !ok {
+			fmt.Println("Unsupported ID strategy. Use --id [uuid|ulid|snowflake|int64]")
+			os.Exit(1)
+		}
+		var fields []crudField
+		for _, spec := range crudFields {
+			field, err := parseCrudField(spec)
+			if err != nil {
+				fmt.Printf("Invalid field %q: %v\n", spec, err)
+				os.Exit(1)
+			}
+			fields = append(fields, field)
+		}
+		createCrudStructure(mustNames(args[0]), fields)
+	},
+}
+
+// crudPrimitives maps the primitive field types accepted by --field to Go types.
+var crudPrimitives = map[string]string{
+	"string":    "string",
+	"int":       "int",
+	"int32":     "int32",
+	"int64":     "int64",
+	"float64":   "float64",
+	"bool":      "bool",
+	"time":      "time.Time",
+	"time.time": "time.Time",
+}
+
+// crudValueObjects gives the type each value object is sent as over the
+// wire, and whether it has a validating constructor.
+var crudValueObjects = map[string]struct {
+	Transport string
+	Validated bool
+}{
+	"Email":   {Transport: "string", Validated: true},
+	"Decimal": {Transport: "string", Validated: true},
+	"Money":   {Transport: "int64"},
+}
+
+// crudSamples are valid example values of each wire type, used by the tests.
+var crudSamples = map[string]string{
+	"string":    `"example"`,
+	"int":       "42",
+	"int32":     "42",
+	"int64":     "42",
+	"float64":   "1.5",
+	"bool":      "true",
+	"time.Time": "time.Date(2024, time.January, 2, 3, 4, 5, 0, time.UTC)",
+	"Email":     `"user@example.com"`,
+	"Decimal":   `"9.99"`,
+	"Money":     "999",
+}
+
+// crudField is an entity field together with its request/response form.
+type crudField struct {
+	domainField
+	JSON      string
+	Transport string
+	Validated bool
+	// Sample is a Go literal of the wire type, empty for optional fields
+	Sample string
+}
+
+// parseCrudField parses a name:type --field value.
+func parseCrudField(spec string) (crudField, error) {
+	name, typ, ok := strings.Cut(spec, ":")
+	if !ok || name == "" || typ == "" {
+		return crudField{}, fmt.Errorf("want name:type")
+	}
+	names, err := newNames(name)
+	if err != nil {
+		return crudField{}, err
+	}
+	if names.Pascal == "ID" {
+		return crudField{}, fmt.Errorf("the ID field is generated")
+	}
+
+	optional := strings.HasPrefix(typ, "*")
+	typ = strings.TrimPrefix(typ, "*")
+	f := crudField{
+		domainField: domainField{Name: names.Pascal, Column: names.Snake},
+		JSON:        names.Camel,
+	}
+	if goType, ok := crudPrimitives[strings.ToLower(typ)]; ok {
+		f.Type, f.Transport, f.Sample = goType, goType, crudSamples[goType]
+	} else {
+		vo := toPascal(typ)
+		def, ok := crudValueObjects[vo]
+		if !ok {
+			return crudField{}, fmt.Errorf("unknown type %s", typ)
+		}
+		if optional {
+			return crudField{}, fmt.Errorf("value objects cannot be optional")
+		}
+		f.Type, f.ValueObject, f.Transport, f.Validated, f.Sample = vo, vo, def.Transport, def.Validated, crudSamples[vo]
+	}
+	f.StorageType = f.Transport
+	if optional {
+		f.Type, f.StorageType, f.Transport, f.Sample = "*"+f.Type, "*"+f.StorageType, "*"+f.Transport, ""
+	}
+	return f, nil
+}
+
+// Assign returns the statements copying the field from req onto the entity
+// e, adding to verr when a value object rejects the value.
+func (f crudField) Assign(domain string) string {
+	switch {
+	case f.Validated:
+		return fmt.Sprintf("if v, err := %s.New%s(req.%s); err != nil {\n\tverr.Add(%q, err.Error())\n} else {\n\te.%s = v\n}",
+			domain, f.ValueObject, f.Name, f.JSON, f.Name)
+	case f.ValueObject != "":
+		return fmt.Sprintf("e.%s = %s.%s(req.%s)", f.Name, domain, f.ValueObject, f.Name)
+	default:
+		return fmt.Sprintf("e.%s = req.%s", f.Name, f.Name)
+	}
+}
+
+// FromEntity returns the field of the entity e in its wire type.
+func (f crudField) FromEntity() string {
+	if f.ValueObject != "" {
+		return fmt.Sprintf("%s(e.%s)", f.Transport, f.Name)
+	}
+	return "e." + f.Name
+}
+
+// Differs returns a condition that is true when the field of a and b differ.
+func (f crudField) Differs(a, b string) string {
+	if f.Transport == "time.Time" {
+		return fmt.Sprintf("!%s.%s.Equal(%s.%s)", a, f.Name, b, f.Name)
+	}
+	return fmt.Sprintf("%s.%s != %s.%s", a, f.Name, b, f.Name)
+}
+
+// crudUsecase is one of the generated usecase packages.
+type crudUsecase struct {
+	Names   Names
+	Package string
+	Service string
+	Import  string
+	Dir     string
+	// Handler is the type of its HTTP handler
+	Handler string
+}
+
+func newCrudUsecase(name string) crudUsecase {
+	names := mustNames(name)
+	dir := filepath.Join(layerPath(boundedContext, "usecase"), names.Package)
+	return crudUsecase{Names: names, Package: names.Package, Service: names.Pascal + "Service", Import: importPath(dir), Dir: dir,
+		Handler: names.Pascal + "HTTPHandler"}
+}
+
+// crudData is the template data of every file generated by crud.
+type crudData struct {
+	Spec              domainSpec
+	Fields            []crudField
+	Domain            string
+	DomainImport      string
+	ValidationImport  string
+	PersistenceImport string
+	Route             string
+	Create            crudUsecase
+	Get               crudUsecase
+	List              crudUsecase
+	Update            crudUsecase
+	Delete            crudUsecase
+}
+
+// IDString returns the ID expression id converted to a string.
+func (d crudData) IDString(id string) string {
+	if d.Spec.IDBase() == "string" {
+		return "string(" + id + ")"
+	}
+	return id + ".String()"
+}
+
+// Validated reports whether any field rejects invalid values.
+func (d crudData) Validated() bool {
+	for _, f := range d.Fields {
+		if f.Validated {
+			return true
+		}
+	}
+	return false
+}
+
+// ModelImports lists the packages needed by the request/response fields.
+func (d crudData) ModelImports() string {
+	var types []string
+	for _, f := range d.Fields {
+		types = append(types, f.Transport)
+	}
+	return renderImports(append(typeImports(types), d.ValidationImport))
+}
+
+func createCrudStructure(names Names, fields []crudField) {
+	database := loadProject().Database
+	withSQL := database == "postgres" || database == "mysql"
+
+	spec := domainSpec{Names: names, IDType: "string"}
+	for _, f := range fields {
+		spec.Fields = append(spec.Fields, f.domainField)
+		if f.ValueObject != "" && !containsString(spec.ValueObjects, f.ValueObject) {
+			spec.ValueObjects = append(spec.ValueObjects, f.ValueObject)
+		}
+	}
+
+	// The generators read the domain command's options
+	repoStyle = "context"
+	idStrategy = crudIDStrategy
+	withMigration = withSQL
+	spec = spec.withEntityOptions().withIDStrategy()
+	createDomainStructure(spec)
+
+	persistencePath := filepath.Join(layerPath(boundedContext, "adapters/persistence"), names.Package)
+	data := crudData{
+		Spec:              spec,
+		Fields:            fields,
+		Domain:            names.Package,
+		DomainImport:      importPath(spec.CoreDir()),
+		ValidationImport:  importPath(validationPath()),
+		PersistenceImport: importPath(persistencePath),
+		Route:             "/" + names.Plural,
+		Create:            newCrudUsecase("Create" + names.Pascal),
+		Get:               newCrudUsecase("Get" + names.Pascal),
+		List:              newCrudUsecase("List" + toPascal(names.Plural)),
+		Update:            newCrudUsecase("Update" + names.Pascal),
+		Delete:            newCrudUsecase("Delete" + names.Pascal),
+	}
+
+	if withSQL {
+		generateSQLRepository(spec)
+	} else {
+		fmt.Printf("Note: no SQL repository or migration for database %q; %s is stored in memory only\n", database, names.Pascal)
+	}
+	generateMemoryRepository(data, persistencePath)
+
+	ensureValidationPackage()
+	deps := resolveUsecaseDeps(names.Raw, []string{spec.Repository()})
+	generateCrudUsecases(data, deps)
+	generateCrudHandlers(data)
+
+	if withSQL || crudGRPC || projectRouter().Name != "net/http" {
+		tidyModule()
+	}
+
+	fmt.Printf("Successfully created CRUD for %s\n", names.Pascal)
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// generateMemoryRepository writes a map-backed implementation of the
+// repository, for tests and for running without a database.
+func generateMemoryRepository(data crudData, persistencePath string) {
+	if err := os.MkdirAll(persistencePath, 0755); err != nil {
+		fmt.Printf("Error creating persistence directory: %v\n", err)
+		os.Exit(1)
+	}
+
+	memoryTemplate := `package {{.Domain}}
+
+import (
+	"context"
+	"maps"
+	"sort"
+{{- if ne .Spec.IDBase "string"}}
+	"strconv"
+{{- end}}
+	"sync"
+
+	domain "{{.DomainImport}}"
+)
+
+// InMemory{{.Spec.Entity}}Repository stores {{.Spec.Entity}} entities in a map. It
+// is safe for concurrent use and hands out copies, so callers cannot change
+// stored entities without saving them.
+type InMemory{{.Spec.Entity}}Repository struct {
+	mu    sync.RWMutex
+	items map[domain.{{.Spec.IDType}}]domain.{{.Spec.Entity}}
+}
+
+var _ domain.{{.Spec.Repository}} = (*InMemory{{.Spec.Entity}}Repository)(nil)
+
+func NewInMemory{{.Spec.Entity}}Repository() *InMemory{{.Spec.Entity}}Repository {
+	return &InMemory{{.Spec.Entity}}Repository{items: map[domain.{{.Spec.IDType}}]domain.{{.Spec.Entity}}{}}
+}
+
+func (r *InMemory{{.Spec.Entity}}Repository) Save(ctx context.Context, e *domain.{{.Spec.Entity}}) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.items[e.ID] = *e
+	return nil
+}
+
+func (r *InMemory{{.Spec.Entity}}Repository) FindByID(ctx context.Context, id domain.{{.Spec.IDType}}) (*domain.{{.Spec.Entity}}, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	e, ok := r.items[id]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	return &e, nil
+}
+
+func (r *InMemory{{.Spec.Entity}}Repository) Delete(ctx context.Context, id domain.{{.Spec.IDType}}) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.items[id]; !ok {
+		return domain.ErrNotFound
+	}
+	delete(r.items, id)
+	return nil
+}
+
+// List pages through the entities in ID order. The cursor is the last ID of the previous page.
+func (r *InMemory{{.Spec.Entity}}Repository) List(ctx context.Context, filter domain.ListFilter, cursor string, limit int) (*domain.Page, error) {
+{{- if ne .Spec.IDBase "string"}}
+	var after int64
+	if cursor != "" {
+		var err error
+		if after, err = strconv.ParseInt(cursor, 10, 64); err != nil {
+			return nil, err
+		}
+	}
+{{- end}}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	// Apply filter fields here
+	page := &domain.Page{}
+	for _, e := range r.items {
+{{- if eq .Spec.IDBase "string"}}
+		if cursor == "" || string(e.ID) > cursor {
+{{- else}}
+		if cursor == "" || int64(e.ID) > after {
+{{- end}}
+			page.Items = append(page.Items, &e)
+		}
+	}
+	sort.Slice(page.Items, func(i, j int) bool { return page.Items[i].ID < page.Items[j].ID })
+	if limit > 0 && len(page.Items) > limit {
+		page.Items = page.Items[:limit]
+		page.NextCursor = {{.IDString "page.Items[limit-1].ID"}}
+	}
+	return page, nil
+}
+
+// Snapshot returns a function restoring the current entities, which lets a
+// memtx unit of work roll the repository back.
+func (r *InMemory{{.Spec.Entity}}Repository) Snapshot() (restore func()) {
+	r.mu.RLock()
+	items := maps.Clone(r.items)
+	r.mu.RUnlock()
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.items = items
+	}
+}
+`
+	generateFile(filepath.Join(persistencePath, "memory_repository.go"), memoryTemplate, data)
+}
+
+// generateCrudUsecases writes the five usecases, each with a test against
+// a fake repository.
+func generateCrudUsecases(data crudData, deps []usecaseDep) {
+	serviceTemplate := `package {{.Package}}
+
+import "context"
+
+type {{.Service}} interface {
+	Execute(ctx context.Context, req *Request) (*Response, error)
+}
+`
+	usecases := []struct {
+		Usecase crudUsecase
+		Models  string
+		Impl    string
+		Test    string
+	}{
+		{data.Create, crudCreateModels, crudCreateImpl, crudCreateTest},
+		{data.Get, crudEntityModels, crudGetImpl, crudGetTest},
+		{data.List, crudListModels, crudListImpl, crudListTest},
+		{data.Update, crudUpdateModels, crudUpdateImpl, crudUpdateTest},
+		{data.Delete, crudDeleteModels, crudDeleteImpl, crudDeleteTest},
+	}
+	for _, uc := range usecases {
+		if err := os.MkdirAll(uc.Usecase.Dir, 0755); err != nil {
+			fmt.Printf("Error creating usecase directory: %v\n", err)
+			os.Exit(1)
+		}
+		tmplData := map[string]interface{}{"D": data, "U": uc.Usecase}
+		generateFile(filepath.Join(uc.Usecase.Dir, "service.go"), serviceTemplate, uc.Usecase)
+		generateFile(filepath.Join(uc.Usecase.Dir, "models.go"), uc.Models, tmplData)
+		generateFile(filepath.Join(uc.Usecase.Dir, "service_impl.go"), uc.Impl, tmplData)
+
+		testPath := filepath.Join(uc.Usecase.Dir, "service_test.go")
+		generateFile(testPath, uc.Test, tmplData)
+		removeUnusedImports(testPath)
+		generateFakes(uc.Usecase.Dir, uc.Usecase.Package, deps)
+
+		fmt.Printf("Created usecase %s in %s\n", uc.Usecase.Service, uc.Usecase.Dir)
+	}
+}
+
+// The Create, Get and Update responses are the entity in its wire form.
+const crudEntityModels = `package {{.U.Package}}
+
+{{.D.ModelImports}}
+type Request struct {
+	ID string ` + "`json:\"id\" validate:\"required\"`" + `
+}
+
+// Validate returns a *validation.Error listing every invalid field.
+func (r *Request) Validate() error {
+	return validation.Struct(r)
+}
+
+type Response struct {
+	ID string ` + "`json:\"id\"`" + `
+{{- range .D.Fields}}
+	{{.Name}} {{.Transport}} ` + "`json:\"{{.JSON}}\"`" + `
+{{- end}}
+}
+`
+
+const crudCreateModels = `package {{.U.Package}}
+
+{{.D.ModelImports}}
+type Request struct {
+{{- range .D.Fields}}
+	{{.Name}} {{.Transport}} ` + "`json:\"{{.JSON}}\"`" + `
+{{- end}}
+}
+
+// Validate returns a *validation.Error listing every invalid field.
+func (r *Request) Validate() error {
+	return validation.Struct(r)
+}
+
+type Response struct {
+	ID string ` + "`json:\"id\"`" + `
+{{- range .D.Fields}}
+	{{.Name}} {{.Transport}} ` + "`json:\"{{.JSON}}\"`" + `
+{{- end}}
+}
+`
+
+const crudUpdateModels = `package {{.U.Package}}
+
+{{.D.ModelImports}}
+// Request replaces every field of the {{.D.Spec.Entity}} with the given ID.
+type Request struct {
+	ID string ` + "`json:\"id\" validate:\"required\"`" + `
+{{- range .D.Fields}}
+	{{.Name}} {{.Transport}} ` + "`json:\"{{.JSON}}\"`" + `
+{{- end}}
+}
+
+// Validate returns a *validation.Error listing every invalid field.
+func (r *Request) Validate() error {
+	return validation.Struct(r)
+}
+
+type Response struct {
+	ID string ` + "`json:\"id\"`" + `
+{{- range .D.Fields}}
+	{{.Name}} {{.Transport}} ` + "`json:\"{{.JSON}}\"`" + `
+{{- end}}
+}
+`
+
+const crudListModels = `package {{.U.Package}}
+
+{{.D.ModelImports}}
+// Request asks for the page after Cursor. A zero Limit uses the default page size.
+type Request struct {
+	Cursor string ` + "`json:\"cursor\"`" + `
+	Limit  int    ` + "`json:\"limit\" validate:\"min=0\"`" + `
+}
+
+// Validate returns a *validation.Error listing every invalid field.
+func (r *Request) Validate() error {
+	return validation.Struct(r)
+}
+
+type Item struct {
+	ID string ` + "`json:\"id\"`" + `
+{{- range .D.Fields}}
+	{{.Name}} {{.Transport}} ` + "`json:\"{{.JSON}}\"`" + `
+{{- end}}
+}
+
+// Response is one page of {{plural .D.Spec.Entity}}. NextCursor is empty on the last page.
+type Response struct {
+	Items      []Item ` + "`json:\"items\"`" + `
+	NextCursor string ` + "`json:\"nextCursor,omitempty\"`" + `
+}
+`
+
+const crudDeleteModels = `package {{.U.Package}}
+
+import "{{.D.ValidationImport}}"
+
+type Request struct {
+	ID string ` + "`json:\"id\" validate:\"required\"`" + `
+}
+
+// Validate returns a *validation.Error listing every invalid field.
+func (r *Request) Validate() error {
+	return validation.Struct(r)
+}
+
+type Response struct{}
+`
+
+// crudToResponse maps the entity e to the Response of Create, Get and Update.
+const crudToResponse = `
+func toResponse(e *{{.D.Domain}}.{{.D.Spec.Entity}}) *Response {
+	return &Response{
+		ID: {{.D.IDString "e.ID"}},
+{{- range .D.Fields}}
+		{{.Name}}: {{.FromEntity}},
+{{- end}}
+	}
+}
+`
+
+// crudApply copies the request fields of Create and Update onto the entity.
+const crudApply = `
+// apply copies the request fields onto e, reporting every invalid field.
+func apply(e *{{.D.Domain}}.{{.D.Spec.Entity}}, req *Request) error {
+	verr := &validation.Error{}
+{{- range .D.Fields}}
+	{{.Assign $.D.Domain}}
+{{- end}}
+	return verr.OrNil()
+}
+`
+
+const crudRepositoryField = `{{camel .D.Spec.Repository}} {{.D.Domain}}.{{.D.Spec.Repository}}`
+
+const crudSingleRepositoryService = `type service struct {
+	` + crudRepositoryField + `
+}
+
+func NewService(` + crudRepositoryField + `) {{.U.Service}} {
+	return &service{ {{- camel .D.Spec.Repository}}: {{camel .D.Spec.Repository -}} }
+}
+`
+
+const crudParseID = `id, err := {{.D.Domain}}.Parse{{.D.Spec.IDType}}(req.ID)
+	if err != nil {
+		return nil, validation.NewError("id", err.Error())
+	}`
+
+const crudCreateImpl = `package {{.U.Package}}
+
+import (
+	"context"
+
+	"{{.D.DomainImport}}"
+	"{{.D.ValidationImport}}"
+)
+
+type service struct {
+	` + crudRepositoryField + `
+	ids {{.D.Domain}}.IDGenerator
+}
+
+func NewService(` + crudRepositoryField + `, ids {{.D.Domain}}.IDGenerator) {{.U.Service}} {
+	return &service{ {{- camel .D.Spec.Repository}}: {{camel .D.Spec.Repository}}, ids: ids}
+}
+
+func (s *service) Execute(ctx context.Context, req *Request) (*Response, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	e := &{{.D.Domain}}.{{.D.Spec.Entity}}{ID: s.ids.NewID()}
+	if err := apply(e, req); err != nil {
+		return nil, err
+	}
+	if err := s.{{camel .D.Spec.Repository}}.Save(ctx, e); err != nil {
+		return nil, err
+	}
+	return toResponse(e), nil
+}
+` + crudApply + crudToResponse
+
+const crudGetImpl = `package {{.U.Package}}
+
+import (
+	"context"
+
+	"{{.D.DomainImport}}"
+	"{{.D.ValidationImport}}"
+)
+
+` + crudSingleRepositoryService + `
+func (s *service) Execute(ctx context.Context, req *Request) (*Response, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	` + crudParseID + `
+	e, err := s.{{camel .D.Spec.Repository}}.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return toResponse(e), nil
+}
+` + crudToResponse
+
+const crudListImpl = `package {{.U.Package}}
+
+import (
+	"context"
+
+	"{{.D.DomainImport}}"
+)
+
+const (
+	defaultLimit = 20
+	maxLimit     = 100
+)
+
+` + crudSingleRepositoryService + `
+func (s *service) Execute(ctx context.Context, req *Request) (*Response, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	limit := req.Limit
+	switch {
+	case limit <= 0:
+		limit = defaultLimit
+	case limit > maxLimit:
+		limit = maxLimit
+	}
+
+	page, err := s.{{camel .D.Spec.Repository}}.List(ctx, {{.D.Domain}}.ListFilter{}, req.Cursor, limit)
+	if err != nil {
+		return nil, err
+	}
+	resp := &Response{Items: make([]Item, 0, len(page.Items)), NextCursor: page.NextCursor}
+	for _, e := range page.Items {
+		resp.Items = append(resp.Items, Item{
+			ID: {{.D.IDString "e.ID"}},
+{{- range .D.Fields}}
+			{{.Name}}: {{.FromEntity}},
+{{- end}}
+		})
+	}
+	return resp, nil
+}
+`
+
+const crudUpdateImpl = `package {{.U.Package}}
+
+import (
+	"context"
+
+	"{{.D.DomainImport}}"
+	"{{.D.ValidationImport}}"
+)
+
+` + crudSingleRepositoryService + `
+func (s *service) Execute(ctx context.Context, req *Request) (*Response, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	` + crudParseID + `
+	e, err := s.{{camel .D.Spec.Repository}}.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := apply(e, req); err != nil {
+		return nil, err
+	}
+	if err := s.{{camel .D.Spec.Repository}}.Save(ctx, e); err != nil {
+		return nil, err
+	}
+	return toResponse(e), nil
+}
+` + crudApply + crudToResponse
+
+const crudDeleteImpl = `package {{.U.Package}}
+
+import (
+	"context"
+
+	"{{.D.DomainImport}}"
+	"{{.D.ValidationImport}}"
+)
+
+` + crudSingleRepositoryService + `
+func (s *service) Execute(ctx context.Context, req *Request) (*Response, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	` + crudParseID + `
+	if err := s.{{camel .D.Spec.Repository}}.Delete(ctx, id); err != nil {
+		return nil, err
+	}
+	return &Response{}, nil
+}
+`
+
+// The test templates import every package they might use; unused imports
+// are removed after rendering.
+const crudTestImports = `import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"{{.D.DomainImport}}"
+	"{{.D.ValidationImport}}"
+)
+`
+
+const crudSampleFields = ` {{- range .D.Fields}}{{if .Sample}}
+		{{.Name}}: {{.Sample}},
+{{- end}}{{end}}`
+
+const crudCheckFields = `{{range .D.Fields}}{{if .Sample}}
+	if {{.Differs "resp" "req"}} {
+		t.Errorf("{{.Name}} = %v, want %v", resp.{{.Name}}, req.{{.Name}})
+	}
+{{- end}}{{end}}`
+
+const crudCreateTest = `package {{.U.Package}}
+
+` + crudTestImports + `
+func Test{{.U.Service}}(t *testing.T) {
+	var saved *{{.D.Domain}}.{{.D.Spec.Entity}}
+	repo := &fake{{.D.Spec.Repository}}{saveFunc: func(_ context.Context, e *{{.D.Domain}}.{{.D.Spec.Entity}}) error {
+		saved = e
+		return nil
+	}}
+	svc := NewService(repo, &{{.D.Domain}}.FakeIDGenerator{})
+
+	req := &Request{` + crudSampleFields + `
+	}
+	resp, err := svc.Execute(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if saved == nil {
+		t.Fatal("Execute() did not save the {{.D.Spec.Entity}}")
+	}
+	if want := {{.D.IDString "saved.ID"}}; resp.ID != want {
+		t.Errorf("ID = %q, want %q", resp.ID, want)
+	}` + crudCheckFields + `
+}
+{{- if .D.Validated}}
+
+func Test{{.U.Service}}Invalid(t *testing.T) {
+	svc := NewService(&fake{{.D.Spec.Repository}}{}, &{{.D.Domain}}.FakeIDGenerator{})
+
+	_, err := svc.Execute(context.Background(), &Request{})
+	var verr *validation.Error
+	if !errors.As(err, &verr) {
+		t.Fatalf("Execute() error = %v, want a validation error", err)
+	}
+}
+{{- end}}
+`
+
+const crudGetTest = `package {{.U.Package}}
+
+` + crudTestImports + `
+func Test{{.U.Service}}(t *testing.T) {
+	ids := &{{.D.Domain}}.FakeIDGenerator{}
+	id, missing := ids.NewID(), ids.NewID()
+	repo := &fake{{.D.Spec.Repository}}{findByIDFunc: func(_ context.Context, got {{.D.Domain}}.{{.D.Spec.IDType}}) (*{{.D.Domain}}.{{.D.Spec.Entity}}, error) {
+		if got != id {
+			return nil, {{.D.Domain}}.ErrNotFound
+		}
+		return &{{.D.Domain}}.{{.D.Spec.Entity}}{ID: id}, nil
+	}}
+	svc := NewService(repo)
+
+	resp, err := svc.Execute(context.Background(), &Request{ID: {{.D.IDString "id"}}})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if want := {{.D.IDString "id"}}; resp.ID != want {
+		t.Errorf("ID = %q, want %q", resp.ID, want)
+	}
+
+	if _, err := svc.Execute(context.Background(), &Request{ID: {{.D.IDString "missing"}}}); !errors.Is(err, {{.D.Domain}}.ErrNotFound) {
+		t.Errorf("Execute(missing) error = %v, want ErrNotFound", err)
+	}
+
+	var verr *validation.Error
+	if _, err := svc.Execute(context.Background(), &Request{ID: "not-an-id"}); !errors.As(err, &verr) {
+		t.Errorf("Execute(not-an-id) error = %v, want a validation error", err)
+	}
+}
+`
+
+const crudListTest = `package {{.U.Package}}
+
+` + crudTestImports + `
+func Test{{.U.Service}}(t *testing.T) {
+	id := (&{{.D.Domain}}.FakeIDGenerator{}).NewID()
+	var gotLimit int
+	repo := &fake{{.D.Spec.Repository}}{listFunc: func(_ context.Context, _ {{.D.Domain}}.ListFilter, _ string, limit int) (*{{.D.Domain}}.Page, error) {
+		gotLimit = limit
+		return &{{.D.Domain}}.Page{Items: []*{{.D.Domain}}.{{.D.Spec.Entity}}{ {ID: id} }, NextCursor: "next"}, nil
+	}}
+	svc := NewService(repo)
+
+	resp, err := svc.Execute(context.Background(), &Request{})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if gotLimit != defaultLimit {
+		t.Errorf("limit = %d, want %d", gotLimit, defaultLimit)
+	}
+	if len(resp.Items) != 1 || resp.Items[0].ID != {{.D.IDString "id"}} {
+		t.Errorf("Items = %+v, want the one stored {{.D.Spec.Entity}}", resp.Items)
+	}
+	if resp.NextCursor != "next" {
+		t.Errorf("NextCursor = %q, want %q", resp.NextCursor, "next")
+	}
+}
+`
+
+const crudUpdateTest = `package {{.U.Package}}
+
+` + crudTestImports + `
+func Test{{.U.Service}}(t *testing.T) {
+	id := (&{{.D.Domain}}.FakeIDGenerator{}).NewID()
+	var saved *{{.D.Domain}}.{{.D.Spec.Entity}}
+	repo := &fake{{.D.Spec.Repository}}{
+		findByIDFunc: func(_ context.Context, got {{.D.Domain}}.{{.D.Spec.IDType}}) (*{{.D.Domain}}.{{.D.Spec.Entity}}, error) {
+			if got != id {
+				return nil, {{.D.Domain}}.ErrNotFound
+			}
+			return &{{.D.Domain}}.{{.D.Spec.Entity}}{ID: id}, nil
+		},
+		saveFunc: func(_ context.Context, e *{{.D.Domain}}.{{.D.Spec.Entity}}) error {
+			saved = e
+			return nil
+		},
+	}
+	svc := NewService(repo)
+
+	req := &Request{
+		ID: {{.D.IDString "id"}},` + crudSampleFields + `
+	}
+	resp, err := svc.Execute(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if saved == nil || saved.ID != id {
+		t.Fatalf("saved = %+v, want the updated {{.D.Spec.Entity}}", saved)
+	}` + crudCheckFields + `
+}
+`
+
+const crudDeleteTest = `package {{.U.Package}}
+
+` + crudTestImports + `
+func Test{{.U.Service}}(t *testing.T) {
+	id := (&{{.D.Domain}}.FakeIDGenerator{}).NewID()
+	var deleted {{.D.Domain}}.{{.D.Spec.IDType}}
+	repo := &fake{{.D.Spec.Repository}}{deleteFunc: func(_ context.Context, got {{.D.Domain}}.{{.D.Spec.IDType}}) error {
+		deleted = got
+		return nil
+	}}
+	svc := NewService(repo)
+
+	if _, err := svc.Execute(context.Background(), &Request{ID: {{.D.IDString "id"}}}); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if deleted != id {
+		t.Errorf("deleted %v, want %v", deleted, id)
+	}
+}
+`
+
+// generateCrudHandlers writes an HTTP handler per usecase for the project's
+// router, as the handler command does, with an end-to-end test of the
+// routes against the in-memory repository and, with --grpc, an rpc per
+// usecase. Every handler is registered.
+func generateCrudHandlers(data crudData) {
+	handlerPath := filepath.Join(layerPath(boundedContext, "interfaces"), data.Domain)
+	if err := os.MkdirAll(handlerPath, 0755); err != nil {
+		fmt.Printf("Error creating handler directory: %v\n", err)
+		os.Exit(1)
+	}
+
+	routes := []struct {
+		usecase      crudUsecase
+		method, path string
+	}{
+		{data.Create, "POST", data.Route},
+		{data.List, "GET", data.Route},
+		{data.Get, "GET", data.Route + "/{id}"},
+		{data.Update, "PUT", data.Route + "/{id}"},
+		{data.Delete, "DELETE", data.Route + "/{id}"},
+	}
+	for _, r := range routes {
+		generateUsecaseHTTPHandler(handlerPath, data.Spec.Names, r.usecase.Names, r.method, r.path)
+	}
+
+	testTemplate := `package {{.Domain}}
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+{{range .Router.Imports}}{{if ne . "net/http"}}
+	"{{.}}"{{end}}{{end}}
+
+	persistence "{{.PersistenceImport}}"
+	domain "{{.DomainImport}}"
+	"{{.Create.Import}}"
+	"{{.Get.Import}}"
+	"{{.List.Import}}"
+	"{{.Update.Import}}"
+	"{{.Delete.Import}}"
+)
+
+// Test{{.Spec.Entity}}Routes runs every route against the in-memory repository.
+func Test{{.Spec.Entity}}Routes(t *testing.T) {
+	repo := persistence.NewInMemory{{.Spec.Entity}}Repository()
+	router := {{.Router.New}}
+	New{{.Create.Handler}}({{.Create.Package}}.NewService(repo, &domain.FakeIDGenerator{})).RegisterRoutes(router)
+	New{{.Get.Handler}}({{.Get.Package}}.NewService(repo)).RegisterRoutes(router)
+	New{{.List.Handler}}({{.List.Package}}.NewService(repo)).RegisterRoutes(router)
+	New{{.Update.Handler}}({{.Update.Package}}.NewService(repo)).RegisterRoutes(router)
+	New{{.Delete.Handler}}({{.Delete.Package}}.NewService(repo)).RegisterRoutes(router)
+
+	rec := serve(t, router, http.MethodPost, "{{.Route}}", {{.Create.Package}}.Request{ {{- template "samples" .}}
+	})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create: status %d, body %s", rec.Code, rec.Body)
+	}
+	var created {{.Create.Package}}.Response
+	decodeBody(t, rec, &created)
+	path := "{{.Route}}/" + created.ID
+
+	if rec := serve(t, router, http.MethodGet, path, nil); rec.Code != http.StatusOK {
+		t.Fatalf("get: status %d, body %s", rec.Code, rec.Body)
+	}
+
+	rec = serve(t, router, http.MethodGet, "{{.Route}}?limit=10", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("list: status %d, body %s", rec.Code, rec.Body)
+	}
+	var list {{.List.Package}}.Response
+	decodeBody(t, rec, &list)
+	if len(list.Items) != 1 {
+		t.Fatalf("list: got %d items, want 1", len(list.Items))
+	}
+	if rec := serve(t, router, http.MethodGet, "{{.Route}}?limit=many", nil); rec.Code != http.StatusBadRequest {
+		t.Fatalf("list invalid limit: status %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+
+	if rec := serve(t, router, http.MethodPut, path, {{.Update.Package}}.Request{ {{- template "samples" .}}
+	}); rec.Code != http.StatusOK {
+		t.Fatalf("update: status %d, body %s", rec.Code, rec.Body)
+	}
+	if rec := serve(t, router, http.MethodDelete, path, nil); rec.Code != http.StatusNoContent {
+		t.Fatalf("delete: status %d, body %s", rec.Code, rec.Body)
+	}
+	if rec := serve(t, router, http.MethodGet, path, nil); rec.Code != http.StatusNotFound {
+		t.Fatalf("get after delete: status %d, want %d", rec.Code, http.StatusNotFound)
+	}
+	if rec := serve(t, router, http.MethodGet, "{{.Route}}/not-an-id", nil); rec.Code != http.StatusBadRequest {
+		t.Fatalf("get invalid id: status %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
+
+// serve sends a request with body encoded as JSON, or no body if it is nil.
+func serve(t *testing.T, h http.Handler, method, path string, body interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+	var r io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r = bytes.NewReader(b)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(method, path, r))
+	return rec
+}
+
+func decodeBody(t *testing.T, rec *httptest.ResponseRecorder, v interface{}) {
+	t.Helper()
+	if err := json.Unmarshal(rec.Body.Bytes(), v); err != nil {
+		t.Fatalf("decoding %s: %v", rec.Body, err)
+	}
+}
+{{define "samples"}}{{range .Fields}}{{if .Sample}}
+		{{.Name}}: {{.Sample}},
+{{- end}}{{end}}{{end}}`
+	testPath := filepath.Join(handlerPath, data.Spec.Names.Snake+"_routes_test.go")
+	generateFile(testPath, testTemplate, struct {
+		crudData
+		Router httpRouter
+	}{data, projectRouter()})
+	removeUnusedImports(testPath)
+
+	fmt.Printf("Created handlers for %s in %s\n", data.Spec.Entity(), handlerPath)
+
+	if crudGRPC {
+		grpcPath := grpcHandlerPath(data.Spec.Names)
+		if err := os.MkdirAll(grpcPath, 0755); err != nil {
+			fmt.Printf("Error creating handler directory: %v\n", err)
+			os.Exit(1)
+		}
+		for _, r := range routes {
+			generateUsecaseGRPCHandler(grpcPath, data.Spec.Names, r.usecase.Names, "")
+		}
+		fmt.Printf("Created gRPC handlers for %s in %s\n", data.Spec.Entity(), grpcPath)
+	}
+}
+
+// tidyModule runs go mod tidy to fetch the modules the generated code
+// imports, past packages that do not exist yet, such as those protoc is
+// still to generate. Failing is not fatal: the code is in place and tidy
+// can be rerun.
+func tidyModule() {
+	cmd := exec.Command("go", "mod", "tidy", "-e")
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		fmt.Printf("Warning: go mod tidy failed (%v); run it once the modules are reachable\n", err)
+	}
+}
+
+func InitGenCrud(rootCmd *cobra.Command) {
+	rootCmd.AddCommand(crudCmd)
+	crudCmd.Flags().StringArrayVar(&crudFields, "field", nil, "Entity field as name:type, repeatable")
+	crudCmd.Flags().StringVar(&crudIDStrategy, "id", "uuid", "ID strategy (uuid|ulid|snowflake|int64)")
+	crudCmd.Flags().BoolVar(&crudGRPC, "grpc", false, "Also generate a gRPC handler")
+	addContextFlag(crudCmd)
+}
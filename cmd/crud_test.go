@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestParseCrudField(t *testing.T) {
+	tests := []struct {
+		spec string
+		want crudField
+		err  string
+	}{
+		{"name:string", crudField{
+			domainField: domainField{Name: "Name", Column: "name", Type: "string", StorageType: "string"},
+			JSON:        "name", Transport: "string", Sample: `"example"`,
+		}, ""},
+		{"created_at:Time", crudField{
+			domainField: domainField{Name: "CreatedAt", Column: "created_at", Type: "time.Time", StorageType: "time.Time"},
+			JSON:        "createdAt", Transport: "time.Time", Sample: "time.Date(2024, time.January, 2, 3, 4, 5, 0, time.UTC)",
+		}, ""},
+		{"note:*string", crudField{
+			domainField: domainField{Name: "Note", Column: "note", Type: "*string", StorageType: "*string"},
+			JSON:        "note", Transport: "*string",
+		}, ""},
+		{"email:Email", crudField{
+			domainField: domainField{Name: "Email", Column: "email", Type: "Email", StorageType: "string", ValueObject: "Email"},
+			JSON:        "email", Transport: "string", Validated: true, Sample: `"user@example.com"`,
+		}, ""},
+		{"price:money", crudField{
+			domainField: domainField{Name: "Price", Column: "price", Type: "Money", StorageType: "int64", ValueObject: "Money"},
+			JSON:        "price", Transport: "int64", Sample: "999",
+		}, ""},
+		{"name", crudField{}, "want name:type"},
+		{":string", crudField{}, "want name:type"},
+		{"id:string", crudField{}, "the ID field is generated"},
+		{"size:uint8", crudField{}, "unknown type uint8"},
+		{"price:*Money", crudField{}, "value objects cannot be optional"},
+	}
+	for _, tt := range tests {
+		got, err := parseCrudField(tt.spec)
+		if tt.err != "" {
+			if err == nil || err.Error() != tt.err {
+				t.Errorf("parseCrudField(%q) error = %v, want %q", tt.spec, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseCrudField(%q) error = %v", tt.spec, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseCrudField(%q) =\n%+v\nwant\n%+v", tt.spec, got, tt.want)
+		}
+	}
+}
+
+func TestCrudFieldCode(t *testing.T) {
+	tests := []struct {
+		spec       string
+		assign     string
+		fromEntity string
+		differs    string
+	}{
+		{"name:string", "e.Name = req.Name", "e.Name", "a.Name != b.Name"},
+		{"email:Email", "if v, err := product.NewEmail(req.Email); err != nil {\n\tverr.Add(\"email\", err.Error())\n} else {\n\te.Email = v\n}",
+			"string(e.Email)", "a.Email != b.Email"},
+		{"price:Money", "e.Price = product.Money(req.Price)", "int64(e.Price)", "a.Price != b.Price"},
+		{"shipped:time", "e.Shipped = req.Shipped", "e.Shipped", "!a.Shipped.Equal(b.Shipped)"},
+	}
+	for _, tt := range tests {
+		f, err := parseCrudField(tt.spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := f.Assign("product"); got != tt.assign {
+			t.Errorf("%s: Assign() =\n%s\nwant\n%s", tt.spec, got, tt.assign)
+		}
+		if got := f.FromEntity(); got != tt.fromEntity {
+			t.Errorf("%s: FromEntity() = %q, want %q", tt.spec, got, tt.fromEntity)
+		}
+		if got := f.Differs("a", "b"); got != tt.differs {
+			t.Errorf("%s: Differs() = %q, want %q", tt.spec, got, tt.differs)
+		}
+	}
+}
+
+func TestCrudWiring(t *testing.T) {
+	inModule(t, "app")
+	crudIDStrategy = "uuid"
+	t.Cleanup(func() { crudIDStrategy = "" })
+	if err := os.WriteFile(projectFile, []byte(`{"router": "net/http", "database": "none"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	field, err := parseCrudField("name:string")
+	if err != nil {
+		t.Fatal(err)
+	}
+	createCrudStructure(mustNames("Product"), []crudField{field})
+
+	// Every route is served by a usecase on the in-memory repository
+	got := newBody(t)
+	for _, want := range []string{
+		"productRepository := productpersistence.NewInMemoryProductRepository()",
+		"productIDGenerator := product.NewIDGenerator()",
+		"app.HTTP.CreateProduct = createproduct.NewService(productRepository, productIDGenerator)",
+		"app.HTTP.GetProduct = getproduct.NewService(productRepository)",
+		"app.HTTP.ListProducts = listproducts.NewService(productRepository)",
+		"app.HTTP.UpdateProduct = updateproduct.NewService(productRepository)",
+		"app.HTTP.DeleteProduct = deleteproduct.NewService(productRepository)",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("New does not have %q:\n%s", want, got)
+		}
+	}
+}
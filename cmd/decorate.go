@@ -0,0 +1,545 @@
+package cmd
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var decorateCmd = &cobra.Command{
+	Use:   "decorate [usecase] [decorator,decorator,...]",
+	Short: "Add cross-cutting decorators to an existing use case",
+	Long: `Wraps the service of a use case with decorators implementing its
+Service interface, so the service itself stays free of infrastructure code:
+- logging: structured log/slog record of every execution
+- metrics: Prometheus-style request counter and duration histogram through
+  the Metrics port
+- tracing: an OpenTelemetry span around every execution
+- retry: retries with exponential backoff, skipping invalid requests
+
+NewService takes the decorators as options and returns the decorated chain:
+
+	createuser.NewService(repo, createuser.WithRetry(3, 100*time.Millisecond), createuser.WithLogging(logger))
+
+Options apply in order, the first innermost. Decorators already present are
+kept, and NewService is only changed the first time.`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		decorateUsecase(mustNames(args[0]), splitList(args[1]))
+	},
+}
+
+// decorators are the templates of the decorators a usecase service can be
+// wrapped with, each with its test. Their data is a decoratorData.
+var decorators = map[string]struct{ Code, Test string }{
+	"logging": {decoratorLogging, decoratorLoggingTest},
+	"metrics": {decoratorMetrics, decoratorMetricsTest},
+	"tracing": {decoratorTracing, decoratorTracingTest},
+	"retry":   {decoratorRetry, decoratorRetryTest},
+}
+
+type decoratorData struct {
+	Package    string
+	Name       string
+	Service    string
+	Ports      string
+	Validation string
+}
+
+// validateDecorators exits unless every name is a known decorator.
+func validateDecorators(names []string) {
+	for _, name := range names {
+		if _, ok := decorators[name]; !ok {
+			var known []string
+			for k := range decorators {
+				known = append(known, k)
+			}
+			sort.Strings(known)
+			fmt.Printf("Unknown decorator %q. Use %s\n", name, strings.Join(known, ", "))
+			os.Exit(1)
+		}
+	}
+}
+
+// decorateUsecase writes the named decorators of an existing usecase and
+// makes its NewService accept them as options.
+func decorateUsecase(names Names, with []string) {
+	validateDecorators(with)
+	dir := filepath.Join(layerPath(boundedContext, "usecase"), names.Package)
+	data := decoratorData{
+		Package:    names.Package,
+		Name:       names.Pascal,
+		Service:    names.Pascal + "Service",
+		Ports:      importPath(portsPath()),
+		Validation: importPath(validationPath()),
+	}
+
+	pkg, err := parseSourcePackage(dir)
+	if err != nil {
+		fmt.Printf("Error reading usecase %s: %v\n", names.Pascal, err)
+		os.Exit(1)
+	}
+	methods, err := pkg.interfaceMethods(data.Service)
+	if err != nil {
+		fmt.Printf("Error reading %s: %v\n", data.Service, err)
+		os.Exit(1)
+	}
+	// Decorators wrap Execute(ctx, req), which usecases generated before
+	// contexts were passed do not have
+	for _, m := range methods {
+		if m.Name != "Execute" || m.Type.Params.NumFields() != 2 {
+			fmt.Printf("%s must have only Execute(ctx context.Context, req *Request) (*Response, error) to be decorated\n", data.Service)
+			os.Exit(1)
+		}
+	}
+
+	writeIfMissing(filepath.Join(dir, "decorate.go"), decorateTemplate, data)
+	writeIfMissing(filepath.Join(dir, "decorate_test.go"), decorateTestTemplate, data)
+	tidy := false
+	for _, name := range with {
+		path := filepath.Join(dir, name+".go")
+		if _, err := os.Stat(path); err == nil {
+			continue
+		}
+		if name == "metrics" {
+			ensurePort("Metrics")
+		}
+		generateFile(path, decorators[name].Code, data)
+		testPath := filepath.Join(dir, name+"_test.go")
+		generateFile(testPath, decorators[name].Test, data)
+		removeUnusedImports(testPath)
+		tidy = tidy || name == "tracing"
+	}
+	addServiceOptions(dir)
+	if tidy {
+		tidyModule()
+	}
+
+	fmt.Printf("Successfully decorated usecase %s with %s\n", names.Pascal, strings.Join(with, ", "))
+}
+
+// addServiceOptions changes NewService in dir to take ...Option and return
+// the service they decorate, leaving the rest of the file as it is.
+func addServiceOptions(dir string) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		fmt.Printf("Error reading %s: %v\n", dir, err)
+		os.Exit(1)
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		path := filepath.Join(dir, name)
+		src, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Printf("Error reading %s: %v\n", path, err)
+			os.Exit(1)
+		}
+		fset := token.NewFileSet()
+		file, err := parser.ParseFile(fset, path, src, parser.ParseComments)
+		if err != nil {
+			fmt.Printf("Error parsing %s: %v\n", path, err)
+			os.Exit(1)
+		}
+		for _, decl := range file.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Recv != nil || fn.Name.Name != "NewService" || fn.Body == nil {
+				continue
+			}
+			if hasOptionsParam(fn) {
+				return
+			}
+			writeFormatted(path, insertServiceOptions(src, fset, fn))
+			return
+		}
+	}
+	fmt.Printf("NewService not found in %s\n", dir)
+	os.Exit(1)
+}
+
+func hasOptionsParam(fn *ast.FuncDecl) bool {
+	params := fn.Type.Params.List
+	if len(params) == 0 {
+		return false
+	}
+	ellipsis, ok := params[len(params)-1].Type.(*ast.Ellipsis)
+	if !ok {
+		return false
+	}
+	ident, ok := ellipsis.Elt.(*ast.Ident)
+	return ok && ident.Name == "Option"
+}
+
+// insertServiceOptions returns src with an opts ...Option parameter added to
+// fn and every value fn returns passed through decorate.
+func insertServiceOptions(src []byte, fset *token.FileSet, fn *ast.FuncDecl) []byte {
+	type edit struct {
+		offset int
+		text   string
+	}
+	offset := func(pos token.Pos) int { return fset.Position(pos).Offset }
+
+	param := "opts ...Option"
+	if fn.Type.Params.NumFields() > 0 {
+		param = ", " + param
+	}
+	edits := []edit{{offset(fn.Type.Params.Closing), param}}
+	if fn.Doc == nil {
+		edits = append(edits, edit{offset(fn.Pos()), "// NewService returns the service decorated with opts, innermost first.\n"})
+	}
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.ReturnStmt:
+			if len(n.Results) == 1 {
+				edits = append(edits,
+					edit{offset(n.Results[0].Pos()), "decorate("},
+					edit{offset(n.Results[0].End()), ", opts)"})
+			}
+		}
+		return true
+	})
+
+	// Apply the edits from the end so earlier offsets stay valid
+	sort.SliceStable(edits, func(i, j int) bool { return edits[i].offset > edits[j].offset })
+	out := append([]byte(nil), src...)
+	for _, e := range edits {
+		out = append(out[:e.offset], append([]byte(e.text), out[e.offset:]...)...)
+	}
+	return out
+}
+
+const decorateTemplate = `package {{.Package}}
+
+// Option wraps the service returned by NewService in a decorator adding a
+// cross-cutting concern, such as WithLogging or WithRetry. Options apply in
+// order, so the first is innermost, next to the service.
+type Option func({{.Service}}) {{.Service}}
+
+func decorate(svc {{.Service}}, opts []Option) {{.Service}} {
+	for _, opt := range opts {
+		svc = opt(svc)
+	}
+	return svc
+}
+`
+
+const decorateTestTemplate = `package {{.Package}}
+
+import "context"
+
+// stubService is a {{.Service}} running a function, for testing decorators.
+type stubService func(ctx context.Context, req *Request) (*Response, error)
+
+func (f stubService) Execute(ctx context.Context, req *Request) (*Response, error) {
+	return f(ctx, req)
+}
+`
+
+const decoratorLogging = `package {{.Package}}
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+type loggingService struct {
+	next   {{.Service}}
+	logger *slog.Logger
+}
+
+// WithLogging logs every execution with its duration, and its error if it fails.
+func WithLogging(logger *slog.Logger) Option {
+	return func(next {{.Service}}) {{.Service}} {
+		return &loggingService{next: next, logger: logger}
+	}
+}
+
+func (s *loggingService) Execute(ctx context.Context, req *Request) (*Response, error) {
+	start := time.Now()
+	resp, err := s.next.Execute(ctx, req)
+	attrs := []slog.Attr{
+		slog.String("usecase", "{{.Name}}"),
+		slog.Duration("duration", time.Since(start)),
+	}
+	if err != nil {
+		s.logger.LogAttrs(ctx, slog.LevelError, "usecase failed", append(attrs, slog.Any("error", err))...)
+		return resp, err
+	}
+	s.logger.LogAttrs(ctx, slog.LevelInfo, "usecase executed", attrs...)
+	return resp, nil
+}
+`
+
+const decoratorLoggingTest = `package {{.Package}}
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestWithLogging(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+	failed := errors.New("failed")
+	svc := decorate(stubService(func(ctx context.Context, req *Request) (*Response, error) {
+		return nil, failed
+	}), []Option{WithLogging(logger)})
+
+	if _, err := svc.Execute(context.Background(), &Request{}); !errors.Is(err, failed) {
+		t.Fatalf("Execute() error = %v, want %v", err, failed)
+	}
+	for _, want := range []string{` + "`\"level\":\"ERROR\"`, `\"usecase\":\"{{.Name}}\"`, `\"error\":\"failed\"`" + `} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("log %s does not contain %s", buf.String(), want)
+		}
+	}
+}
+`
+
+const decoratorMetrics = `package {{.Package}}
+
+import (
+	"context"
+	"time"
+
+	"{{.Ports}}"
+)
+
+type metricsService struct {
+	next    {{.Service}}
+	metrics ports.Metrics
+}
+
+// WithMetrics counts executions in usecase_requests_total, labelled with the
+// usecase and an outcome of success or error, and observes their duration
+// in usecase_duration_seconds.
+func WithMetrics(metrics ports.Metrics) Option {
+	return func(next {{.Service}}) {{.Service}} {
+		return &metricsService{next: next, metrics: metrics}
+	}
+}
+
+func (s *metricsService) Execute(ctx context.Context, req *Request) (*Response, error) {
+	start := time.Now()
+	resp, err := s.next.Execute(ctx, req)
+	outcome := "success"
+	if err != nil {
+		outcome = "error"
+	}
+	s.metrics.IncCounter("usecase_requests_total", map[string]string{"usecase": "{{.Name}}", "outcome": outcome})
+	s.metrics.ObserveHistogram("usecase_duration_seconds", time.Since(start).Seconds(), map[string]string{"usecase": "{{.Name}}"})
+	return resp, err
+}
+`
+
+const decoratorMetricsTest = `package {{.Package}}
+
+import (
+	"context"
+	"testing"
+)
+
+type recordedMetrics struct {
+	counters     map[string]int
+	observations int
+}
+
+func (m *recordedMetrics) IncCounter(name string, labels map[string]string) {
+	m.counters[name+"/"+labels["outcome"]]++
+}
+
+func (m *recordedMetrics) ObserveHistogram(name string, value float64, labels map[string]string) {
+	m.observations++
+}
+
+func TestWithMetrics(t *testing.T) {
+	metrics := &recordedMetrics{counters: map[string]int{}}
+	svc := decorate(stubService(func(ctx context.Context, req *Request) (*Response, error) {
+		return &Response{}, nil
+	}), []Option{WithMetrics(metrics)})
+
+	if _, err := svc.Execute(context.Background(), &Request{}); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if metrics.counters["usecase_requests_total/success"] != 1 || metrics.observations != 1 {
+		t.Errorf("recorded %v and %d observations, want one success and one observation", metrics.counters, metrics.observations)
+	}
+}
+`
+
+const decoratorTracing = `package {{.Package}}
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type tracingService struct {
+	next   {{.Service}}
+	tracer trace.Tracer
+}
+
+// WithTracing runs every execution in a {{.Name}} span, which records the
+// error if it fails. Pass otel.Tracer(...) to use the global provider.
+func WithTracing(tracer trace.Tracer) Option {
+	return func(next {{.Service}}) {{.Service}} {
+		return &tracingService{next: next, tracer: tracer}
+	}
+}
+
+func (s *tracingService) Execute(ctx context.Context, req *Request) (*Response, error) {
+	ctx, span := s.tracer.Start(ctx, "{{.Name}}")
+	defer span.End()
+
+	resp, err := s.next.Execute(ctx, req)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return resp, err
+}
+`
+
+const decoratorTracingTest = `package {{.Package}}
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func TestWithTracing(t *testing.T) {
+	failed := errors.New("failed")
+	svc := decorate(stubService(func(ctx context.Context, req *Request) (*Response, error) {
+		return nil, failed
+	}), []Option{WithTracing(noop.NewTracerProvider().Tracer("test"))})
+
+	if _, err := svc.Execute(context.Background(), &Request{}); !errors.Is(err, failed) {
+		t.Fatalf("Execute() error = %v, want %v", err, failed)
+	}
+}
+`
+
+const decoratorRetry = `package {{.Package}}
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"{{.Validation}}"
+)
+
+type retryService struct {
+	next     {{.Service}}
+	attempts int
+	backoff  time.Duration
+}
+
+// WithRetry runs Execute up to attempts times, waiting backoff before the
+// second attempt and twice as long before each one after it. Invalid
+// requests and cancelled contexts are not retried. Only decorate usecases
+// that are safe to run again after a failure.
+func WithRetry(attempts int, backoff time.Duration) Option {
+	return func(next {{.Service}}) {{.Service}} {
+		return &retryService{next: next, attempts: attempts, backoff: backoff}
+	}
+}
+
+func (s *retryService) Execute(ctx context.Context, req *Request) (*Response, error) {
+	wait := s.backoff
+	for attempt := 1; ; attempt++ {
+		resp, err := s.next.Execute(ctx, req)
+		if err == nil || attempt >= s.attempts || !retryable(err) {
+			return resp, err
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, err
+		case <-timer.C:
+		}
+		wait *= 2
+	}
+}
+
+// retryable reports whether trying again may succeed where err failed.
+func retryable(err error) bool {
+	var invalid *validation.Error
+	return !errors.As(err, &invalid) &&
+		!errors.Is(err, context.Canceled) &&
+		!errors.Is(err, context.DeadlineExceeded)
+}
+`
+
+const decoratorRetryTest = `package {{.Package}}
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"{{.Validation}}"
+)
+
+func TestWithRetry(t *testing.T) {
+	calls := 0
+	svc := decorate(stubService(func(ctx context.Context, req *Request) (*Response, error) {
+		calls++
+		if calls < 3 {
+			return nil, errors.New("temporary failure")
+		}
+		return &Response{}, nil
+	}), []Option{WithRetry(3, time.Millisecond)})
+
+	if _, err := svc.Execute(context.Background(), &Request{}); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("calls = %d, want 3", calls)
+	}
+}
+
+func TestWithRetryInvalidRequest(t *testing.T) {
+	calls := 0
+	svc := decorate(stubService(func(ctx context.Context, req *Request) (*Response, error) {
+		calls++
+		return nil, validation.NewError("field", "is required")
+	}), []Option{WithRetry(3, time.Millisecond)})
+
+	if _, err := svc.Execute(context.Background(), &Request{}); err == nil {
+		t.Fatal("Execute() succeeded, want the validation error")
+	}
+	if calls != 1 {
+		t.Errorf("calls = %d, want 1", calls)
+	}
+}
+`
+
+func InitGenDecorate(rootCmd *cobra.Command) {
+	rootCmd.AddCommand(decorateCmd)
+	addContextFlag(decorateCmd)
+}
@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAddServiceOptions(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "service.go")
+	src := `package restock
+
+func NewService(repo Repository) RestockService {
+	if repo == nil {
+		return &service{}
+	}
+	build := func() RestockService { return &service{repo: repo} }
+	return build()
+}
+`
+	if err := os.WriteFile(path, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	want := `package restock
+
+// NewService returns the service decorated with opts, innermost first.
+func NewService(repo Repository, opts ...Option) RestockService {
+	if repo == nil {
+		return decorate(&service{}, opts)
+	}
+	build := func() RestockService { return &service{repo: repo} }
+	return decorate(build(), opts)
+}
+`
+	// Decorating again leaves the options as they are
+	for i := 0; i < 2; i++ {
+		addServiceOptions(dir)
+		got, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != want {
+			t.Errorf("addServiceOptions() #%d =\n%s\nwant\n%s", i+1, got, want)
+		}
+	}
+}
@@ -1,31 +1,326 @@
 package cmd
 
 import (
+	"bytes"
 	"fmt"
+	"go/format"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"text/template"
 
 	"github.com/spf13/cobra"
 )
 
+var (
+	repoStyle     string
+	fromSQL       string
+	sqlTableName  string
+	fromSchema    string
+	fromOpenAPI   string
+	withFactory   bool
+	versioned     bool
+	audited       bool
+	idStrategy    string
+	withMigration bool
+)
+
 var domainCmd = &cobra.Command{
 	Use:   "domain [name]",
 	Short: "Generate a new domain entity",
 	Long: `Creates a new domain entity with:
 - Entity struct
 - Repository interface
-- Value objects (optional)`,
-	Args: cobra.ExactArgs(1),
+- Value objects (optional)
+
+Use --repo-style context to generate a context-aware repository with
+an ErrNotFound sentinel, Delete and cursor-paginated List.
+
+Use --from-sql to generate the entity, repository interface and persistence
+model from CREATE TABLE statements. Without --table every table is generated.
+
+Use --from-schema file.json#/definitions/User or
+--from-openapi api.yaml#/components/schemas/User to generate the entity,
+nested value objects and enums from a schema. Required and format
+constraints become checks in a validating constructor.
+
+Use --factory to add a factory that builds the aggregate from other
+aggregates or external data.
+
+Use --versioned to add a Version field for optimistic concurrency and
+--audited to add CreatedAt, UpdatedAt, CreatedBy and DeletedAt. Either flag
+also generates a database/sql repository adapter that saves with
+compare-and-swap updates and filters out soft-deleted rows.
+
+Use --id uuid|ulid|snowflake|int64 to give the entity a distinct ID type
+(e.g. UserID) with Parse and Validate helpers, an IDGenerator port and a
+deterministic FakeIDGenerator for tests.
+
+Use --migration to also write a CREATE TABLE migration for the entity in the
+project's database dialect (see the migration command).`,
+	Args: cobra.RangeArgs(0, 1),
 	Run: func(cmd *cobra.Command, args []string) {
-		domainName := args[0]
-		createDomainStructure(domainName)
+		if repoStyle != "simple" && repoStyle != "context" {
+			fmt.Println("Unsupported repository style. Use --repo-style [simple|context]")
+			os.Exit(1)
+		}
+		if !validIDStrategy(idStrategy) {
+			fmt.Println("Unsupported ID strategy. Use --id [uuid|ulid|snowflake|int64]")
+			os.Exit(1)
+		}
+		if withMigration {
+			// Fail before generating anything if the database has no migrations
+			migrationDialect()
+		}
+
+		switch {
+		case fromSQL != "":
+			createDomainsFromSQL(fromSQL, sqlTableName, args)
+			return
+		case fromSchema != "":
+			createDomainFromSchema(fromSchema, args)
+			return
+		case fromOpenAPI != "":
+			createDomainFromSchema(fromOpenAPI, args)
+			return
+		}
+		if len(args) == 0 {
+			fmt.Println("Missing domain name. Use domain [name] or domain --from-sql [file]")
+			os.Exit(1)
+		}
+		createDomainStructure(domainSpec{Names: mustNames(args[0]), IDType: "string"})
 	},
 }
 
-func createDomainStructure(domainName string) {
+// domainSpec describes everything generated for a domain. The zero set of
+// fields produces the plain entity with an ID and a placeholder comment.
+type domainSpec struct {
+	Names      Names
+	IDType     string
+	IDStrategy string
+	// IDUnderlying is the underlying type of a typed ID such as UserID.
+	IDUnderlying string
+	IDColumn     string
+	Table        string
+	Fields       []domainField
+	ValueObjects []string
+	Versioned    bool
+	Audited      bool
+
+	// Validations are statements on the receiver e that append to errs.
+	// When present the entity gets a validating constructor.
+	Validations       []string
+	ValidationImports []string
+	// Decls are extra declarations, such as nested value objects and enums,
+	// written to value_objects.go.
+	Decls            []valueObjectDef
+	Enums            []string
+	ValidatedStructs []string
+}
+
+// domainField is an entity field. StorageType is the type used by the
+// persistence model, which differs from Type for value objects.
+type domainField struct {
+	Name        string
+	Column      string
+	Type        string
+	StorageType string
+	ValueObject string
+}
+
+// ModelValue converts the field of the entity e to its storage type.
+func (f domainField) ModelValue() string {
+	if f.ValueObject == "" {
+		return "e." + f.Name
+	}
+	return convertExpr("e."+f.Name, f.StorageType)
+}
+
+// EntityValue converts the field of the model m back to its domain type.
+func (f domainField) EntityValue() string {
+	if f.ValueObject == "" {
+		return "m." + f.Name
+	}
+	return convertExpr("m."+f.Name, strings.Replace(f.Type, f.ValueObject, "domain."+f.ValueObject, 1))
+}
+
+// convertExpr converts expr to typ, parenthesizing pointer types.
+func convertExpr(expr, typ string) string {
+	if strings.HasPrefix(typ, "*") {
+		typ = "(" + typ + ")"
+	}
+	return typ + "(" + expr + ")"
+}
+
+func (s domainSpec) Domain() string     { return s.Names.Package }
+func (s domainSpec) Entity() string     { return s.Names.Pascal }
+func (s domainSpec) Repository() string { return s.Names.Pascal + "Repository" }
+
+// TableName is the table the entity is stored in.
+func (s domainSpec) TableName() string {
+	if s.Table != "" {
+		return s.Table
+	}
+	return toPlural(s.Names.Snake)
+}
+
+// IDColumnName is the column the entity ID is stored in.
+func (s domainSpec) IDColumnName() string {
+	if s.IDColumn != "" {
+		return s.IDColumn
+	}
+	return "id"
+}
+
+func (s domainSpec) hasField(name string) bool {
+	for _, f := range s.Fields {
+		if f.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// withEntityOptions adds the fields requested by --versioned and --audited,
+// keeping any that already came from a schema.
+func (s domainSpec) withEntityOptions() domainSpec {
+	var extra []domainField
+	if versioned {
+		s.Versioned = true
+		extra = append(extra, domainField{Name: "Version", Column: "version", Type: "int64"})
+	}
+	if audited {
+		s.Audited = true
+		extra = append(extra,
+			domainField{Name: "CreatedAt", Column: "created_at", Type: "time.Time"},
+			domainField{Name: "UpdatedAt", Column: "updated_at", Type: "time.Time"},
+			domainField{Name: "CreatedBy", Column: "created_by", Type: "string"},
+			domainField{Name: "DeletedAt", Column: "deleted_at", Type: "*time.Time"},
+		)
+	}
+	for _, f := range extra {
+		if !s.hasField(f.Name) {
+			f.StorageType = f.Type
+			s.Fields = append(s.Fields, f)
+		}
+	}
+	return s
+}
+
+// withIDStrategy gives the entity its own ID type when --id is set.
+func (s domainSpec) withIDStrategy() domainSpec {
+	if idStrategy != "" {
+		s.IDStrategy = idStrategy
+		s.IDType = s.Names.Pascal + "ID"
+		s.IDUnderlying = idStrategies[idStrategy]
+	}
+	return s
+}
+
+// TypedID reports whether the ID has its own type declared in the domain.
+func (s domainSpec) TypedID() bool {
+	return s.IDUnderlying != ""
+}
+
+// IDBase is the underlying type the ID is stored as.
+func (s domainSpec) IDBase() string {
+	if s.TypedID() {
+		return s.IDUnderlying
+	}
+	return s.IDType
+}
+
+// QualifiedIDType is the ID type as written outside the domain package,
+// where the domain is imported as "domain".
+func (s domainSpec) QualifiedIDType() string {
+	if s.TypedID() {
+		return "domain." + s.IDType
+	}
+	return s.IDType
+}
+
+// CoreDir is the domain package directory, inside the bounded context if one
+// was selected with --context.
+func (s domainSpec) CoreDir() string {
+	return filepath.Join(layerPath(boundedContext, "core"), s.Names.Package)
+}
+
+// EntityImports lists the packages needed by the entity's field types.
+func (s domainSpec) EntityImports() []string {
+	types := []string{s.IDType}
+	for _, f := range s.Fields {
+		types = append(types, f.Type)
+	}
+	return mergeImports(typeImports(types), s.ValidationImports)
+}
+
+// StorageImports lists the packages needed by the persistence model.
+func (s domainSpec) StorageImports() []string {
+	types := []string{s.IDBase()}
+	for _, f := range s.Fields {
+		types = append(types, f.StorageType)
+	}
+	return typeImports(types)
+}
+
+func (s domainSpec) hasEnum(name string) bool {
+	for _, e := range s.Enums {
+		if e == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (s domainSpec) hasValidatedStruct(name string) bool {
+	for _, v := range s.ValidatedStructs {
+		if v == name {
+			return true
+		}
+	}
+	return false
+}
+
+func mergeImports(lists ...[]string) []string {
+	seen := map[string]bool{}
+	var imports []string
+	for _, list := range lists {
+		for _, imp := range list {
+			if !seen[imp] {
+				seen[imp] = true
+				imports = append(imports, imp)
+			}
+		}
+	}
+	sort.Strings(imports)
+	return imports
+}
+
+func typeImports(types []string) []string {
+	seen := map[string]bool{}
+	for _, t := range types {
+		t = strings.TrimLeft(t, "*[]")
+		switch {
+		case strings.HasPrefix(t, "time."):
+			seen["time"] = true
+		case strings.HasPrefix(t, "json."):
+			seen["encoding/json"] = true
+		}
+	}
+	imports := make([]string, 0, len(seen))
+	for imp := range seen {
+		imports = append(imports, imp)
+	}
+	sort.Strings(imports)
+	return imports
+}
+
+func createDomainStructure(spec domainSpec) {
+	spec = spec.withEntityOptions().withIDStrategy()
+
 	// Create domain directory
-	domainPath := filepath.Join("internal/core", domainName)
+	domainPath := spec.CoreDir()
 	if err := os.MkdirAll(domainPath, 0755); err != nil {
 		fmt.Printf("Error creating domain directory: %v\n", err)
 		os.Exit(1)
@@ -33,50 +328,381 @@ func createDomainStructure(domainName string) {
 
 	// Generate entity file
 	entityTemplate := `package {{.Domain}}
-
+{{with .EntityImports}}
+import (
+{{- range .}}
+	"{{.}}"
+{{- end}}
+)
+{{end}}
 type {{.Entity}} struct {
-	ID string
+	ID {{.IDType}}
+{{- range .Fields}}
+	{{.Name}} {{.Type}}
+{{- end}}
 	// Add domain-specific fields here
 }
+{{- if .Validations}}
+
+// New{{.Entity}} returns a {{.Entity}} after checking the constraints from its schema.
+func New{{.Entity}}(e {{.Entity}}) (*{{.Entity}}, error) {
+	if err := e.Validate(); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// Validate checks the constraints from the {{.Entity}} schema.
+func (e {{.Entity}}) Validate() error {
+	var errs []error
+{{- range .Validations}}
+	{{.}}
+{{- end}}
+	return errors.Join(errs...)
+}
+{{- end}}
 `
-	generateFile(filepath.Join(domainPath, "entity.go"), entityTemplate, map[string]string{
-		"Domain": domainName,
-		"Entity": domainName,
-	})
+	generateFile(filepath.Join(domainPath, "entity.go"), entityTemplate, spec)
+
+	if len(spec.ValueObjects) > 0 || len(spec.Decls) > 0 {
+		generateValueObjects(domainPath, spec.Names.Package, append(lookupValueObjects(spec.ValueObjects), spec.Decls...))
+	}
 
 	// Generate repository interface
+	if repoStyle == "context" {
+		generateContextRepository(domainPath, spec)
+	} else {
+		generateSimpleRepository(domainPath, spec)
+	}
+
+	if repoStyle == "context" || spec.Versioned || spec.Audited {
+		generateDomainErrors(domainPath, spec)
+	}
+
+	if spec.Table != "" {
+		generatePersistenceModel(spec)
+	}
+
+	if spec.Versioned || spec.Audited {
+		generateSQLRepository(spec)
+	}
+
+	if spec.IDStrategy != "" {
+		generateTypedID(domainPath, spec)
+	}
+
+	if withFactory {
+		generateFactory(domainPath, spec)
+	}
+
+	if withMigration {
+		generateDomainMigration(spec)
+	}
+
+	fmt.Printf("Successfully created domain %s in %s\n", spec.Names.Pascal, domainPath)
+}
+
+func generateSimpleRepository(domainPath string, spec domainSpec) {
 	repoTemplate := `package {{.Domain}}
 
 type {{.Repository}} interface {
 	Save(entity *{{.Entity}}) error
-	FindByID(id string) (*{{.Entity}}, error)
+	FindByID(id {{.IDType}}) (*{{.Entity}}, error)
 	// Add additional repository methods here
 }
 `
-	generateFile(filepath.Join(domainPath, "repository.go"), repoTemplate, map[string]string{
-		"Domain":     domainName,
-		"Repository": domainName + "Repository",
-		"Entity":     domainName,
-	})
+	generateFile(filepath.Join(domainPath, "repository.go"), repoTemplate, spec)
+}
+
+func generateDomainErrors(domainPath string, spec domainSpec) {
+	errorsTemplate := `package {{.Domain}}
+
+import (
+	"errors"
+{{- if .Versioned}}
+	"fmt"
+{{- end}}
+)
+
+// ErrNotFound is returned when a {{.Entity}} does not exist.
+var ErrNotFound = errors.New("{{.Domain}}: not found")
+{{- if .Versioned}}
+
+// ErrConcurrentModification is returned when a {{.Entity}} is saved from a
+// stale copy, i.e. someone else saved a newer version since it was loaded.
+// Reload the entity and retry the operation.
+type ErrConcurrentModification struct {
+	ID      {{.IDType}}
+	Version int64
+}
+
+func (e *ErrConcurrentModification) Error() string {
+	return fmt.Sprintf("{{.Domain}}: %v was modified concurrently (stale version %d)", e.ID, e.Version)
+}
+{{- end}}
+`
+	generateFile(filepath.Join(domainPath, "errors.go"), errorsTemplate, spec)
+}
+
+func generateContextRepository(domainPath string, spec domainSpec) {
+	// Generate context-aware repository interface
+	repoTemplate := `package {{.Domain}}
+
+import "context"
+
+// ListFilter narrows the {{.Entity}} entities returned by List.
+type ListFilter struct {
+	// Add filter fields here
+}
+
+// Page is a single page of List results. NextCursor is empty on the last page.
+type Page struct {
+	Items      []*{{.Entity}}
+	NextCursor string
+}
+
+type {{.Repository}} interface {
+	Save(ctx context.Context, entity *{{.Entity}}) error
+	// FindByID returns ErrNotFound when no {{.Entity}} has the given id.
+	FindByID(ctx context.Context, id {{.IDType}}) (*{{.Entity}}, error)
+	// Delete returns ErrNotFound when no {{.Entity}} has the given id.
+	Delete(ctx context.Context, id {{.IDType}}) error
+	// List returns up to limit entities after cursor. An empty cursor starts from the beginning.
+	List(ctx context.Context, filter ListFilter, cursor string, limit int) (*Page, error)
+	// Add additional repository methods here
+}
+`
+	generateFile(filepath.Join(domainPath, "repository.go"), repoTemplate, spec)
+}
+
+func generateFactory(domainPath string, spec domainSpec) {
+	factoryTemplate := `package {{.Domain}}
+
+// {{.Entity}}Factory builds {{.Entity}} aggregates from other aggregates or
+// external data, keeping that construction logic out of the entity itself.
+type {{.Entity}}Factory struct {
+{{- if .IDStrategy}}
+	ids IDGenerator
+{{- end}}
+	// Add dependencies such as clocks here
+}
+
+{{if .IDStrategy}}func New{{.Entity}}Factory(ids IDGenerator) *{{.Entity}}Factory {
+	return &{{.Entity}}Factory{ids: ids}
+}{{else}}func New{{.Entity}}Factory() *{{.Entity}}Factory {
+	return &{{.Entity}}Factory{}
+}{{end}}
+
+// Create builds a new {{.Entity}}. Add the source aggregates or data it is
+// built from as parameters.
+func (f *{{.Entity}}Factory) Create() (*{{.Entity}}, error) {
+	entity := &{{.Entity}}{ {{- if .IDStrategy}}ID: f.ids.NewID(){{end -}} }
+	// Populate the aggregate here
+{{- if .Validations}}
+	if err := entity.Validate(); err != nil {
+		return nil, err
+	}
+{{- end}}
+	return entity, nil
+}
+`
+	generateFile(filepath.Join(domainPath, "factory.go"), factoryTemplate, spec)
+
+	generateTestStub(filepath.Join(domainPath, "factory_test.go"), spec.Names.Package, spec.Names.Pascal+"Factory")
+}
+
+func generatePersistenceModel(spec domainSpec) {
+	// Create persistence directory
+	persistencePath := filepath.Join(layerPath(boundedContext, "adapters/persistence"), spec.Names.Package)
+	if err := os.MkdirAll(persistencePath, 0755); err != nil {
+		fmt.Printf("Error creating persistence directory: %v\n", err)
+		os.Exit(1)
+	}
+
+	modelTemplate := `package {{.Spec.Domain}}
+
+import (
+{{- range .Spec.StorageImports}}
+	"{{.}}"
+{{- end}}
+
+	domain "{{.CoreImport}}"
+)
 
-	fmt.Printf("Successfully created domain %s in %s\n", domainName, domainPath)
+// {{.Spec.Entity}}Model is the persistence representation of a {{.Spec.Entity}} in the {{.Spec.Table}} table.
+type {{.Spec.Entity}}Model struct {
+{{- if .Spec.IDColumn}}
+	ID {{.Spec.IDBase}} ` + "`db:\"{{.Spec.IDColumn}}\"`" + `
+{{- end}}
+{{- range .Spec.Fields}}
+	{{.Name}} {{.StorageType}} ` + "`db:\"{{.Column}}\"`" + `
+{{- end}}
 }
 
-func generateFile(path string, tmpl string, data map[string]string) {
-	t := template.Must(template.New("").Parse(tmpl))
-	f, err := os.Create(path)
+// TableName returns the table the model is stored in.
+func ({{.Spec.Entity}}Model) TableName() string {
+	return "{{.Spec.Table}}"
+}
+
+// To{{.Spec.Entity}}Model converts a domain entity into its persistence model.
+func To{{.Spec.Entity}}Model(e *domain.{{.Spec.Entity}}) *{{.Spec.Entity}}Model {
+	return &{{.Spec.Entity}}Model{
+{{- if .Spec.IDColumn}}
+		ID: {{if .Spec.TypedID}}{{.Spec.IDBase}}(e.ID){{else}}e.ID{{end}},
+{{- end}}
+{{- range .Spec.Fields}}
+		{{.Name}}: {{.ModelValue}},
+{{- end}}
+	}
+}
+
+// ToEntity converts the persistence model back into a domain entity.
+func (m *{{.Spec.Entity}}Model) ToEntity() *domain.{{.Spec.Entity}} {
+	return &domain.{{.Spec.Entity}}{
+{{- if .Spec.IDColumn}}
+		ID: {{if .Spec.TypedID}}domain.{{.Spec.IDType}}(m.ID){{else}}m.ID{{end}},
+{{- end}}
+{{- range .Spec.Fields}}
+		{{.Name}}: {{.EntityValue}},
+{{- end}}
+	}
+}
+`
+	generateFile(filepath.Join(persistencePath, "model.go"), modelTemplate, map[string]interface{}{
+		"Spec":       spec,
+		"CoreImport": importPath(spec.CoreDir()),
+	})
+}
+
+func createDomainsFromSQL(schemaPath, tableName string, args []string) {
+	src, err := os.ReadFile(schemaPath)
 	if err != nil {
-		fmt.Printf("Error creating file %s: %v\n", path, err)
+		fmt.Printf("Error reading schema: %v\n", err)
+		os.Exit(1)
+	}
+
+	tables, err := parseSQLSchema(string(src))
+	if err != nil {
+		fmt.Printf("Error parsing schema: %v\n", err)
+		os.Exit(1)
+	}
+	if len(tables) == 0 {
+		fmt.Printf("No CREATE TABLE statements found in %s\n", schemaPath)
+		os.Exit(1)
+	}
+
+	if tableName != "" {
+		var selected []sqlTable
+		for _, t := range tables {
+			if strings.EqualFold(t.Name, tableName) {
+				selected = append(selected, t)
+			}
+		}
+		if len(selected) == 0 {
+			fmt.Printf("Table %s not found in %s\n", tableName, schemaPath)
+			os.Exit(1)
+		}
+		tables = selected
+	}
+
+	if len(args) == 1 && len(tables) > 1 {
+		fmt.Println("A domain name can only be given together with --table when the schema has several tables")
 		os.Exit(1)
 	}
-	defer f.Close()
 
-	if err := t.Execute(f, data); err != nil {
+	for _, table := range tables {
+		name := toSingular(table.Name)
+		if len(args) == 1 {
+			name = args[0]
+		}
+		createDomainStructure(domainSpecFromTable(mustNames(name), table))
+	}
+}
+
+// domainSpecFromTable maps a table to a domain. A single-column primary key,
+// or else a column called id, becomes the entity ID.
+func domainSpecFromTable(names Names, table sqlTable) domainSpec {
+	spec := domainSpec{Names: names, IDType: "string", Table: table.Name}
+	for _, col := range table.Columns {
+		if (len(table.PrimaryKey) == 1 && col.Name == table.PrimaryKey[0]) || (len(table.PrimaryKey) == 0 && col.Name == "id") {
+			spec.IDColumn = col.Name
+		}
+	}
+	if spec.IDColumn == "" {
+		fmt.Printf("Warning: table %s has no single-column primary key, so %s.ID is not mapped to a column\n", table.Name, names.Pascal)
+	}
+
+	seenValueObjects := map[string]bool{}
+	for _, col := range table.Columns {
+		goType, valueObject := sqlGoType(col)
+		if col.Name == spec.IDColumn {
+			spec.IDType = goType
+			continue
+		}
+
+		field := domainField{
+			Name:        toPascal(col.Name),
+			Column:      col.Name,
+			Type:        goType,
+			StorageType: goType,
+		}
+		if valueObject != "" {
+			field.Type = valueObject
+			field.ValueObject = valueObject
+			if !seenValueObjects[valueObject] {
+				seenValueObjects[valueObject] = true
+				spec.ValueObjects = append(spec.ValueObjects, valueObject)
+			}
+		}
+		if !col.NotNull && !strings.HasPrefix(goType, "[]") && goType != "json.RawMessage" {
+			// Nullable columns become pointers so NULL maps cleanly to nil
+			field.Type = "*" + field.Type
+			field.StorageType = "*" + field.StorageType
+		}
+		spec.Fields = append(spec.Fields, field)
+	}
+	return spec
+}
+
+// generateFile renders tmpl with data into path. Go sources are gofmt'ed so
+// templates do not need to get alignment right.
+func generateFile(path string, tmpl string, data interface{}) {
+	t := template.Must(template.New("").Funcs(templateFuncs).Parse(tmpl))
+
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
 		fmt.Printf("Error executing template: %v\n", err)
 		os.Exit(1)
 	}
+
+	content := buf.Bytes()
+	if strings.HasSuffix(path, ".go") {
+		formatted, err := format.Source(content)
+		if err != nil {
+			fmt.Printf("Error formatting %s: %v\n", path, err)
+			os.Exit(1)
+		}
+		content = formatted
+	}
+
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		fmt.Printf("Error creating file %s: %v\n", path, err)
+		os.Exit(1)
+	}
 }
 
 func InitGenDomain(rootCmd *cobra.Command) {
 	rootCmd.AddCommand(domainCmd)
+	domainCmd.Flags().StringVar(&repoStyle, "repo-style", "simple", "Repository interface style (simple|context)")
+	domainCmd.Flags().StringVar(&fromSQL, "from-sql", "", "Generate the domain from CREATE TABLE statements in this file")
+	domainCmd.Flags().StringVar(&sqlTableName, "table", "", "Table to generate when using --from-sql")
+	domainCmd.Flags().StringVar(&fromSchema, "from-schema", "", "Generate the domain from a JSON Schema (file.json#/definitions/Name)")
+	domainCmd.Flags().StringVar(&fromOpenAPI, "from-openapi", "", "Generate the domain from an OpenAPI schema (api.yaml#/components/schemas/Name)")
+	domainCmd.Flags().BoolVar(&withFactory, "factory", false, "Generate a factory for the aggregate")
+	domainCmd.Flags().BoolVar(&versioned, "versioned", false, "Add a Version field and optimistic concurrency to the repository adapter")
+	domainCmd.Flags().BoolVar(&audited, "audited", false, "Add audit fields and soft delete to the repository adapter")
+	domainCmd.Flags().StringVar(&idStrategy, "id", "", "Typed ID strategy (uuid|ulid|snowflake|int64)")
+	domainCmd.Flags().BoolVar(&withMigration, "migration", false, "Generate a CREATE TABLE migration for the entity")
+	addContextFlag(domainCmd)
+	domainCmd.MarkFlagsMutuallyExclusive("from-sql", "from-schema", "from-openapi")
 }
@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+)
+
+var domainServiceCmd = &cobra.Command{
+	Use:   "domainservice [name]",
+	Short: "Generate a new domain service",
+	Long: `Creates a stateless domain service in the core layer with:
+- Service interface
+- Service implementation
+- Test stub
+
+Use a domain service for domain logic that spans several entities and does
+not belong to any one of them.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		names := mustNames(args[0])
+		createDomainServiceStructure(names)
+	},
+}
+
+func createDomainServiceStructure(names Names) {
+	// Create domain service directory
+	servicePath := filepath.Join(layerPath(boundedContext, "core"), names.Package)
+	if err := os.MkdirAll(servicePath, 0755); err != nil {
+		fmt.Printf("Error creating domain service directory: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Generate service interface and stateless implementation
+	serviceTemplate := `package {{.Package}}
+
+// {{.Service}} holds {{.Name}} domain logic that spans several entities.
+// Implementations must be stateless: everything they need is passed in.
+type {{.Service}} interface {
+	// Add domain operations here
+}
+
+type service struct{}
+
+func New{{.Service}}() {{.Service}} {
+	return service{}
+}
+`
+	generateFile(filepath.Join(servicePath, "service.go"), serviceTemplate, map[string]string{
+		"Package": names.Package,
+		"Name":    names.Pascal,
+		"Service": names.Pascal + "Service",
+	})
+
+	generateTestStub(filepath.Join(servicePath, "service_test.go"), names.Package, names.Pascal+"Service")
+
+	fmt.Printf("Successfully created domain service %s in %s\n", names.Pascal, servicePath)
+}
+
+func InitGenDomainService(rootCmd *cobra.Command) {
+	rootCmd.AddCommand(domainServiceCmd)
+	addContextFlag(domainServiceCmd)
+}
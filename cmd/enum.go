@@ -0,0 +1,298 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	enumDomain      string
+	enumTransitions string
+)
+
+var enumCmd = &cobra.Command{
+	Use:   "enum [name] [value,value,...]",
+	Short: "Generate a typed enum for a domain",
+	Long: `Creates a typed string enum in a domain package with:
+- One constant per value and a list of all values
+- String, Parse and IsValid
+- JSON and text marshaling
+- SQL Scan and Value
+
+Use --transitions pending>paid,paid>shipped to also generate a transition
+table that aggregates check state changes against.`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		names := mustNames(args[0])
+		if enumDomain == "" {
+			fmt.Println("Missing domain. Use --domain [name]")
+			os.Exit(1)
+		}
+		createEnumStructure(names, mustNames(enumDomain), splitList(args[1]), enumTransitions)
+	},
+}
+
+// enumSpec is the data for enumTemplate.
+type enumSpec struct {
+	Type        string
+	Values      []enumValue
+	Transitions map[string][]string
+}
+
+type enumValue struct {
+	Const string
+	Value string
+}
+
+// enumTemplate renders the enum declarations without a package clause, so
+// the schema importer can place them next to other value objects.
+const enumTemplate = `// {{.Type}} is a typed enum. Use Parse{{.Type}} to convert untrusted input.
+type {{.Type}} string
+
+const (
+{{- range .Values}}
+	{{.Const}} {{$.Type}} = "{{.Value}}"
+{{- end}}
+)
+
+// {{.Type}}Values lists every {{.Type}} in declaration order.
+var {{.Type}}Values = []{{.Type}}{
+{{- range .Values}}
+	{{.Const}},
+{{- end}}
+}
+
+// Parse{{.Type}} returns the {{.Type}} named by s.
+func Parse{{.Type}}(s string) ({{.Type}}, error) {
+	v := {{.Type}}(s)
+	if !v.IsValid() {
+		return "", fmt.Errorf("invalid {{.Type}} %q", s)
+	}
+	return v, nil
+}
+
+// IsValid reports whether v is one of the declared values.
+func (v {{.Type}}) IsValid() bool {
+	switch v {
+	case {{range $i, $v := .Values}}{{if $i}}, {{end}}{{$v.Const}}{{end}}:
+		return true
+	}
+	return false
+}
+
+func (v {{.Type}}) String() string {
+	return string(v)
+}
+
+// MarshalText implements encoding.TextMarshaler, which encoding/json also uses.
+func (v {{.Type}}) MarshalText() ([]byte, error) {
+	if !v.IsValid() {
+		return nil, fmt.Errorf("invalid {{.Type}} %q", string(v))
+	}
+	return []byte(v), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, which encoding/json also uses.
+func (v *{{.Type}}) UnmarshalText(text []byte) error {
+	parsed, err := Parse{{.Type}}(string(text))
+	if err != nil {
+		return err
+	}
+	*v = parsed
+	return nil
+}
+
+// Scan implements sql.Scanner.
+func (v *{{.Type}}) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case string:
+		return v.UnmarshalText([]byte(s))
+	case []byte:
+		return v.UnmarshalText(s)
+	default:
+		return fmt.Errorf("cannot scan %T into {{.Type}}", src)
+	}
+}
+
+// Value implements driver.Valuer.
+func (v {{.Type}}) Value() (driver.Value, error) {
+	if !v.IsValid() {
+		return nil, fmt.Errorf("invalid {{.Type}} %q", string(v))
+	}
+	return string(v), nil
+}
+{{- if .Transitions}}
+
+// {{camel .Type}}Transitions lists the states each {{.Type}} may move to.
+var {{camel .Type}}Transitions = map[{{.Type}}][]{{.Type}}{ {{- .TransitionEntries}}
+}
+
+// ErrInvalid{{.Type}}Transition is returned for a state change the
+// transition table does not allow.
+type ErrInvalid{{.Type}}Transition struct {
+	From, To {{.Type}}
+}
+
+func (e *ErrInvalid{{.Type}}Transition) Error() string {
+	return fmt.Sprintf("invalid {{.Type}} transition from %q to %q", e.From, e.To)
+}
+
+// CanTransitionTo reports whether the transition table allows moving from v to next.
+func (v {{.Type}}) CanTransitionTo(next {{.Type}}) bool {
+	for _, allowed := range {{camel .Type}}Transitions[v] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// TransitionTo returns next if moving from v to next is allowed, so an
+// aggregate can write: o.Status, err = o.Status.TransitionTo(next).
+func (v {{.Type}}) TransitionTo(next {{.Type}}) ({{.Type}}, error) {
+	if !v.CanTransitionTo(next) {
+		return v, &ErrInvalid{{.Type}}Transition{From: v, To: next}
+	}
+	return next, nil
+}
+{{- end}}
+`
+
+// newEnumSpec names a constant after each value. Values that repeat, or
+// that differ only in ways the constant names lose, as in-progress and
+// in_progress, are an error.
+func newEnumSpec(typeName string, values []string) (enumSpec, error) {
+	spec := enumSpec{Type: typeName}
+	valueOf := map[string]string{}
+	for _, v := range values {
+		name := typeName + enumConstSuffix(v)
+		if prev, ok := valueOf[name]; ok {
+			if prev == v {
+				return enumSpec{}, fmt.Errorf("value %q is listed twice", v)
+			}
+			return enumSpec{}, fmt.Errorf("values %q and %q both become the constant %s", prev, v, name)
+		}
+		valueOf[name] = v
+		spec.Values = append(spec.Values, enumValue{Const: name, Value: v})
+	}
+	return spec, nil
+}
+
+// renderEnum returns the enum declarations for spec.
+func renderEnum(spec enumSpec) string {
+	var buf bytes.Buffer
+	t := template.Must(template.New("enum").Funcs(templateFuncs).Parse(enumTemplate))
+	if err := t.Execute(&buf, spec); err != nil {
+		fmt.Printf("Error executing template: %v\n", err)
+		os.Exit(1)
+	}
+	return buf.String()
+}
+
+// TransitionEntries renders the map literal entries of the transition table.
+func (s enumSpec) TransitionEntries() string {
+	constOf := map[string]string{}
+	for _, v := range s.Values {
+		constOf[v.Value] = v.Const
+	}
+
+	var b strings.Builder
+	for _, v := range s.Values {
+		next := s.Transitions[v.Value]
+		if len(next) == 0 {
+			continue
+		}
+		consts := make([]string, len(next))
+		for i, n := range next {
+			consts[i] = constOf[n]
+		}
+		fmt.Fprintf(&b, "\n\t%s: {%s},", v.Const, strings.Join(consts, ", "))
+	}
+	return b.String()
+}
+
+// parseTransitions reads "a>b,b>c" into a map from state to next states.
+func parseTransitions(spec string, values []string) (map[string][]string, error) {
+	known := map[string]bool{}
+	for _, v := range values {
+		known[v] = true
+	}
+
+	transitions := map[string][]string{}
+	for _, pair := range splitList(spec) {
+		from, to, ok := strings.Cut(pair, ">")
+		from, to = strings.TrimSpace(from), strings.TrimSpace(to)
+		if !ok || !known[from] || !known[to] {
+			return nil, fmt.Errorf("invalid transition %q: use from>to with declared values", pair)
+		}
+		transitions[from] = append(transitions[from], to)
+	}
+	return transitions, nil
+}
+
+func createEnumStructure(names, domain Names, values []string, transitions string) {
+	if len(values) == 0 {
+		fmt.Println("An enum needs at least one value")
+		os.Exit(1)
+	}
+
+	domainPath := filepath.Join(layerPath(boundedContext, "core"), domain.Package)
+	if _, err := os.Stat(domainPath); err != nil {
+		fmt.Printf("Domain %s not found in %s. Create it first with: go-ddd-skel domain %s\n", domain.Pascal, domainPath, domain.Pascal)
+		os.Exit(1)
+	}
+
+	spec, err := newEnumSpec(names.Pascal, values)
+	if err != nil {
+		fmt.Printf("Invalid enum %s: %v\n", names.Pascal, err)
+		os.Exit(1)
+	}
+	if transitions != "" {
+		parsed, err := parseTransitions(transitions, values)
+		if err != nil {
+			fmt.Printf("Error parsing transitions: %v\n", err)
+			os.Exit(1)
+		}
+		spec.Transitions = parsed
+	}
+
+	fileTemplate := `package {{.Package}}
+
+import (
+	"database/sql/driver"
+	"fmt"
+)
+
+{{.Code}}`
+	enumPath := filepath.Join(domainPath, names.Snake+".go")
+	generateFile(enumPath, fileTemplate, map[string]string{
+		"Package": domain.Package,
+		"Code":    renderEnum(spec),
+	})
+
+	fmt.Printf("Successfully created enum %s in %s\n", names.Pascal, enumPath)
+}
+
+// splitList splits a comma separated flag value, dropping empty entries.
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func InitGenEnum(rootCmd *cobra.Command) {
+	rootCmd.AddCommand(enumCmd)
+	enumCmd.Flags().StringVar(&enumDomain, "domain", "", "Domain the enum belongs to")
+	enumCmd.Flags().StringVar(&enumTransitions, "transitions", "", "Allowed state changes, e.g. pending>paid,paid>shipped")
+	addContextFlag(enumCmd)
+}
@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNewEnumSpec(t *testing.T) {
+	tests := []struct {
+		values     []string
+		wantConsts []string
+		wantErr    string
+	}{
+		{values: []string{"pending", "in-progress", "done"}, wantConsts: []string{"PhasePending", "PhaseInProgress", "PhaseDone"}},
+		{values: []string{"1", "2"}, wantConsts: []string{"PhaseV1", "PhaseV2"}},
+		{values: []string{"done", "done"}, wantErr: `value "done" is listed twice`},
+		{values: []string{"in-progress", "done", "in_progress"}, wantErr: `values "in-progress" and "in_progress" both become the constant PhaseInProgress`},
+		{values: []string{"InProgress", "in progress"}, wantErr: "both become the constant PhaseInProgress"},
+		{values: []string{"1", "v1"}, wantErr: "both become the constant PhaseV1"},
+	}
+	for _, tt := range tests {
+		spec, err := newEnumSpec("Phase", tt.values)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("newEnumSpec(%q) error = %v, want %q", tt.values, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("newEnumSpec(%q) error = %v", tt.values, err)
+			continue
+		}
+		var consts []string
+		for _, v := range spec.Values {
+			consts = append(consts, v.Const)
+		}
+		if strings.Join(consts, ",") != strings.Join(tt.wantConsts, ",") {
+			t.Errorf("newEnumSpec(%q) consts = %q, want %q", tt.values, consts, tt.wantConsts)
+		}
+	}
+}
@@ -0,0 +1,583 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// removeUnusedImports drops the imports a generated file does not use, so
+// templates can import everything any of their variants might need.
+func removeUnusedImports(filename string) {
+	src, err := os.ReadFile(filename)
+	if err != nil {
+		fmt.Printf("Error reading %s: %v\n", filename, err)
+		os.Exit(1)
+	}
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filename, src, 0)
+	if err != nil {
+		fmt.Printf("Error parsing %s: %v\n", filename, err)
+		os.Exit(1)
+	}
+
+	used := map[string]bool{}
+	ast.Inspect(file, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if ident, ok := sel.X.(*ast.Ident); ok {
+				used[ident.Name] = true
+			}
+		}
+		return true
+	})
+
+	// Blank out the lines of unused imports, then let gofmt tidy up
+	lines := bytes.Split(src, []byte("\n"))
+	for _, imp := range file.Imports {
+		name := importName(imp)
+		if used[name] || name == "_" || name == "." {
+			continue
+		}
+		lines[fset.Position(imp.Pos()).Line-1] = nil
+	}
+	writeFormatted(filename, bytes.Join(removeEmptyImportLines(lines), []byte("\n")))
+}
+
+// writeFormatted writes gofmt-formatted src to path.
+func writeFormatted(path string, src []byte) {
+	formatted, err := format.Source(src)
+	if err != nil {
+		fmt.Printf("Error formatting %s: %v\n", path, err)
+		os.Exit(1)
+	}
+	if err := os.WriteFile(path, formatted, 0644); err != nil {
+		fmt.Printf("Error writing %s: %v\n", path, err)
+		os.Exit(1)
+	}
+}
+
+// parseGoFile reads and parses the file path with its comments.
+func parseGoFile(path string) ([]byte, *token.FileSet, *ast.File) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Printf("Error reading %s: %v\n", path, err)
+		os.Exit(1)
+	}
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, src, parser.ParseComments)
+	if err != nil {
+		fmt.Printf("Error parsing %s: %v\n", path, err)
+		os.Exit(1)
+	}
+	return src, fset, file
+}
+
+// insertAt returns src with text inserted at offset.
+func insertAt(src []byte, offset int, text string) []byte {
+	out := append([]byte(nil), src[:offset]...)
+	out = append(out, text...)
+	return append(out, src[offset:]...)
+}
+
+// findStruct returns the struct typeName declared in file.
+func findStruct(file *ast.File, path, typeName string) *ast.StructType {
+	var st *ast.StructType
+	ast.Inspect(file, func(n ast.Node) bool {
+		if ts, ok := n.(*ast.TypeSpec); ok && ts.Name.Name == typeName {
+			st, _ = ts.Type.(*ast.StructType)
+		}
+		return st == nil
+	})
+	if st == nil {
+		fmt.Printf("Struct %s not found in %s\n", typeName, path)
+		os.Exit(1)
+	}
+	return st
+}
+
+// findFunc returns the function name declared in file.
+func findFunc(file *ast.File, path, name string) *ast.FuncDecl {
+	for _, decl := range file.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok && fn.Recv == nil && fn.Name.Name == name && fn.Body != nil {
+			return fn
+		}
+	}
+	fmt.Printf("Function %s not found in %s\n", name, path)
+	os.Exit(1)
+	return nil
+}
+
+// addStructField adds the field name of type typ to the struct typeName
+// declared in the file path unless it has a field of that name already.
+// An empty name embeds typ, e.g. *Handler.
+func addStructField(path, typeName, name, typ string) {
+	src, fset, file := parseGoFile(path)
+	st := findStruct(file, path, typeName)
+	for _, f := range st.Fields.List {
+		if len(f.Names) == 0 && name == "" && types.ExprString(f.Type) == typ {
+			return
+		}
+		for _, n := range f.Names {
+			if n.Name == name {
+				return
+			}
+		}
+	}
+	field := strings.TrimSpace(name + " " + typ)
+	writeFormatted(path, insertAt(src, fset.Position(st.Fields.Closing).Offset, "\t"+field+"\n"))
+}
+
+// removeStructField removes the field, or embedded type, name from the
+// struct typeName declared in the file path.
+func removeStructField(path, typeName, name string) {
+	src, fset, file := parseGoFile(path)
+	for _, f := range findStruct(file, path, typeName).Fields.List {
+		match := len(f.Names) == 0 && types.ExprString(f.Type) == name
+		for _, n := range f.Names {
+			match = match || n.Name == name && len(f.Names) == 1
+		}
+		if match {
+			writeFormatted(path, cutLines(src, fset, f.Pos(), f.End()))
+			return
+		}
+	}
+}
+
+// addImport adds the import path to the file unless it imports it already.
+// The path joins the last group of its kind, as importGroup orders them, or
+// starts a group of its own after those ordered before it.
+func addImport(filename, path string) {
+	addImportAs(filename, "", path)
+}
+
+// addImportAs is addImport naming the import name, unless name is empty.
+func addImportAs(filename, name, path string) {
+	entry := strconv.Quote(path)
+	if name != "" {
+		entry = name + " " + entry
+	}
+	src, fset, file := parseGoFile(filename)
+	for _, imp := range file.Imports {
+		if p, _ := strconv.Unquote(imp.Path.Value); p == path {
+			return
+		}
+	}
+	group := importGroup(path)
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.IMPORT || !gen.Lparen.IsValid() || len(gen.Specs) == 0 {
+			continue
+		}
+		// After the last import of the same group, else in a new group after
+		// the last one ordered before it, else in a new group at the top
+		offset := fset.Position(gen.Lparen).Offset + 1
+		line := "\n\t" + entry + "\n"
+		same := false
+		for _, spec := range gen.Specs {
+			p, _ := strconv.Unquote(spec.(*ast.ImportSpec).Path.Value)
+			end := fset.Position(spec.End()).Offset
+			if nl := bytes.IndexByte(src[end:], '\n'); nl >= 0 {
+				end += nl
+			}
+			switch g := importGroup(p); {
+			case g == group:
+				offset, line, same = end, "\n\t"+entry, true
+			case g < group && !same:
+				offset, line = end, "\n\n\t"+entry
+			}
+		}
+		writeFormatted(filename, insertAt(src, offset, line))
+		return
+	}
+	offset := fset.Position(file.Name.End()).Offset
+	writeFormatted(filename, insertAt(src, offset, "\n\nimport "+entry))
+}
+
+// addStatement appends stmt to the body of the function fn in the file
+// path unless the body has it already. When the body has the statement
+// before, stmt is inserted ahead of it instead.
+func addStatement(path, fn, stmt, before string) {
+	src, fset, file := parseGoFile(path)
+	body := findFunc(file, path, fn).Body
+	offset := fset.Position(body.Rbrace).Offset
+	for _, s := range body.List {
+		text := sameCode(src[fset.Position(s.Pos()).Offset:fset.Position(s.End()).Offset])
+		if text == sameCode([]byte(stmt)) {
+			return
+		}
+		if before != "" && text == sameCode([]byte(before)) {
+			offset = fset.Position(s.Pos()).Offset
+		}
+	}
+	writeFormatted(path, insertAt(src, offset, stmt+"\n"))
+}
+
+// removeStatements removes the statements of the function fn in the file
+// path that match and reports how many there were. match is given each
+// statement with its code stripped of the layout, as sameCode does.
+func removeStatements(path, fn string, match func(s ast.Stmt, code string) bool) int {
+	src, fset, file := parseGoFile(path)
+	body := findFunc(file, path, fn).Body
+	removed := 0
+	for i := len(body.List) - 1; i >= 0; i-- {
+		s := body.List[i]
+		if match(s, sameCode(src[fset.Position(s.Pos()).Offset:fset.Position(s.End()).Offset])) {
+			src = cutLines(src, fset, s.Pos(), s.End())
+			removed++
+		}
+	}
+	if removed > 0 {
+		writeFormatted(path, src)
+	}
+	return removed
+}
+
+// removeMethods removes the methods of the type typeName from the file
+// path and reports how many there were.
+func removeMethods(path, typeName string) int {
+	src, fset, file := parseGoFile(path)
+	removed := 0
+	for i := len(file.Decls) - 1; i >= 0; i-- {
+		fn, ok := file.Decls[i].(*ast.FuncDecl)
+		if !ok || fn.Recv == nil {
+			continue
+		}
+		recv := fn.Recv.List[0].Type
+		if star, ok := recv.(*ast.StarExpr); ok {
+			recv = star.X
+		}
+		if ident, ok := recv.(*ast.Ident); !ok || ident.Name != typeName {
+			continue
+		}
+		pos := fn.Pos()
+		if fn.Doc != nil {
+			pos = fn.Doc.Pos()
+		}
+		src = cutLines(src, fset, pos, fn.End())
+		removed++
+	}
+	if removed > 0 {
+		writeFormatted(path, src)
+	}
+	return removed
+}
+
+// sameCode strips the layout from code so statements can be compared.
+func sameCode(code []byte) string {
+	return strings.Join(strings.Fields(string(code)), "")
+}
+
+// cutLines removes the source from pos to end along with the rest of the
+// last line. Callers cutting several ranges go from the end of the file.
+func cutLines(src []byte, fset *token.FileSet, pos, end token.Pos) []byte {
+	from, to := fset.Position(pos).Offset, fset.Position(end).Offset
+	for from > 0 && (src[from-1] == ' ' || src[from-1] == '\t') {
+		from--
+	}
+	if i := bytes.IndexByte(src[to:], '\n'); i >= 0 {
+		to += i + 1
+	}
+	return append(append([]byte(nil), src[:from]...), src[to:]...)
+}
+
+// refersTo reports whether node uses the identifier name.
+func refersTo(node ast.Node, name string) bool {
+	found := false
+	ast.Inspect(node, func(n ast.Node) bool {
+		if ident, ok := n.(*ast.Ident); ok && ident.Name == name {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+// removeEmptyImportLines drops removed import lines, the blank lines left
+// between import groups that became empty, and import blocks left empty.
+func removeEmptyImportLines(lines [][]byte) [][]byte {
+	var out [][]byte
+	inImports := false
+	for _, line := range lines {
+		if line == nil {
+			continue
+		}
+		trimmed := strings.TrimSpace(string(line))
+		last := ""
+		if len(out) > 0 {
+			last = strings.TrimSpace(string(out[len(out)-1]))
+		}
+		switch {
+		case trimmed == "import (":
+			inImports = true
+		case inImports && trimmed == "" && (last == "" || last == "import ("):
+			continue
+		case inImports && trimmed == ")":
+			inImports = false
+			if last == "" {
+				out = out[:len(out)-1]
+				last = strings.TrimSpace(string(out[len(out)-1]))
+			}
+			if last == "import (" {
+				out = out[:len(out)-1]
+				continue
+			}
+		}
+		out = append(out, line)
+	}
+	return out
+}
+
+// renderImports returns an import declaration for paths grouped as
+// goimports does: the standard library, other modules and the project's own
+// packages. It returns nothing when paths is empty.
+func renderImports(paths []string) string {
+	return renderImportsAs(paths, nil)
+}
+
+// renderImportsAs is renderImports naming the packages in names by the
+// name given for their path.
+func renderImportsAs(paths []string, names map[string]string) string {
+	var groups [3][]string
+	for _, p := range mergeImports(paths) {
+		g := importGroup(p)
+		if name, ok := names[p]; ok {
+			groups[g] = append(groups[g], fmt.Sprintf("\t%s %q\n", name, p))
+		} else {
+			groups[g] = append(groups[g], fmt.Sprintf("\t%q\n", p))
+		}
+	}
+	var blocks []string
+	for _, g := range groups {
+		if len(g) > 0 {
+			blocks = append(blocks, strings.Join(g, ""))
+		}
+	}
+	if len(blocks) == 0 {
+		return ""
+	}
+	return "import (\n" + strings.Join(blocks, "\n") + ")\n"
+}
+
+// importGroup orders import paths into the groups of goimports: 0 for the
+// standard library, 1 for other modules and 2 for the project's packages.
+func importGroup(p string) int {
+	if mod := currentModule(); mod != "" && (p == mod || strings.HasPrefix(p, mod+"/")) {
+		return 2
+	}
+	if strings.Contains(strings.Split(p, "/")[0], ".") {
+		return 1
+	}
+	return 0
+}
+
+// importName is the name an import is referred to by in the file. Without an
+// explicit name this is the last path element, ignoring a major version suffix.
+func importName(imp *ast.ImportSpec) string {
+	if imp.Name != nil {
+		return imp.Name.Name
+	}
+	p, _ := strconv.Unquote(imp.Path.Value)
+	name := path.Base(p)
+	if strings.HasPrefix(name, "v") && len(name) > 1 && strings.Trim(name[1:], "0123456789") == "" {
+		name = path.Base(path.Dir(p))
+	}
+	return name
+}
+
+// sourcePackage is the parsed source of a package such as a domain, for
+// commands that build on code after it was generated and possibly edited by hand.
+type sourcePackage struct {
+	Dir     string
+	Name    string
+	types   map[string]ast.Expr
+	decls   map[string]bool
+	imports map[string]string
+}
+
+func parseSourcePackage(dir string) (*sourcePackage, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	pkg := &sourcePackage{Dir: dir, types: map[string]ast.Expr{}, decls: map[string]bool{}, imports: map[string]string{}}
+	fset := token.NewFileSet()
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		file, err := parser.ParseFile(fset, filepath.Join(dir, name), nil, 0)
+		if err != nil {
+			return nil, err
+		}
+		pkg.Name = file.Name.Name
+		for _, imp := range file.Imports {
+			p, _ := strconv.Unquote(imp.Path.Value)
+			pkg.imports[importName(imp)] = p
+		}
+		for _, decl := range file.Decls {
+			switch d := decl.(type) {
+			case *ast.FuncDecl:
+				if d.Recv == nil {
+					pkg.decls[d.Name.Name] = true
+				}
+			case *ast.GenDecl:
+				for _, spec := range d.Specs {
+					switch s := spec.(type) {
+					case *ast.TypeSpec:
+						pkg.types[s.Name.Name] = s.Type
+						pkg.decls[s.Name.Name] = true
+					case *ast.ValueSpec:
+						for _, n := range s.Names {
+							pkg.decls[n.Name] = true
+						}
+					}
+				}
+			}
+		}
+	}
+	return pkg, nil
+}
+
+func (p *sourcePackage) declares(name string) bool {
+	return p.decls[name]
+}
+
+// underlying follows named types declared in the package to the type they are
+// defined from, e.g. "string" for type Email string.
+func (p *sourcePackage) underlying(typeName string) string {
+	for i := 0; i < 10; i++ {
+		expr, ok := p.types[typeName]
+		if !ok {
+			break
+		}
+		typeName = types.ExprString(expr)
+	}
+	return typeName
+}
+
+// isInterface reports whether the package declares name as an interface type.
+func (p *sourcePackage) isInterface(name string) bool {
+	_, ok := p.types[name].(*ast.InterfaceType)
+	return ok
+}
+
+// qualify renders a type expression from the package as seen from another
+// package that imports it under qualifier.
+func (p *sourcePackage) qualify(expr ast.Expr, qualifier string) string {
+	ast.Inspect(expr, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.SelectorExpr:
+			return false
+		case *ast.Field:
+			// Only the type of a parameter or field is qualified, not its name
+			ast.Inspect(n.Type, func(m ast.Node) bool {
+				if ident, ok := m.(*ast.Ident); ok {
+					p.qualifyIdent(ident, qualifier)
+				}
+				_, sel := m.(*ast.SelectorExpr)
+				return !sel
+			})
+			return false
+		case *ast.Ident:
+			p.qualifyIdent(n, qualifier)
+		}
+		return true
+	})
+	return types.ExprString(expr)
+}
+
+func (p *sourcePackage) qualifyIdent(ident *ast.Ident, qualifier string) {
+	if _, ok := p.types[ident.Name]; ok && ast.IsExported(ident.Name) {
+		ident.Name = qualifier + "." + ident.Name
+	}
+}
+
+// structFields returns the named fields of a struct type as domain fields.
+func (p *sourcePackage) structFields(name string) ([]domainField, error) {
+	st, ok := p.types[name].(*ast.StructType)
+	if !ok {
+		return nil, fmt.Errorf("struct %s not found in %s", name, p.Dir)
+	}
+
+	var fields []domainField
+	for _, field := range st.Fields.List {
+		goType := types.ExprString(field.Type)
+		for _, n := range field.Names {
+			fields = append(fields, domainField{Name: n.Name, Column: toSnake(n.Name), Type: goType, StorageType: goType})
+		}
+	}
+	return fields, nil
+}
+
+// interfaceMethod is a method of an interface type in declaration order.
+type interfaceMethod struct {
+	Name string
+	Type *ast.FuncType
+}
+
+// interfaceMethods returns the methods of an interface type. Embedded
+// interfaces are not expanded.
+func (p *sourcePackage) interfaceMethods(name string) ([]interfaceMethod, error) {
+	it, ok := p.types[name].(*ast.InterfaceType)
+	if !ok {
+		return nil, fmt.Errorf("interface %s not found in %s", name, p.Dir)
+	}
+
+	var methods []interfaceMethod
+	for _, m := range it.Methods.List {
+		if fn, ok := m.Type.(*ast.FuncType); ok {
+			for _, n := range m.Names {
+				methods = append(methods, interfaceMethod{Name: n.Name, Type: fn})
+			}
+		}
+	}
+	return methods, nil
+}
+
+// loadDomainSpec rebuilds the domainSpec of an existing domain from its
+// source, so generators see fields added by hand since it was generated.
+func loadDomainSpec(names Names) (domainSpec, *sourcePackage) {
+	dir := filepath.Join(layerPath(boundedContext, "core"), names.Package)
+	pkg, err := parseSourcePackage(dir)
+	if err != nil {
+		fmt.Printf("Domain %s not found in %s (%v). Create it first with: go-ddd-skel domain %s\n", names.Pascal, dir, err, names.Pascal)
+		os.Exit(1)
+	}
+	fields, err := pkg.structFields(names.Pascal)
+	if err != nil {
+		fmt.Printf("Error reading entity: %v\n", err)
+		os.Exit(1)
+	}
+
+	spec := domainSpec{Names: names, IDType: "string"}
+	for _, f := range fields {
+		if f.Name == "ID" {
+			spec.IDType = f.Type
+			if pkg.declares(f.Type) {
+				spec.IDUnderlying = pkg.underlying(f.Type)
+			}
+			continue
+		}
+		if pkg.declares(f.Type) {
+			f.ValueObject = f.Type
+			f.StorageType = pkg.underlying(f.Type)
+		}
+		spec.Fields = append(spec.Fields, f)
+	}
+
+	spec.Versioned = spec.hasField("Version") && pkg.declares("ErrConcurrentModification")
+	spec.Audited = spec.hasField("CreatedAt") && spec.hasField("UpdatedAt") && spec.hasField("DeletedAt")
+	return spec, pkg
+}
@@ -0,0 +1,153 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// inModule runs the test in a temporary directory holding a go.mod for
+// module.
+func inModule(t *testing.T, module string) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module "+module+"\n\ngo 1.21\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+	return dir
+}
+
+func TestAddImport(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		path string
+		want string
+	}{
+		{
+			name: "local after local",
+			src:  "package p\n\nimport (\n\t\"context\"\n\n\t\"app/internal/a\"\n)\n",
+			path: "app/internal/b",
+			want: "package p\n\nimport (\n\t\"context\"\n\n\t\"app/internal/a\"\n\t\"app/internal/b\"\n)\n",
+		},
+		{
+			name: "local after standard library",
+			src:  "package p\n\nimport (\n\t\"context\"\n)\n",
+			path: "app/internal/messaging",
+			want: "package p\n\nimport (\n\t\"context\"\n\n\t\"app/internal/messaging\"\n)\n",
+		},
+		{
+			name: "local after other modules",
+			src:  "package p\n\nimport (\n\t\"github.com/spf13/cobra\"\n\n\t\"app/internal/bootstrap\"\n)\n",
+			path: "app/internal/usecase/reindex",
+			want: "package p\n\nimport (\n\t\"github.com/spf13/cobra\"\n\n\t\"app/internal/bootstrap\"\n\t\"app/internal/usecase/reindex\"\n)\n",
+		},
+		{
+			name: "other module between standard library and local",
+			src:  "package p\n\nimport (\n\t\"net/http\"\n\n\t\"app/internal/a\"\n)\n",
+			path: "github.com/gin-gonic/gin",
+			want: "package p\n\nimport (\n\t\"net/http\"\n\n\t\"github.com/gin-gonic/gin\"\n\n\t\"app/internal/a\"\n)\n",
+		},
+		{
+			name: "standard library at the top",
+			src:  "package p\n\nimport (\n\t\"app/internal/a\" // comment\n)\n",
+			path: "context",
+			want: "package p\n\nimport (\n\t\"context\"\n\n\t\"app/internal/a\" // comment\n)\n",
+		},
+		{
+			name: "after a trailing comment",
+			src:  "package p\n\nimport (\n\t\"app/internal/a\" // comment\n)\n",
+			path: "app/internal/b",
+			want: "package p\n\nimport (\n\t\"app/internal/a\" // comment\n\t\"app/internal/b\"\n)\n",
+		},
+		{
+			name: "present already",
+			src:  "package p\n\nimport (\n\t\"context\"\n)\n",
+			path: "context",
+			want: "package p\n\nimport (\n\t\"context\"\n)\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := inModule(t, "app")
+			path := filepath.Join(dir, "p.go")
+			if err := os.WriteFile(path, []byte(tt.src), 0644); err != nil {
+				t.Fatal(err)
+			}
+			addImport(path, tt.path)
+			got, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("addImport() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderImports(t *testing.T) {
+	inModule(t, "app")
+	got := renderImports([]string{"app/internal/usecase/x", "github.com/spf13/cobra", "time", "fmt"})
+	want := "import (\n\t\"fmt\"\n\t\"time\"\n\n\t\"github.com/spf13/cobra\"\n\n\t\"app/internal/usecase/x\"\n)\n"
+	if got != want {
+		t.Errorf("renderImports() =\n%s\nwant\n%s", got, want)
+	}
+
+	got = renderImportsAs([]string{"net/http", "github.com/gin-gonic/gin", "app/internal/core/user"}, map[string]string{"app/internal/core/user": "domain"})
+	want = "import (\n\t\"net/http\"\n\n\t\"github.com/gin-gonic/gin\"\n\n\tdomain \"app/internal/core/user\"\n)\n"
+	if got != want {
+		t.Errorf("renderImportsAs() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestRemoveMethods(t *testing.T) {
+	dir := inModule(t, "app")
+	path := filepath.Join(dir, "p.go")
+	src := `package p
+
+type A struct{}
+
+type B struct{}
+
+// Get is a method of A.
+func (a *A) Get() {}
+
+func (b *B) Get() {}
+
+func (a A) Put() {}
+
+func Get() {}
+`
+	if err := os.WriteFile(path, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if n := removeMethods(path, "A"); n != 2 {
+		t.Errorf("removeMethods() = %d, want 2", n)
+	}
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `package p
+
+type A struct{}
+
+type B struct{}
+
+func (b *B) Get() {}
+
+func Get() {}
+`
+	if string(got) != want {
+		t.Errorf("removeMethods() left\n%s\nwant\n%s", got, want)
+	}
+}
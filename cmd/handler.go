@@ -4,41 +4,301 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/spf13/cobra"
 )
 
+var (
+	handlerType        string
+	handlerFromOpenAPI string
+)
+
 var handlerCmd = &cobra.Command{
 	Use:   "handler [name]",
 	Short: "Generate a new handler",
 	Long: `Creates a new handler with:
-- HTTP/GRPC handler implementation
+- HTTP, gRPC, message consumer or CLI implementation (--type http|grpc|consumer|cli)
 - Route/Endpoint registration
-- Request/Response mapping`,
-	Args: cobra.ExactArgs(1),
+- Request/Response mapping
+
+HTTP handlers are written for the router recorded in .go-ddd-skel.json at
+init: net/http (the default), gin, echo or chi.
+
+With --usecase the handler serves one usecase. Over HTTP it binds the JSON
+body, or path and query parameters, to the usecase's Request, executes it
+with the request context and encodes the Response; usecase errors become
+status codes.
+
+gRPC handlers write <interfaces>/grpc/<name>pb/<name>.proto with a
+<Name>Service that has an rpc per usecase, whose messages are made from the
+fields of the usecase's Request and Response, along with the code mapping
+between them. Run protoc on the .proto to get the Go code the handlers use.
+With --pb the messages of a package protoc generated from your own .proto
+are mapped instead. cmd/grpc serves the services registered in
+internal/interfaces/grpc/services.go.
+
+With --from-openapi api.yaml a handler is generated for every operation of
+an OpenAPI 3 document, in the package of the operation's first tag or the
+one named. openapi.go gets the request and response types, validated
+against the document, and the routes; each operation gets a usecase named
+after its operationId and a handler calling it in <operation>_http.go.
+Running it again after changing the document rewrites openapi.go and keeps
+the handlers.
+
+Consumers (--type consumer --topic orders.placed) decode the JSON payload of
+each message into the Request of --usecase, by default Handle<Name>, which
+is created if missing. Handle acks the message, nacks it when it is
+malformed or invalid, or retries it. internal/adapters/messaging gets a
+runner that retries and dead-letters messages, with Kafka and in-memory
+brokers, and cmd/consumer runs the consumers registered in
+internal/interfaces/messaging/consumers.go on Kafka.
+
+CLI handlers (--type cli --usecase ReindexProducts) are subcommands of
+cmd/admin, a cobra command for operational tasks. Each field of the
+usecase's Request becomes a flag, and the Response is printed as text or,
+with --output json, as JSON. cmd/admin takes its usecases from
+internal/bootstrap like the servers do, and the commands are registered in
+cmd/admin/commands.go.`,
+	Args: cobra.MaximumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
-		handlerName := args[0]
-		createHandlerStructure(handlerName)
+		if handlerFromOpenAPI != "" {
+			if handlerType != "http" {
+				fmt.Println("--from-openapi generates HTTP handlers")
+				os.Exit(1)
+			}
+			var names *Names
+			if len(args) == 1 {
+				n := mustNames(args[0])
+				names = &n
+			}
+			generateOpenAPIHandlers(handlerFromOpenAPI, names)
+			return
+		}
+		if len(args) != 1 {
+			fmt.Println("Missing handler name")
+			os.Exit(1)
+		}
+		switch handlerType {
+		case "http", "grpc", "consumer":
+		case "cli":
+			if handlerUsecase == "" {
+				fmt.Println("CLI handlers need the --usecase they run")
+				os.Exit(1)
+			}
+			generateCommand(mustNames(args[0]), mustNames(handlerUsecase))
+			return
+		default:
+			fmt.Println("Unsupported handler type. Use --type [http|grpc|consumer|cli]")
+			os.Exit(1)
+		}
+		handlerMethod = strings.ToUpper(handlerMethod)
+		switch handlerMethod {
+		case "GET", "POST", "PUT", "PATCH", "DELETE":
+		default:
+			fmt.Println("Unsupported method. Use --method [GET|POST|PUT|PATCH|DELETE]")
+			os.Exit(1)
+		}
+		if handlerRoute != "" && !strings.HasPrefix(handlerRoute, "/") {
+			fmt.Println("The path must start with /")
+			os.Exit(1)
+		}
+		names := mustNames(args[0])
+		createHandlerStructure(names)
+	},
+}
+
+// httpRouter describes how handlers are written for one of the routers a
+// project can be initialized with.
+type httpRouter struct {
+	Name    string
+	Imports []string
+	// Type is the type of the router RegisterRoutes adds routes to and New
+	// creates one in tests
+	Type string
+	New  string
+	// Params and Result make up the signature of a handler method, in
+	// which Ctx and Body are the request's context and body
+	Params string
+	Result string
+	Ctx    string
+	Body   string
+	// PathParam and Query format the lookup of a named parameter
+	PathParam string
+	Query     string
+}
+
+var httpRouters = map[string]httpRouter{
+	"net/http": {
+		Name:      "net/http",
+		Imports:   []string{"net/http"},
+		Type:      "*http.ServeMux",
+		New:       "http.NewServeMux()",
+		Params:    "w http.ResponseWriter, r *http.Request",
+		Ctx:       "r.Context()",
+		Body:      "r.Body",
+		PathParam: "r.PathValue(%q)",
+		Query:     "r.URL.Query().Get(%q)",
+	},
+	"gin": {
+		Name:      "gin",
+		Imports:   []string{"net/http", "github.com/gin-gonic/gin"},
+		Type:      "gin.IRouter",
+		New:       "gin.New()",
+		Params:    "c *gin.Context",
+		Ctx:       "c.Request.Context()",
+		Body:      "c.Request.Body",
+		PathParam: "c.Param(%q)",
+		Query:     "c.Query(%q)",
+	},
+	"echo": {
+		Name:      "echo",
+		Imports:   []string{"net/http", "github.com/labstack/echo/v4"},
+		Type:      "*echo.Echo",
+		New:       "echo.New()",
+		Params:    "c echo.Context",
+		Result:    " error",
+		Ctx:       "c.Request().Context()",
+		Body:      "c.Request().Body",
+		PathParam: "c.Param(%q)",
+		Query:     "c.QueryParam(%q)",
+	},
+	"chi": {
+		Name:      "chi",
+		Imports:   []string{"net/http", "github.com/go-chi/chi/v5"},
+		Type:      "chi.Router",
+		New:       "chi.NewRouter()",
+		Params:    "w http.ResponseWriter, r *http.Request",
+		Ctx:       "r.Context()",
+		Body:      "r.Body",
+		PathParam: "chi.URLParam(r, %q)",
+		Query:     "r.URL.Query().Get(%q)",
 	},
 }
 
-func createHandlerStructure(handlerName string) {
+// projectRouter returns the router the project was initialized with.
+// Projects without one use net/http, as init does.
+func projectRouter() httpRouter {
+	name := loadProject().Router
+	if name == "" {
+		name = "net/http"
+	}
+	router, ok := httpRouters[name]
+	if !ok {
+		fmt.Printf("Unsupported router %q in %s\n", name, projectFile)
+		os.Exit(1)
+	}
+	return router
+}
+
+// Route returns the statement registering fn for method and path, given
+// with {name} wildcards as net/http and chi write them.
+func (r httpRouter) Route(method, path, fn string) string {
+	switch r.Name {
+	case "gin", "echo":
+		return fmt.Sprintf("router.%s(%q, %s)", method, colonParams(path), fn)
+	case "chi":
+		return fmt.Sprintf("router.%s(%q, %s)", capitalize(strings.ToLower(method)), path, fn)
+	default:
+		return fmt.Sprintf("router.HandleFunc(%q, %s)", method+" "+path, fn)
+	}
+}
+
+// Respond returns the statement answering with status and the JSON of v.
+func (r httpRouter) Respond(status, v string) string {
+	switch r.Name {
+	case "gin":
+		return fmt.Sprintf("c.JSON(%s, %s)", status, v)
+	case "echo":
+		return fmt.Sprintf("return c.JSON(%s, %s)", status, v)
+	default:
+		return fmt.Sprintf("writeJSON(w, %s, %s)", status, v)
+	}
+}
+
+// NoContent returns the statement answering with status and no body.
+func (r httpRouter) NoContent(status string) string {
+	switch r.Name {
+	case "gin":
+		return fmt.Sprintf("c.Status(%s)", status)
+	case "echo":
+		return fmt.Sprintf("return c.NoContent(%s)", status)
+	default:
+		return fmt.Sprintf("w.WriteHeader(%s)", status)
+	}
+}
+
+// Fail returns the statements answering with the error err and returning.
+func (r httpRouter) Fail(err string) string {
+	switch r.Name {
+	case "gin":
+		return fmt.Sprintf("writeError(c, %s)\nreturn", err)
+	case "echo":
+		return fmt.Sprintf("return writeError(c, %s)", err)
+	default:
+		return fmt.Sprintf("writeError(w, %s)\nreturn", err)
+	}
+}
+
+// colonParams rewrites {name} wildcards as :name for gin and echo.
+func colonParams(path string) string {
+	segments := strings.Split(path, "/")
+	for i, s := range segments {
+		if strings.HasPrefix(s, "{") && strings.HasSuffix(s, "}") {
+			segments[i] = ":" + strings.TrimSuffix(s[1:], "}")
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+func createHandlerStructure(names Names) {
 	// Create handler directory
-	handlerPath := filepath.Join("internal/interfaces", handlerName)
+	handlerPath := filepath.Join(layerPath(boundedContext, "interfaces"), names.Package)
+	if handlerType == "grpc" {
+		handlerPath = grpcHandlerPath(names)
+	}
 	if err := os.MkdirAll(handlerPath, 0755); err != nil {
 		fmt.Printf("Error creating handler directory: %v\n", err)
 		os.Exit(1)
 	}
 
-	// Generate HTTP handler
-	httpTemplate := `package {{.Handler}}
+	switch {
+	case handlerType == "consumer":
+		usecase := handlerUsecase
+		if usecase == "" {
+			usecase = "Handle" + names.Pascal
+		}
+		topic := handlerTopic
+		if topic == "" {
+			topic = names.Kebab
+		}
+		generateConsumerHandler(handlerPath, names, mustNames(usecase), topic)
+	case handlerUsecase != "" && handlerType == "grpc":
+		generateUsecaseGRPCHandler(handlerPath, names, mustNames(handlerUsecase), handlerPB)
+		tidyModule()
+	case handlerUsecase != "":
+		route := handlerRoute
+		if route == "" {
+			route = "/" + toPlural(names.Kebab)
+		}
+		generateUsecaseHTTPHandler(handlerPath, names, mustNames(handlerUsecase), handlerMethod, route)
+		if projectRouter().Name != "net/http" {
+			tidyModule()
+		}
+	case handlerType == "grpc":
+		generateGRPCHandler(handlerPath, names)
+	default:
+		generateHTTPHandler(handlerPath, names)
+	}
 
-import (
-	"net/http"
+	fmt.Printf("Successfully created %s handler %s in %s\n", handlerType, names.Pascal, handlerPath)
+}
 
-	"github.com/gin-gonic/gin"
-)
+func generateHTTPHandler(handlerPath string, names Names) {
+	router := projectRouter()
+	httpTemplate := `package {{.Handler}}
 
+{{.Imports}}
 type {{.HTTPHandler}} struct {
 	// Add dependencies here
 }
@@ -47,55 +307,169 @@ func NewHTTPHandler() *{{.HTTPHandler}} {
 	return &{{.HTTPHandler}}{}
 }
 
-func (h *{{.HTTPHandler}}) RegisterRoutes(router *gin.Engine) {
-	router.POST("/{{.Route}}", h.handle)
+func (h *{{.HTTPHandler}}) RegisterRoutes(router {{.Router.Type}}) {
+	{{.Router.Route "POST" .Route "h.handle"}}
 }
 
-func (h *{{.HTTPHandler}}) handle(c *gin.Context) {
+func (h *{{.HTTPHandler}}) handle({{.Router.Params}}){{.Router.Result}} {
 	// Implement handler logic here
-	c.JSON(http.StatusOK, gin.H{"message": "Hello from {{.Handler}}"})
+	{{.Router.Respond "http.StatusOK" .Message}}
 }
 `
-	generateFile(filepath.Join(handlerPath, "http_handler.go"), httpTemplate, map[string]string{
-		"Handler":     handlerName,
-		"HTTPHandler": handlerName + "HTTPHandler",
-		"Route":       handlerName,
+	generateFile(filepath.Join(handlerPath, "http_handler.go"), httpTemplate, map[string]interface{}{
+		"Handler":     names.Package,
+		"HTTPHandler": names.Pascal + "HTTPHandler",
+		"Router":      router,
+		"Route":       "/" + toPlural(names.Kebab),
+		"Message":     fmt.Sprintf("map[string]string{\"message\": %q}", "Hello from "+names.Pascal),
+		"Imports":     renderImports(router.Imports),
 	})
+	removeUnusedImports(filepath.Join(handlerPath, "http_handler.go"))
+	ensureHTTPHelpers(handlerPath, names, router)
+	registerRoutes(registration{
+		Imports: []string{importPath(handlerPath)},
+		Stmts:   []string{names.Package + ".NewHTTPHandler().RegisterRoutes(router)"},
+	})
+	if router.Name != "net/http" {
+		tidyModule()
+	}
+}
 
-	// Generate GRPC handler
-	grpcTemplate := `package {{.Handler}}
-
-import (
-	"context"
+// respondData is the data of respondTemplate.
+type respondData struct {
+	Package    string
+	Router     httpRouter
+	Validation string
+	Domain     string
+	Versioned  bool
+}
 
-	"google.golang.org/grpc"
-)
+// Imports renders the import block of respond.go.
+func (d respondData) Imports() string {
+	paths := append([]string{"encoding/json", "errors", "io", "net/http", d.Validation}, d.Router.Imports...)
+	if d.Domain == "" {
+		return renderImports(paths)
+	}
+	return renderImportsAs(append(paths, d.Domain), map[string]string{d.Domain: "domain"})
+}
 
-type {{.GRPCHandler}} struct {
-	// Add dependencies here
+// ensureHTTPHelpers writes respond.go with the helpers HTTP handlers in
+// handlerPath share unless the package has them. Errors of the domain
+// named like the handler package are mapped to status codes too.
+func ensureHTTPHelpers(handlerPath string, names Names, router httpRouter) {
+	if pkg, err := parseSourcePackage(handlerPath); err == nil && pkg.declares("httpError") {
+		return
+	}
+	ensureValidationPackage()
+	data := respondData{
+		Package:    names.Package,
+		Router:     router,
+		Validation: importPath(validationPath()),
+	}
+	domainDir := filepath.Join(layerPath(boundedContext, "core"), names.Package)
+	if pkg, err := parseSourcePackage(domainDir); err == nil && pkg.declares("ErrNotFound") {
+		data.Domain = importPath(domainDir)
+		data.Versioned = pkg.declares("ErrConcurrentModification")
+	}
+	path := filepath.Join(handlerPath, "respond.go")
+	generateFile(path, respondTemplate, data)
+	removeUnusedImports(path)
 }
 
-func NewGRPCHandler() *{{.GRPCHandler}} {
-	return &{{.GRPCHandler}}{}
+// respondTemplate holds the helpers HTTP handlers use to read requests and
+// answer with usecase results and errors.
+const respondTemplate = `package {{.Package}}
+
+{{.Imports}}
+
+// decodeJSON reads a JSON body into v. An empty body leaves v unchanged and
+// a malformed one is an invalid request.
+func decodeJSON(body io.Reader, v interface{}) error {
+	if err := json.NewDecoder(body).Decode(v); err != nil && !errors.Is(err, io.EOF) {
+		return validation.NewError("body", err.Error())
+	}
+	return nil
 }
 
-func (h *{{.GRPCHandler}}) RegisterService(server *grpc.Server) {
-	// Register GRPC service here
+// errNotImplemented is answered by the handlers of usecases bootstrap.New
+// leaves nil.
+var errNotImplemented = errors.New("not implemented")
+
+// httpError maps usecase errors to a status code and response body.
+// Unexpected errors are not shown to the client.
+func httpError(err error) (int, interface{}) {
+	var verr *validation.Error
+	switch {
+	case errors.Is(err, errNotImplemented):
+		return http.StatusNotImplemented, map[string]string{"error": err.Error()}
+	case errors.As(err, &verr):
+		return http.StatusBadRequest, map[string]interface{}{"error": "invalid request", "fields": verr.Fields}
+{{- if .Domain}}
+	case errors.Is(err, domain.ErrNotFound):
+		return http.StatusNotFound, map[string]string{"error": err.Error()}
+{{- if .Versioned}}
+	case errors.As(err, new(*domain.ErrConcurrentModification)):
+		return http.StatusConflict, map[string]string{"error": err.Error()}
+{{- end}}
+{{- end}}
+	default:
+		return http.StatusInternalServerError, map[string]string{"error": "internal error"}
+	}
+}
+{{if eq .Router.Name "gin"}}
+func writeError(c *gin.Context, err error) {
+	status, body := httpError(err)
+	c.JSON(status, body)
+}
+{{- else if eq .Router.Name "echo"}}
+func writeError(c echo.Context, err error) error {
+	status, body := httpError(err)
+	return c.JSON(status, body)
+}
+{{- else}}
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
 }
 
-func (h *{{.GRPCHandler}}) Handle(ctx context.Context, req *Request) (*Response, error) {
-	// Implement handler logic here
-	return &Response{}, nil
+func writeError(w http.ResponseWriter, err error) {
+	status, body := httpError(err)
+	writeJSON(w, status, body)
 }
+{{- end}}
 `
-	generateFile(filepath.Join(handlerPath, "grpc_handler.go"), grpcTemplate, map[string]string{
-		"Handler":     handlerName,
-		"GRPCHandler": handlerName + "GRPCHandler",
-	})
 
-	fmt.Printf("Successfully created handler %s in %s\n", handlerName, handlerPath)
+// generateGRPCHandler writes the .proto of the service of the handler
+// package and the server implementing it, and registers the server. The
+// handler command with --usecase adds rpcs to both.
+func generateGRPCHandler(handlerPath string, names Names) {
+	pbDir := defaultPBDir(names)
+	data := grpcEndpoint{
+		Package:   names.Package,
+		Server:    names.Pascal + "GRPCServer",
+		PBService: names.Pascal + "Service",
+		PB:        writeProtoService(handlerPath, names, pbDir, nil),
+	}
+	ensureGRPCServer(handlerPath, data, pbDir)
+
+	server := names.Package + "Server"
+	registerService(registration{
+		Imports: []string{importPath(handlerPath)},
+		Stmts:   []string{fmt.Sprintf("%s := &%s.%s{}", server, names.Package, data.Server), server + ".RegisterService(server)"},
+	})
+	tidyModule()
 }
 
 func InitGenHandler(rootCmd *cobra.Command) {
 	rootCmd.AddCommand(handlerCmd)
+	handlerCmd.Flags().StringVar(&handlerType, "type", "http", "Handler type (http|grpc|consumer|cli)")
+	handlerCmd.Flags().StringVar(&handlerUsecase, "usecase", "", "Usecase the handler executes")
+	handlerCmd.Flags().StringVar(&handlerMethod, "method", "POST", "HTTP method of a usecase handler")
+	handlerCmd.Flags().StringVar(&handlerRoute, "path", "", "Path of a usecase handler with {name} parameters (default /<plural name>)")
+	handlerCmd.Flags().StringVar(&handlerPB, "pb", "", "Package generated from the .proto (default <interfaces>/grpc/<name>pb)")
+	handlerCmd.Flags().StringVar(&handlerTopic, "topic", "", "Topic a consumer handles (default the kebab-case name)")
+	handlerCmd.Flags().StringVar(&handlerFromOpenAPI, "from-openapi", "", "Generate the handlers of every operation in this OpenAPI document")
+	handlerCmd.MarkFlagsMutuallyExclusive("from-openapi", "usecase")
+	addContextFlag(handlerCmd)
 }
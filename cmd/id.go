@@ -0,0 +1,306 @@
+package cmd
+
+import (
+	"path/filepath"
+)
+
+// idStrategies maps each --id strategy to the underlying Go type of the ID.
+var idStrategies = map[string]string{
+	"uuid":      "string",
+	"ulid":      "string",
+	"snowflake": "int64",
+	"int64":     "int64",
+}
+
+// idStrategyCode holds the parse/validate helpers and the default generator
+// for each strategy. The shared parts of id.go are in idTemplate.
+var idStrategyCode = map[string]string{
+	"uuid": `// Parse{{.ID}} parses a canonical textual UUID.
+func Parse{{.ID}}(s string) ({{.ID}}, error) {
+	id := {{.ID}}(strings.ToLower(s))
+	if err := id.Validate(); err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// Validate checks that id is a canonical textual UUID.
+func (id {{.ID}}) Validate() error {
+	s := string(id)
+	if len(s) != 36 {
+		return fmt.Errorf("invalid {{.ID}} %q: want 36 characters", s)
+	}
+	for i, c := range s {
+		switch {
+		case i == 8 || i == 13 || i == 18 || i == 23:
+			if c != '-' {
+				return fmt.Errorf("invalid {{.ID}} %q: misplaced hyphen", s)
+			}
+		case !strings.ContainsRune("0123456789abcdefABCDEF", c):
+			return fmt.Errorf("invalid {{.ID}} %q: not hexadecimal", s)
+		}
+	}
+	return nil
+}
+
+type uuidGenerator struct{}
+
+// NewIDGenerator returns a generator of random (version 4) UUIDs.
+func NewIDGenerator() IDGenerator {
+	return uuidGenerator{}
+}
+
+func (uuidGenerator) NewID() {{.ID}} {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(fmt.Sprintf("{{.Domain}}: reading random bytes: %v", err))
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return {{.ID}}(fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]))
+}
+`,
+	"ulid": `const crockfordBase32 = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// Parse{{.ID}} parses a ULID in Crockford base32.
+func Parse{{.ID}}(s string) ({{.ID}}, error) {
+	id := {{.ID}}(strings.ToUpper(s))
+	if err := id.Validate(); err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// Validate checks that id is a 26 character ULID.
+func (id {{.ID}}) Validate() error {
+	s := string(id)
+	if len(s) != 26 {
+		return fmt.Errorf("invalid {{.ID}} %q: want 26 characters", s)
+	}
+	if s[0] > '7' {
+		return fmt.Errorf("invalid {{.ID}} %q: overflows 128 bits", s)
+	}
+	for _, c := range s {
+		if !strings.ContainsRune(crockfordBase32, c) {
+			return fmt.Errorf("invalid {{.ID}} %q: not Crockford base32", s)
+		}
+	}
+	return nil
+}
+
+type ulidGenerator struct {
+	now func() time.Time
+}
+
+// NewIDGenerator returns a generator of time-ordered ULIDs.
+func NewIDGenerator() IDGenerator {
+	return ulidGenerator{now: time.Now}
+}
+
+func (g ulidGenerator) NewID() {{.ID}} {
+	var b [16]byte
+	ms := uint64(g.now().UnixMilli())
+	for i := 0; i < 6; i++ {
+		b[i] = byte(ms >> (40 - 8*i))
+	}
+	if _, err := rand.Read(b[6:]); err != nil {
+		panic(fmt.Sprintf("{{.Domain}}: reading random bytes: %v", err))
+	}
+
+	// 128 bits encode to 26 base32 characters, most significant first
+	n := new(big.Int).SetBytes(b[:])
+	out := make([]byte, 26)
+	for i := len(out) - 1; i >= 0; i-- {
+		out[i] = crockfordBase32[new(big.Int).And(n, big.NewInt(31)).Int64()]
+		n.Rsh(n, 5)
+	}
+	return {{.ID}}(out)
+}
+`,
+	"snowflake": `// Parse{{.ID}} parses the decimal form of a snowflake ID.
+func Parse{{.ID}}(s string) ({{.ID}}, error) {
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid {{.ID}} %q: %w", s, err)
+	}
+	id := {{.ID}}(n)
+	if err := id.Validate(); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// Validate checks that id is positive.
+func (id {{.ID}}) Validate() error {
+	if id <= 0 {
+		return fmt.Errorf("invalid {{.ID}} %d: must be positive", int64(id))
+	}
+	return nil
+}
+
+func (id {{.ID}}) String() string {
+	return strconv.FormatInt(int64(id), 10)
+}
+
+// snowflakeEpoch is the custom epoch the 41 bit timestamp counts from.
+var snowflakeEpoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// snowflakeGenerator packs milliseconds since snowflakeEpoch, a 10 bit node
+// number and a 12 bit per-millisecond sequence into an int64.
+type snowflakeGenerator struct {
+	mu       sync.Mutex
+	now      func() time.Time
+	node     int64
+	lastMs   int64
+	sequence int64
+}
+
+// NewIDGenerator returns a snowflake generator. Every process generating
+// IDs concurrently needs its own node number between 0 and 1023.
+func NewIDGenerator(node int64) IDGenerator {
+	return &snowflakeGenerator{now: time.Now, node: node & 0x3ff}
+}
+
+func (g *snowflakeGenerator) NewID() {{.ID}} {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	ms := g.now().Sub(snowflakeEpoch).Milliseconds()
+	if ms <= g.lastMs {
+		// Same millisecond, or the clock moved backwards: keep counting
+		ms = g.lastMs
+		g.sequence = (g.sequence + 1) & 0xfff
+		if g.sequence == 0 {
+			ms++
+		}
+	} else {
+		g.sequence = 0
+	}
+	g.lastMs = ms
+	return {{.ID}}(ms<<22 | g.node<<12 | g.sequence)
+}
+`,
+	"int64": `// Parse{{.ID}} parses the decimal form of an ID.
+func Parse{{.ID}}(s string) ({{.ID}}, error) {
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid {{.ID}} %q: %w", s, err)
+	}
+	id := {{.ID}}(n)
+	if err := id.Validate(); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// Validate checks that id is positive.
+func (id {{.ID}}) Validate() error {
+	if id <= 0 {
+		return fmt.Errorf("invalid {{.ID}} %d: must be positive", int64(id))
+	}
+	return nil
+}
+
+func (id {{.ID}}) String() string {
+	return strconv.FormatInt(int64(id), 10)
+}
+
+type sequenceGenerator struct {
+	last atomic.Int64
+}
+
+// NewIDGenerator returns an in-process sequence starting after start. Use an
+// adapter backed by a database sequence when several processes create IDs.
+func NewIDGenerator(start int64) IDGenerator {
+	g := &sequenceGenerator{}
+	g.last.Store(start)
+	return g
+}
+
+func (g *sequenceGenerator) NewID() {{.ID}} {
+	return {{.ID}}(g.last.Add(1))
+}
+`,
+}
+
+// idFakeValues renders the n-th deterministic ID of the fake generator.
+var idFakeValues = map[string]string{
+	"uuid":      `{{.ID}}(fmt.Sprintf("00000000-0000-4000-8000-%012d", n))`,
+	"ulid":      `{{.ID}}(fmt.Sprintf("%026d", n))`,
+	"snowflake": `{{.ID}}(n)`,
+	"int64":     `{{.ID}}(n)`,
+}
+
+// generateTypedID writes id.go with the ID type, its helpers and generator
+// port, and id_fake.go with a deterministic generator for tests.
+func generateTypedID(domainPath string, spec domainSpec) {
+	data := map[string]string{
+		"Domain": spec.Names.Package,
+		"Entity": spec.Names.Pascal,
+		"ID":     spec.IDType,
+		"Base":   spec.IDUnderlying,
+	}
+
+	idTemplate := `package {{.Domain}}
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// {{.ID}} identifies a {{.Entity}}. Create new IDs with an IDGenerator and
+// parse untrusted input with Parse{{.ID}}.
+type {{.ID}} {{.Base}}
+
+// IDGenerator is the port for creating new {{.ID}} values.
+type IDGenerator interface {
+	NewID() {{.ID}}
+}
+
+// IsZero reports whether id is unset.
+func (id {{.ID}}) IsZero() bool {
+	return id == {{if eq .Base "string"}}""{{else}}0{{end}}
+}
+
+` + idStrategyCode[spec.IDStrategy]
+	idPath := filepath.Join(domainPath, "id.go")
+	generateFile(idPath, idTemplate, data)
+	removeUnusedImports(idPath)
+
+	fakeTemplate := `package {{.Domain}}
+
+import (
+	"fmt"
+	"sync"
+)
+
+// FakeIDGenerator returns 1, 2, 3, ... rendered as {{.ID}} values, so tests
+// get the same IDs on every run.
+type FakeIDGenerator struct {
+	mu sync.Mutex
+	n  int64
+}
+
+func (g *FakeIDGenerator) NewID() {{.ID}} {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.n++
+	n := g.n
+	return ` + idFakeValues[spec.IDStrategy] + `
+}
+`
+	fakePath := filepath.Join(domainPath, "id_fake.go")
+	generateFile(fakePath, fakeTemplate, data)
+	removeUnusedImports(fakePath)
+}
+
+func validIDStrategy(strategy string) bool {
+	_, ok := idStrategies[strategy]
+	return strategy == "" || ok
+}
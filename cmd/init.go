@@ -11,13 +11,13 @@ import (
 )
 
 type ProjectConfig struct {
-	Router   string
-	Logger   string
-	Database string
-	Cache    string
-	UseRedis bool
-	UseKafka bool
-	UseGRPC  bool
+	Router   string `json:"router"`
+	Logger   string `json:"logger"`
+	Database string `json:"database"`
+	Cache    string `json:"cache"`
+	UseRedis bool   `json:"useRedis"`
+	UseKafka bool   `json:"useKafka"`
+	UseGRPC  bool   `json:"useGRPC"`
 }
 
 var initCmd = &cobra.Command{
@@ -117,21 +117,36 @@ func createProjectStructure(projectName string, config *ProjectConfig) {
 		os.Exit(1)
 	}
 
-	// Create main.go file based on selected router
+	// Create main.go file based on selected router, serving the routes the
+	// handler command registers in routes.go with the usecases bootstrap.go
+	// wires
 	var mainContent string
 	switch config.Router {
 	case "gin":
 		mainContent = `package main
 
 import (
+	"context"
+	"log"
+
 	"github.com/gin-gonic/gin"
+
+	"{{.}}/internal/bootstrap"
+	httpapi "{{.}}/internal/interfaces/http"
 )
 
 func main() {
+	app, err := bootstrap.New(context.Background())
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer app.Close()
+
 	r := gin.Default()
 	r.GET("/", func(c *gin.Context) {
 		c.String(200, "Hello World!")
 	})
+	httpapi.RegisterRoutes(r, app.HTTP)
 	r.Run()
 }
 `
@@ -139,14 +154,27 @@ func main() {
 		mainContent = `package main
 
 import (
+	"context"
+	"log"
+
 	"github.com/labstack/echo/v4"
+
+	"{{.}}/internal/bootstrap"
+	httpapi "{{.}}/internal/interfaces/http"
 )
 
 func main() {
+	app, err := bootstrap.New(context.Background())
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer app.Close()
+
 	e := echo.New()
 	e.GET("/", func(c echo.Context) error {
 		return c.String(200, "Hello World!")
 	})
+	httpapi.RegisterRoutes(e, app.HTTP)
 	e.Start(":8080")
 }
 `
@@ -154,16 +182,28 @@ func main() {
 		mainContent = `package main
 
 import (
+	"context"
+	"log"
 	"net/http"
-	
+
 	"github.com/go-chi/chi/v5"
+
+	"{{.}}/internal/bootstrap"
+	httpapi "{{.}}/internal/interfaces/http"
 )
 
 func main() {
+	app, err := bootstrap.New(context.Background())
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer app.Close()
+
 	r := chi.NewRouter()
 	r.Get("/", func(w http.ResponseWriter, r *http.Request) {
 		w.Write([]byte("Hello World!"))
 	})
+	httpapi.RegisterRoutes(r, app.HTTP)
 	http.ListenAndServe(":8080", r)
 }
 `
@@ -171,28 +211,59 @@ func main() {
 		mainContent = `package main
 
 import (
+	"context"
 	"fmt"
+	"log"
 	"net/http"
+
+	"{{.}}/internal/bootstrap"
+	httpapi "{{.}}/internal/interfaces/http"
 )
 
 func main() {
-	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+	app, err := bootstrap.New(context.Background())
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer app.Close()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		fmt.Fprintf(w, "Hello from %s!", r.URL.Path[1:])
 	})
+	httpapi.RegisterRoutes(mux, app.HTTP)
 
 	fmt.Printf("Starting server at port 8080\n")
-	if err := http.ListenAndServe(":8080", nil); err != nil {
+	if err := http.ListenAndServe(":8080", mux); err != nil {
 		panic(err)
 	}
 }
 `
 	}
 
-	mainPath := filepath.Join(projectName, "main.go")
-	if err := os.WriteFile(mainPath, []byte(mainContent), 0644); err != nil {
-		fmt.Printf("Error creating main.go: %v\n", err)
-		os.Exit(1)
+	generateFile(filepath.Join(projectName, "main.go"), mainContent, projectName)
+
+	router, ok := httpRouters[config.Router]
+	if !ok {
+		router = httpRouters["net/http"]
 	}
+	routes := filepath.Join(projectName, routesPath())
+	writeIfMissing(routes, routesTemplate, router)
+	removeUnusedImports(routes)
+	boot := bootstrapData{HTTP: projectName + "/internal/interfaces/http"}
+	if config.UseGRPC {
+		// cmd/grpc serves the services the handler command registers in
+		// services.go, next to the HTTP server of main.go
+		boot.GRPC = projectName + "/internal/interfaces/grpc"
+		writeIfMissing(filepath.Join(projectName, servicesPath()), servicesTemplate, nil)
+		writeIfMissing(filepath.Join(projectName, grpcMainPath()), grpcMainTemplate, map[string]string{
+			"Bootstrap": projectName + "/internal/bootstrap",
+			"Services":  boot.GRPC,
+		})
+	}
+	writeIfMissing(filepath.Join(projectName, bootstrapPath()), bootstrapTemplate, boot)
+
+	saveProject(projectName, projectManifest{ProjectConfig: *config})
 
 	fmt.Printf("Successfully created DDD project structure in %s/ with Go module initialized and main.go created\n", projectName)
 }
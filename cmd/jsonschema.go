@@ -0,0 +1,500 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// schemaDocument is a JSON Schema or OpenAPI document loaded with yaml.v3,
+// which reads JSON as well and keeps properties in document order.
+type schemaDocument struct {
+	root *yaml.Node
+}
+
+// schemaConverter turns a schema into a domainSpec. Nested objects become
+// value objects and string enums become enum types, both declared next to
+// the entity.
+type schemaConverter struct {
+	doc    *schemaDocument
+	spec   *domainSpec
+	entity string
+	decls  map[string]bool
+}
+
+func loadSchemaDocument(path string) (*schemaDocument, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, err
+	}
+	if len(root.Content) == 0 {
+		return nil, fmt.Errorf("%s is empty", path)
+	}
+	return &schemaDocument{root: root.Content[0]}, nil
+}
+
+// resolve follows a JSON pointer such as #/components/schemas/User.
+func (d *schemaDocument) resolve(pointer string) (*yaml.Node, error) {
+	if !strings.HasPrefix(pointer, "#") {
+		return nil, fmt.Errorf("only local references are supported: %s", pointer)
+	}
+	node := d.root
+	for _, segment := range strings.Split(strings.TrimPrefix(strings.TrimPrefix(pointer, "#"), "/"), "/") {
+		if segment == "" {
+			continue
+		}
+		segment = strings.ReplaceAll(strings.ReplaceAll(segment, "~1", "/"), "~0", "~")
+		if node = schemaNodeGet(node, segment); node == nil {
+			return nil, fmt.Errorf("%s not found", pointer)
+		}
+	}
+	return node, nil
+}
+
+// createDomainFromSchema generates a domain from a schema reference of the
+// form file#/pointer. Without a name the last pointer segment is used.
+func createDomainFromSchema(ref string, args []string) {
+	path, pointer, _ := strings.Cut(ref, "#")
+	doc, err := loadSchemaDocument(path)
+	if err != nil {
+		fmt.Printf("Error reading schema: %v\n", err)
+		os.Exit(1)
+	}
+
+	schema, err := doc.resolve("#" + pointer)
+	if err != nil {
+		fmt.Printf("Error resolving schema: %v\n", err)
+		os.Exit(1)
+	}
+
+	name := ""
+	switch {
+	case len(args) == 1:
+		name = args[0]
+	case pointer != "" && pointer != "/":
+		name = pointer[strings.LastIndex(pointer, "/")+1:]
+	case schemaNodeString(schema, "title") != "":
+		name = schemaNodeString(schema, "title")
+	default:
+		fmt.Println("Missing domain name. Pass it as an argument or point at a named schema with #/...")
+		os.Exit(1)
+	}
+
+	spec, err := domainSpecFromSchema(mustNames(name), doc, schema)
+	if err != nil {
+		fmt.Printf("Error converting schema: %v\n", err)
+		os.Exit(1)
+	}
+	createDomainStructure(spec)
+}
+
+func domainSpecFromSchema(names Names, doc *schemaDocument, schema *yaml.Node) (domainSpec, error) {
+	spec := domainSpec{Names: names, IDType: "string"}
+	c := &schemaConverter{
+		doc:    doc,
+		spec:   &spec,
+		entity: names.Pascal,
+		decls:  map[string]bool{names.Pascal: true},
+	}
+
+	schema, err := c.deref(schema)
+	if err != nil {
+		return spec, err
+	}
+	fields, checks, err := c.objectFields(names.Pascal, "e", schema)
+	if err != nil {
+		return spec, err
+	}
+
+	for _, f := range fields {
+		if f.Name == "ID" {
+			spec.IDType = f.Type
+			continue
+		}
+		spec.Fields = append(spec.Fields, f)
+	}
+	spec.Validations = checks
+	if len(checks) > 0 {
+		spec.ValidationImports = detectImports("errors." + strings.Join(checks, "\n"))
+	}
+	return spec, nil
+}
+
+// objectFields converts the properties of an object schema into fields and
+// the validation statements for them, written against receiver.
+func (c *schemaConverter) objectFields(owner, receiver string, schema *yaml.Node) ([]domainField, []string, error) {
+	properties, required, err := c.doc.properties(schema)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var fields []domainField
+	var checks []string
+	for i := 0; i+1 < len(properties.Content); i += 2 {
+		prop := properties.Content[i].Value
+		propSchema := properties.Content[i+1]
+		isRequired := required[prop] && schemaNodeString(propSchema, "nullable") != "true"
+
+		goType, err := c.goType(owner+toPascal(prop), propSchema, isRequired)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s.%s: %w", owner, prop, err)
+		}
+		// The entity's ID is held by value even when the schema leaves it
+		// optional, so it is checked as a value too
+		if owner == c.entity && toPascal(prop) == "ID" {
+			goType = strings.TrimPrefix(goType, "*")
+		}
+		field := domainField{Name: toPascal(prop), Column: toSnake(prop), Type: goType, StorageType: goType}
+		fields = append(fields, field)
+
+		fieldChecks, err := c.fieldChecks(receiver+"."+field.Name, prop, goType, propSchema, isRequired)
+		if err != nil {
+			return nil, nil, err
+		}
+		checks = append(checks, fieldChecks...)
+	}
+	return fields, checks, nil
+}
+
+// goType maps a property schema to a Go type, declaring nested value objects
+// and enums on the way. Optional scalars become pointers.
+func (c *schemaConverter) goType(suggested string, schema *yaml.Node, required bool) (string, error) {
+	if ref := schemaNodeString(schema, "$ref"); ref != "" {
+		suggested = toPascal(ref[strings.LastIndex(ref, "/")+1:])
+	}
+	schema, err := c.deref(schema)
+	if err != nil {
+		return "", err
+	}
+
+	optional := func(t string) string {
+		if required {
+			return t
+		}
+		return "*" + t
+	}
+
+	switch schemaNodeString(schema, "type") {
+	case "string":
+		if enum := schemaNodeGet(schema, "enum"); enum != nil {
+			if err := c.declareEnum(suggested, enum); err != nil {
+				return "", err
+			}
+			return optional(suggested), nil
+		}
+		switch schemaNodeString(schema, "format") {
+		case "date-time", "date":
+			return optional("time.Time"), nil
+		case "byte", "binary":
+			return "[]byte", nil
+		}
+		return optional("string"), nil
+	case "integer":
+		if schemaNodeString(schema, "format") == "int32" {
+			return optional("int32"), nil
+		}
+		return optional("int64"), nil
+	case "number":
+		if schemaNodeString(schema, "format") == "float" {
+			return optional("float32"), nil
+		}
+		return optional("float64"), nil
+	case "boolean":
+		return optional("bool"), nil
+	case "array":
+		items := schemaNodeGet(schema, "items")
+		if items == nil {
+			return "[]interface{}", nil
+		}
+		itemType, err := c.goType(toSingular(suggested), items, true)
+		if err != nil {
+			return "", err
+		}
+		return "[]" + itemType, nil
+	}
+
+	if schemaNodeGet(schema, "properties") != nil || schemaNodeGet(schema, "allOf") != nil {
+		if err := c.declareStruct(suggested, schema); err != nil {
+			return "", err
+		}
+		return optional(suggested), nil
+	}
+	if additional := schemaNodeGet(schema, "additionalProperties"); additional != nil && additional.Kind == yaml.MappingNode {
+		valueType, err := c.goType(suggested+"Value", additional, true)
+		if err != nil {
+			return "", err
+		}
+		return "map[string]" + valueType, nil
+	}
+	return "json.RawMessage", nil
+}
+
+// fieldChecks returns the statements validating one property of e.
+func (c *schemaConverter) fieldChecks(expr, label, goType string, schema *yaml.Node, required bool) ([]string, error) {
+	schema, err := c.deref(schema)
+	if err != nil {
+		return nil, err
+	}
+
+	value := expr
+	if strings.HasPrefix(goType, "*") {
+		value = "*" + expr
+	}
+	base := strings.TrimPrefix(goType, "*")
+
+	var checks []string
+	fail := func(cond, msg string, args ...string) {
+		format := strconv.Quote(label + ": " + msg)
+		checks = append(checks, fmt.Sprintf("if %s {\n\terrs = append(errs, fmt.Errorf(%s%s))\n}", cond, format, joinArgs(args)))
+	}
+
+	switch {
+	case required && base == "string":
+		fail(value+` == ""`, "is required")
+	case required && base == "time.Time":
+		fail(value+".IsZero()", "is required")
+	case required && (strings.HasPrefix(base, "[]") || strings.HasPrefix(base, "map[")):
+		fail("len("+value+") == 0", "is required")
+	}
+
+	if base == "string" {
+		switch schemaNodeString(schema, "format") {
+		case "email":
+			fail(fmt.Sprintf(`%s != "" && !isEmail(%s)`, value, value), "invalid email %q", value)
+			c.declareHelper("isEmail", "func isEmail(s string) bool {\n\t_, err := mail.ParseAddress(s)\n\treturn err == nil\n}\n")
+		case "uri", "url":
+			fail(fmt.Sprintf(`%s != "" && !isURI(%s)`, value, value), "invalid URI %q", value)
+			c.declareHelper("isURI", "func isURI(s string) bool {\n\t_, err := url.ParseRequestURI(s)\n\treturn err == nil\n}\n")
+		case "uuid":
+			fail(fmt.Sprintf(`%s != "" && !uuidPattern.MatchString(%s)`, value, value), "invalid UUID %q", value)
+			c.declareHelper("uuidPattern", "var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)\n")
+		case "ipv4", "ipv6":
+			fail(fmt.Sprintf(`%s != "" && net.ParseIP(%s) == nil`, value, value), "invalid IP address %q", value)
+		}
+		if n := schemaNodeString(schema, "minLength"); n != "" {
+			fail(fmt.Sprintf("utf8.RuneCountInString(%s) < %s", value, n), "must be at least "+n+" characters")
+		}
+		if n := schemaNodeString(schema, "maxLength"); n != "" {
+			fail(fmt.Sprintf("utf8.RuneCountInString(%s) > %s", value, n), "must be at most "+n+" characters")
+		}
+	}
+
+	if strings.HasPrefix(base, "int") || strings.HasPrefix(base, "float") {
+		if n := schemaNodeString(schema, "minimum"); n != "" {
+			fail(fmt.Sprintf("%s < %s", value, n), "must be at least "+n)
+		}
+		if n := schemaNodeString(schema, "maximum"); n != "" {
+			fail(fmt.Sprintf("%s > %s", value, n), "must be at most "+n)
+		}
+	}
+
+	// Method calls on a dereferenced pointer need parentheses
+	receiver := value
+	if strings.HasPrefix(value, "*") {
+		receiver = "(" + value + ")"
+	}
+	if c.spec.hasEnum(base) {
+		fail(fmt.Sprintf("!%s.IsValid()", receiver), "invalid value %q", value)
+	}
+	if c.spec.hasValidatedStruct(base) {
+		checks = append(checks, fmt.Sprintf("if err := %s.Validate(); err != nil {\n\terrs = append(errs, fmt.Errorf(%s, err))\n}", receiver, strconv.Quote(label+": %w")))
+	}
+	if item := strings.TrimPrefix(base, "[]"); item != base && c.spec.hasValidatedStruct(item) {
+		checks = append(checks, fmt.Sprintf("for i, item := range %s {\n\tif err := item.Validate(); err != nil {\n\t\terrs = append(errs, fmt.Errorf(%s, i, err))\n\t}\n}", value, strconv.Quote(label+"[%d]: %w")))
+	}
+
+	if len(checks) > 0 && strings.HasPrefix(goType, "*") {
+		return []string{fmt.Sprintf("if %s != nil {\n%s\n}", expr, strings.Join(checks, "\n"))}, nil
+	}
+	return checks, nil
+}
+
+// declareStruct adds a nested value object with its own Validate method.
+func (c *schemaConverter) declareStruct(name string, schema *yaml.Node) error {
+	if c.decls[name] {
+		return nil
+	}
+	c.decls[name] = true
+
+	fields, checks, err := c.objectFields(name, "v", schema)
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "// %s is a value object of %s.\ntype %s struct {\n", name, c.entity, name)
+	for _, f := range fields {
+		fmt.Fprintf(&b, "\t%s %s\n", f.Name, f.Type)
+	}
+	b.WriteString("}\n")
+	if len(checks) > 0 {
+		fmt.Fprintf(&b, "\n// Validate checks the constraints from the %s schema.\nfunc (v %s) Validate() error {\n\tvar errs []error\n", name, name)
+		for _, check := range checks {
+			b.WriteString(check + "\n")
+		}
+		b.WriteString("\treturn errors.Join(errs...)\n}\n")
+		c.spec.ValidatedStructs = append(c.spec.ValidatedStructs, name)
+	}
+
+	c.spec.Decls = append(c.spec.Decls, valueObjectDef{Imports: detectImports(b.String()), Code: b.String()})
+	return nil
+}
+
+// declareEnum adds a string enum type with one constant per value.
+func (c *schemaConverter) declareEnum(name string, values *yaml.Node) error {
+	if c.decls[name] {
+		return nil
+	}
+	c.decls[name] = true
+
+	var list []string
+	for _, v := range values.Content {
+		list = append(list, v.Value)
+	}
+	spec, err := newEnumSpec(name, list)
+	if err != nil {
+		return fmt.Errorf("enum %s: %w", name, err)
+	}
+	code := renderEnum(spec)
+
+	c.spec.Enums = append(c.spec.Enums, name)
+	c.spec.Decls = append(c.spec.Decls, valueObjectDef{Imports: detectImports(code), Code: code})
+	return nil
+}
+
+// declareHelper adds a package level helper once.
+func (c *schemaConverter) declareHelper(name, code string) {
+	if c.decls[name] {
+		return
+	}
+	c.decls[name] = true
+	c.spec.Decls = append(c.spec.Decls, valueObjectDef{Imports: detectImports(code), Code: code})
+}
+
+// properties merges the properties of an object schema and its allOf
+// parts, in document order, and reports which are required.
+func (d *schemaDocument) properties(schema *yaml.Node) (*yaml.Node, map[string]bool, error) {
+	required := map[string]bool{}
+	properties := &yaml.Node{Kind: yaml.MappingNode}
+
+	parts := []*yaml.Node{schema}
+	if allOf := schemaNodeGet(schema, "allOf"); allOf != nil {
+		parts = append(parts, allOf.Content...)
+	}
+	for _, part := range parts {
+		part, err := d.deref(part)
+		if err != nil {
+			return nil, nil, err
+		}
+		if req := schemaNodeGet(part, "required"); req != nil {
+			for _, r := range req.Content {
+				required[r.Value] = true
+			}
+		}
+		if props := schemaNodeGet(part, "properties"); props != nil {
+			properties.Content = append(properties.Content, props.Content...)
+		}
+	}
+	return properties, required, nil
+}
+
+func (c *schemaConverter) deref(schema *yaml.Node) (*yaml.Node, error) {
+	return c.doc.deref(schema)
+}
+
+// deref follows $ref until it reaches a schema without one.
+func (d *schemaDocument) deref(schema *yaml.Node) (*yaml.Node, error) {
+	for i := 0; i < 32; i++ {
+		if schema.Kind == yaml.AliasNode {
+			schema = schema.Alias
+			continue
+		}
+		ref := schemaNodeString(schema, "$ref")
+		if ref == "" {
+			return schema, nil
+		}
+		target, err := d.resolve(ref)
+		if err != nil {
+			return nil, err
+		}
+		schema = target
+	}
+	return nil, fmt.Errorf("too many nested $ref")
+}
+
+// importSelectors maps package selectors used in generated snippets to their
+// import paths.
+var importSelectors = map[string]string{
+	"big":    "math/big",
+	"driver": "database/sql/driver",
+	"errors": "errors",
+	"fmt":    "fmt",
+	"json":   "encoding/json",
+	"mail":   "net/mail",
+	"net":    "net",
+	"regexp": "regexp",
+	"time":   "time",
+	"url":    "net/url",
+	"utf8":   "unicode/utf8",
+}
+
+var selectorPattern = regexp.MustCompile(`\b([a-z0-9]+)\.`)
+
+// detectImports returns the imports needed by a generated code snippet.
+func detectImports(code string) []string {
+	seen := map[string]bool{}
+	for _, m := range selectorPattern.FindAllStringSubmatch(code, -1) {
+		if imp, ok := importSelectors[m[1]]; ok {
+			seen[imp] = true
+		}
+	}
+	imports := make([]string, 0, len(seen))
+	for imp := range seen {
+		imports = append(imports, imp)
+	}
+	sort.Strings(imports)
+	return imports
+}
+
+func enumConstSuffix(value string) string {
+	suffix := toPascal(value)
+	if suffix == "" || !(suffix[0] >= 'A' && suffix[0] <= 'Z') {
+		suffix = "V" + suffix
+	}
+	return suffix
+}
+
+func joinArgs(args []string) string {
+	if len(args) == 0 {
+		return ""
+	}
+	return ", " + strings.Join(args, ", ")
+}
+
+func schemaNodeGet(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+func schemaNodeString(node *yaml.Node, key string) string {
+	if v := schemaNodeGet(node, key); v != nil && v.Kind == yaml.ScalarNode {
+		return v.Value
+	}
+	return ""
+}
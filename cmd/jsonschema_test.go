@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func parseSchemaDocument(t *testing.T, src string) *schemaDocument {
+	t.Helper()
+	var root yaml.Node
+	if err := yaml.Unmarshal([]byte(src), &root); err != nil {
+		t.Fatal(err)
+	}
+	return &schemaDocument{root: root.Content[0]}
+}
+
+// typeCheckChecks reports whether the validation statements compile in a
+// method of an entity with the ID type given.
+func typeCheckChecks(t *testing.T, idType string, checks []string) error {
+	t.Helper()
+	src := `package p
+
+import (
+	"fmt"
+	"regexp"
+)
+
+var _ = fmt.Errorf
+
+var uuidPattern = regexp.MustCompile("")
+
+type E struct {
+	ID ` + idType + `
+}
+
+func (e *E) validate() []error {
+	var errs []error
+	` + strings.Join(checks, "\n") + `
+	return errs
+}
+`
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "e.go", src, 0)
+	if err != nil {
+		return err
+	}
+	conf := types.Config{Importer: importer.Default()}
+	_, err = conf.Check("p", fset, []*ast.File{file}, nil)
+	return err
+}
+
+func TestDomainSpecFromSchemaID(t *testing.T) {
+	tests := []struct {
+		name     string
+		schema   string
+		idType   string
+		wantType string
+	}{
+		{
+			name: "optional uuid",
+			schema: `
+definitions:
+  User:
+    type: object
+    properties:
+      id: {type: string, format: uuid}
+      name: {type: string}
+`,
+			wantType: "string",
+		},
+		{
+			name: "required uuid",
+			schema: `
+definitions:
+  User:
+    type: object
+    required: [id]
+    properties:
+      id: {type: string, format: uuid}
+`,
+			wantType: "string",
+		},
+		{
+			name: "optional integer with bounds",
+			schema: `
+definitions:
+  User:
+    type: object
+    properties:
+      id: {type: integer, minimum: 1}
+`,
+			wantType: "int64",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := parseSchemaDocument(t, tt.schema)
+			schema, err := doc.resolve("#/definitions/User")
+			if err != nil {
+				t.Fatal(err)
+			}
+			spec, err := domainSpecFromSchema(mustNames("Member"), doc, schema)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if spec.IDType != tt.wantType {
+				t.Errorf("IDType = %q, want %q", spec.IDType, tt.wantType)
+			}
+			for _, check := range spec.Validations {
+				if strings.Contains(check, "e.ID != nil") || strings.Contains(check, "*e.ID") {
+					t.Errorf("check treats the ID as a pointer:\n%s", check)
+				}
+			}
+			if err := typeCheckChecks(t, spec.IDType, spec.Validations); err != nil {
+				t.Errorf("checks do not compile: %v\n%s", err, strings.Join(spec.Validations, "\n"))
+			}
+		})
+	}
+}
@@ -0,0 +1,443 @@
+package cmd
+
+import (
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	mapperOut       string
+	mapperName      string
+	mapperOverrides []string
+	mapperReverse   bool
+)
+
+var mapperCmd = &cobra.Command{
+	Use:   "mapper [from] [to]",
+	Short: "Generate mapping functions between two struct types",
+	Long: `Generates a function copying the fields of one struct type to another,
+e.g. from a usecase Request to a domain entity or from an entity to its
+persistence model. Types are given as <package dir>.<Type>:
+
+	go-ddd-skel mapper internal/usecase/createuser.Request internal/core/user.User
+
+The packages are loaded with go/types and fields are matched by name,
+exactly or else ignoring case. Values are assigned when their types are
+assignable, converted when they are convertible (e.g. string to Email) and
+dereferenced when the source is a pointer. Fields that cannot be matched are
+listed in the output and in the function's doc comment.
+
+Use --map Dst=Src to match fields with different names and --map Dst=- to
+leave a field out on purpose. --reverse also generates the mapping back.
+
+The mapper is written to the package of the source type, or of the target
+when the source is a domain type, unless --out is given. Its file also
+converts each struct to a copy of its field list, so the build fails when a
+field is added or removed until the mapper is generated again.`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		generateMapper(args[0], args[1])
+	},
+}
+
+// mapperType is a struct type loaded with go/types.
+type mapperType struct {
+	Dir    string
+	Type   string
+	pkg    *types.Package
+	fields *types.Struct
+}
+
+// loadMapperType type-checks the package of ref, given as <dir>.<Type>,
+// and finds the struct type in it. Mappers generated before are left out,
+// since their checks stop compiling when the structs change, and other type
+// errors are ignored so a half-edited package can still be mapped.
+func loadMapperType(imp types.Importer, ref string) mapperType {
+	dot := strings.LastIndex(ref, ".")
+	if dot <= 0 || dot == len(ref)-1 {
+		fmt.Printf("Invalid type %q. Use <package dir>.<Type>, e.g. internal/core/user.User\n", ref)
+		os.Exit(1)
+	}
+	dir := strings.TrimPrefix(strings.TrimPrefix(ref[:dot], modulePath()), "/")
+	t := mapperType{Dir: filepath.Clean(dir), Type: ref[dot+1:]}
+
+	entries, err := os.ReadDir(t.Dir)
+	if err != nil {
+		fmt.Printf("Error loading %s: %v\n", t.Dir, err)
+		os.Exit(1)
+	}
+	fset := token.NewFileSet()
+	var files []*ast.File
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		file, err := parser.ParseFile(fset, filepath.Join(t.Dir, name), nil, parser.ParseComments)
+		if err != nil {
+			fmt.Printf("Error parsing %s: %v\n", name, err)
+			os.Exit(1)
+		}
+		if len(file.Comments) > 0 && strings.HasPrefix(file.Comments[0].Text(), mapperHeader) {
+			continue
+		}
+		files = append(files, file)
+	}
+	conf := types.Config{Importer: imp, Error: func(error) {}}
+	t.pkg, _ = conf.Check(importPath(t.Dir), fset, files, nil)
+
+	obj, ok := t.pkg.Scope().Lookup(t.Type).(*types.TypeName)
+	if !ok {
+		fmt.Printf("%s does not declare a type %s\n", t.Dir, t.Type)
+		os.Exit(1)
+	}
+	if t.fields, ok = obj.Type().Underlying().(*types.Struct); !ok {
+		fmt.Printf("%s.%s is not a struct\n", t.Dir, t.Type)
+		os.Exit(1)
+	}
+	return t
+}
+
+// mapperAssign copies one field. Conv is the type to convert to, if any.
+type mapperAssign struct {
+	Dst   string
+	Src   string
+	Conv  string
+	Deref bool
+}
+
+type mapperFunc struct {
+	Name     string
+	From     string
+	To       string
+	Assigns  []mapperAssign
+	Unmapped []string
+	Unused   []string
+}
+
+// mapperFile renders the imports, checks and functions of a mapper file,
+// qualifying types as seen from the package it is written to.
+type mapperFile struct {
+	Package string
+	outPath string
+	aliases map[string]string
+	imports map[string]bool
+	Checks  []string
+	Funcs   []mapperFunc
+}
+
+func (f *mapperFile) qualifier(p *types.Package) string {
+	if p.Path() == f.outPath {
+		return ""
+	}
+	f.imports[p.Path()] = true
+	if alias, ok := f.aliases[p.Path()]; ok {
+		return alias
+	}
+	return p.Name()
+}
+
+// Imports renders the import block, naming aliased packages.
+func (f *mapperFile) Imports() string {
+	var paths []string
+	for p := range f.imports {
+		paths = append(paths, p)
+	}
+	return renderImportsAs(paths, f.aliases)
+}
+
+// addCheck records a conversion of t to its field list, which stops
+// compiling when a field of t changes. Structs with fields that cannot be
+// named from the mapper's package are skipped.
+func (f *mapperFile) addCheck(t mapperType) {
+	vars := make([]*types.Var, t.fields.NumFields())
+	for i := range vars {
+		field := t.fields.Field(i)
+		if !field.Exported() && t.pkg.Path() != f.outPath {
+			return
+		}
+		vars[i] = field
+	}
+	check := fmt.Sprintf("%s(%s{})", types.TypeString(types.NewStruct(vars, nil), f.qualifier), f.typeName(t))
+	for _, c := range f.Checks {
+		if c == check {
+			return
+		}
+	}
+	f.Checks = append(f.Checks, check)
+}
+
+func (f *mapperFile) typeName(t mapperType) string {
+	if q := f.qualifier(t.pkg); q != "" {
+		return q + "." + t.Type
+	}
+	return t.Type
+}
+
+// mapFields matches the fields of to with those of from. overrides maps
+// target field names to source field names, or to "-" to skip them.
+func (f *mapperFile) mapFields(name string, from, to mapperType, overrides map[string]string) mapperFunc {
+	fn := mapperFunc{Name: name, From: f.typeName(from), To: f.typeName(to)}
+	accessible := func(t mapperType, v *types.Var) bool {
+		return v.Exported() || t.pkg.Path() == f.outPath
+	}
+	sources := map[string]*types.Var{}
+	for i := 0; i < from.fields.NumFields(); i++ {
+		if v := from.fields.Field(i); accessible(from, v) {
+			sources[v.Name()] = v
+		}
+	}
+	lookup := func(name string) *types.Var {
+		if v, ok := sources[name]; ok {
+			return v
+		}
+		for n, v := range sources {
+			if strings.EqualFold(n, name) {
+				return v
+			}
+		}
+		return nil
+	}
+
+	used := map[string]bool{}
+	for i := 0; i < to.fields.NumFields(); i++ {
+		dst := to.fields.Field(i)
+		srcName, overridden := overrides[dst.Name()]
+		if srcName == "-" {
+			continue
+		}
+		if !accessible(to, dst) {
+			fn.Unmapped = append(fn.Unmapped, dst.Name()+": unexported")
+			continue
+		}
+		var src *types.Var
+		if overridden {
+			if src = sources[srcName]; src == nil {
+				fmt.Printf("Override %s=%s: %s has no field %s\n", dst.Name(), srcName, from.Type, srcName)
+				os.Exit(1)
+			}
+		} else if src = lookup(dst.Name()); src == nil {
+			fn.Unmapped = append(fn.Unmapped, fmt.Sprintf("%s: no field in %s", dst.Name(), from.Type))
+			continue
+		}
+
+		assign, ok := f.assign(src.Type(), dst.Type())
+		if !ok {
+			fn.Unmapped = append(fn.Unmapped, fmt.Sprintf("%s: %s %s does not convert to %s",
+				dst.Name(), src.Name(), types.TypeString(src.Type(), f.qualifier), types.TypeString(dst.Type(), f.qualifier)))
+			continue
+		}
+		assign.Dst, assign.Src = dst.Name(), src.Name()
+		fn.Assigns = append(fn.Assigns, assign)
+		used[src.Name()] = true
+	}
+	for name := range sources {
+		if !used[name] {
+			fn.Unused = append(fn.Unused, name)
+		}
+	}
+	sort.Strings(fn.Unused)
+	return fn
+}
+
+// assign works out how a value of type src is stored in a field of type dst.
+func (f *mapperFile) assign(src, dst types.Type) (mapperAssign, bool) {
+	if types.AssignableTo(src, dst) {
+		return mapperAssign{}, true
+	}
+	if convertible(src, dst) {
+		return mapperAssign{Conv: types.TypeString(dst, f.qualifier)}, true
+	}
+	if ptr, ok := src.(*types.Pointer); ok {
+		a, ok := f.assign(ptr.Elem(), dst)
+		if !ok || a.Deref {
+			return mapperAssign{}, false
+		}
+		a.Deref = true
+		return a, true
+	}
+	return mapperAssign{}, false
+}
+
+// convertible reports whether a conversion from src to dst keeps the value,
+// as between a string and a named string type. Integers are not converted
+// to strings, which would yield a rune.
+func convertible(src, dst types.Type) bool {
+	switch dst.(type) {
+	case *types.Named, *types.Basic:
+	default:
+		return false
+	}
+	s, ok := src.Underlying().(*types.Basic)
+	d, ok2 := dst.Underlying().(*types.Basic)
+	if !ok || !ok2 {
+		return types.ConvertibleTo(src, dst) && types.Identical(src.Underlying(), dst.Underlying())
+	}
+	if d.Info()&types.IsString != 0 && s.Info()&types.IsString == 0 {
+		return false
+	}
+	return types.ConvertibleTo(src, dst)
+}
+
+func generateMapper(fromRef, toRef string) {
+	imp := importer.ForCompiler(token.NewFileSet(), "source", nil)
+	from := loadMapperType(imp, fromRef)
+	to := loadMapperType(imp, toRef)
+
+	overrides := map[string]string{}
+	reverse := map[string]string{}
+	for _, o := range mapperOverrides {
+		dst, src, ok := strings.Cut(o, "=")
+		if !ok || dst == "" || src == "" {
+			fmt.Printf("Invalid override %q. Use --map Dst=Src or --map Dst=-\n", o)
+			os.Exit(1)
+		}
+		overrides[dst] = src
+		if src != "-" {
+			reverse[src] = dst
+		}
+	}
+
+	// Mappers live outside the domain, which must not import the other side
+	outDir := mapperOut
+	if outDir == "" {
+		outDir = from.Dir
+		if strings.HasPrefix(from.Dir, layerPath(boundedContext, "core")+string(filepath.Separator)) {
+			outDir = to.Dir
+		}
+	}
+	outDir = filepath.Clean(outDir)
+	file := &mapperFile{
+		Package: filepath.Base(outDir),
+		outPath: importPath(outDir),
+		aliases: map[string]string{},
+		imports: map[string]bool{},
+	}
+	switch {
+	case outDir == from.Dir:
+		file.Package = from.pkg.Name()
+	case outDir == to.Dir:
+		file.Package = to.pkg.Name()
+	default:
+		if pkg, err := parseSourcePackage(outDir); err == nil && pkg.Name != "" {
+			file.Package = pkg.Name
+		}
+	}
+	// Packages of the same name, such as a domain and its persistence
+	// adapter, are told apart by calling the domain one domain
+	if from.pkg.Name() == to.pkg.Name() && from.pkg.Path() != to.pkg.Path() {
+		for _, t := range []mapperType{from, to} {
+			if t.pkg.Path() != file.outPath && strings.HasPrefix(t.Dir, layerPath(boundedContext, "core")) {
+				file.aliases[t.pkg.Path()] = "domain"
+			}
+		}
+		if len(file.aliases) == 0 && from.pkg.Path() != file.outPath && to.pkg.Path() != file.outPath {
+			file.aliases[from.pkg.Path()] = "from" + from.pkg.Name()
+		}
+	}
+
+	name := mapperName
+	if name == "" {
+		name = file.funcName(from, to)
+	}
+	file.Funcs = append(file.Funcs, file.mapFields(name, from, to, overrides))
+	if mapperReverse {
+		file.Funcs = append(file.Funcs, file.mapFields(file.funcName(to, from), to, from, reverse))
+	}
+	file.addCheck(from)
+	file.addCheck(to)
+
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		fmt.Printf("Error creating %s: %v\n", outDir, err)
+		os.Exit(1)
+	}
+	path := filepath.Join(outDir, "mapper_"+toSnake(from.Type)+"_to_"+toSnake(to.Type)+".go")
+	generateFile(path, mapperTemplate, file)
+	removeUnusedImports(path)
+
+	for _, fn := range file.Funcs {
+		for _, u := range fn.Unmapped {
+			fmt.Printf("Unmapped %s.%s\n", fn.To, u)
+		}
+		if len(fn.Unused) > 0 {
+			fmt.Printf("Unused fields of %s: %s\n", fn.From, strings.Join(fn.Unused, ", "))
+		}
+	}
+	fmt.Printf("Successfully created mapper in %s\n", path)
+}
+
+// funcName names a mapper after its types, adding the package names the
+// mapper refers to them by when the types share a name, e.g. RequestToUser
+// or DomainUserToUser.
+func (f *mapperFile) funcName(from, to mapperType) string {
+	if from.Type != to.Type {
+		return from.Type + "To" + to.Type
+	}
+	name := func(t mapperType) string {
+		return toPascal(f.qualifier(t.pkg)) + t.Type
+	}
+	return name(from) + "To" + name(to)
+}
+
+// mapperHeader starts the files the mapper generates.
+const mapperHeader = "Code generated by go-ddd-skel mapper"
+
+const mapperTemplate = `// Code generated by go-ddd-skel mapper; DO NOT EDIT.
+
+package {{.Package}}
+
+{{.Imports}}
+{{- if .Checks}}
+// The build fails here when a field is added to or removed from the mapped
+// structs. Generate the mapper again to map it.
+var (
+{{- range .Checks}}
+	_ = {{.}}
+{{- end}}
+)
+{{end}}
+{{- range .Funcs}}
+// {{.Name}} copies a {{.From}} into a new {{.To}}.
+{{- if .Unmapped}}
+//
+// Left unmapped:
+{{- range .Unmapped}}
+//   - {{.}}
+{{- end}}
+{{- end}}
+func {{.Name}}(src *{{.From}}) *{{.To}} {
+	if src == nil {
+		return nil
+	}
+	dst := &{{.To}}{}
+{{- range .Assigns}}
+{{- if .Deref}}
+	if src.{{.Src}} != nil {
+		dst.{{.Dst}} = {{if .Conv}}{{.Conv}}(*src.{{.Src}}){{else}}*src.{{.Src}}{{end}}
+	}
+{{- else}}
+	dst.{{.Dst}} = {{if .Conv}}{{.Conv}}(src.{{.Src}}){{else}}src.{{.Src}}{{end}}
+{{- end}}
+{{- end}}
+	return dst
+}
+{{end}}`
+
+func InitGenMapper(rootCmd *cobra.Command) {
+	rootCmd.AddCommand(mapperCmd)
+	mapperCmd.Flags().StringVar(&mapperOut, "out", "", "Package directory to write the mapper to")
+	mapperCmd.Flags().StringVar(&mapperName, "name", "", "Name of the mapping function")
+	mapperCmd.Flags().StringArrayVar(&mapperOverrides, "map", nil, "Field override as Dst=Src, or Dst=- to skip, repeatable")
+	mapperCmd.Flags().BoolVar(&mapperReverse, "reverse", false, "Also generate the mapping from the target back to the source")
+	addContextFlag(mapperCmd)
+}
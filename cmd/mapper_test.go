@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"reflect"
+	"testing"
+)
+
+// checkMapperType type-checks src as the package path and returns its
+// struct typeName.
+func checkMapperType(t *testing.T, path, src, typeName string) mapperType {
+	t.Helper()
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "src.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := types.Config{Importer: importer.Default()}
+	pkg, err := conf.Check(path, fset, []*ast.File{file}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return mapperType{Type: typeName, pkg: pkg, fields: pkg.Scope().Lookup(typeName).Type().Underlying().(*types.Struct)}
+}
+
+func TestMapFields(t *testing.T) {
+	from := checkMapperType(t, "app/internal/usecase/createuser", `package createuser
+
+type Request struct {
+	Name     string
+	EMAIL    string
+	Nickname *string
+	Age      int
+	Secret   string
+	Tags     []string
+	internal string
+}
+`, "Request")
+	to := checkMapperType(t, "app/internal/core/user", `package user
+
+type Email string
+
+type User struct {
+	ID       string
+	Name     string
+	Email    Email
+	Nickname string
+	Age      int64
+	Tags     []int
+	internal string
+}
+`, "User")
+
+	tests := []struct {
+		name      string
+		overrides map[string]string
+		want      mapperFunc
+	}{
+		{
+			name: "by name",
+			want: mapperFunc{
+				Assigns: []mapperAssign{
+					{Dst: "Name", Src: "Name"},
+					{Dst: "Email", Src: "EMAIL", Conv: "user.Email"},
+					{Dst: "Nickname", Src: "Nickname", Deref: true},
+					{Dst: "Age", Src: "Age", Conv: "int64"},
+				},
+				Unmapped: []string{"ID: no field in Request", "Tags: Tags []string does not convert to []int", "internal: unexported"},
+				Unused:   []string{"Secret", "Tags"},
+			},
+		},
+		{
+			name:      "overridden",
+			overrides: map[string]string{"ID": "Secret", "Email": "-", "Tags": "-"},
+			want: mapperFunc{
+				Assigns: []mapperAssign{
+					{Dst: "ID", Src: "Secret"},
+					{Dst: "Name", Src: "Name"},
+					{Dst: "Nickname", Src: "Nickname", Deref: true},
+					{Dst: "Age", Src: "Age", Conv: "int64"},
+				},
+				Unmapped: []string{"internal: unexported"},
+				Unused:   []string{"EMAIL", "Tags"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &mapperFile{outPath: "app/internal/adapters/mapper", aliases: map[string]string{}, imports: map[string]bool{}}
+			got := f.mapFields("RequestToUser", from, to, tt.overrides)
+			tt.want.Name, tt.want.From, tt.want.To = "RequestToUser", "createuser.Request", "user.User"
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("mapFields() =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertible(t *testing.T) {
+	email := types.NewNamed(types.NewTypeName(token.NoPos, nil, "Email", nil), types.Typ[types.String], nil)
+	tests := []struct {
+		src, dst types.Type
+		want     bool
+	}{
+		{types.Typ[types.String], email, true},
+		{email, types.Typ[types.String], true},
+		{types.Typ[types.Int], types.Typ[types.Int64], true},
+		{types.Typ[types.Int], types.Typ[types.String], false},
+		{types.NewSlice(types.Typ[types.String]), types.NewSlice(types.Typ[types.Int]), false},
+		{types.NewSlice(types.Typ[types.String]), types.NewSlice(types.Typ[types.String]), false},
+	}
+	for _, tt := range tests {
+		if got := convertible(tt.src, tt.dst); got != tt.want {
+			t.Errorf("convertible(%s, %s) = %v, want %v", tt.src, tt.dst, got, tt.want)
+		}
+	}
+}
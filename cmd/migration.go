@@ -0,0 +1,561 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+const migrationsDir = "migrations"
+
+var migrationCmd = &cobra.Command{
+	Use:   "migration",
+	Short: "Manage SQL migrations",
+	Long: `Creates timestamped up/down SQL migrations in migrations/ together with an
+embedded migration runner for the app. Run it with:
+
+  go run ./cmd/migrate up|down|status|version`,
+}
+
+var migrationCreateCmd = &cobra.Command{
+	Use:   "create [name]",
+	Short: "Create an empty up/down migration",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		names := mustNames(args[0])
+		createMigration(names, "-- Write the migration here\n", "-- Revert the migration here\n")
+	},
+}
+
+// createMigration writes the next <version>_<name>.up.sql and .down.sql and
+// makes sure the runner exists.
+func createMigration(names Names, up, down string) {
+	if err := os.MkdirAll(migrationsDir, 0755); err != nil {
+		fmt.Printf("Error creating migrations directory: %v\n", err)
+		os.Exit(1)
+	}
+
+	base := fmt.Sprintf("%d_%s", nextMigrationVersion(time.Now()), names.Snake)
+	for _, file := range []struct{ path, sql string }{
+		{filepath.Join(migrationsDir, base+".up.sql"), up},
+		{filepath.Join(migrationsDir, base+".down.sql"), down},
+	} {
+		if err := os.WriteFile(file.path, []byte(file.sql), 0644); err != nil {
+			fmt.Printf("Error creating file %s: %v\n", file.path, err)
+			os.Exit(1)
+		}
+	}
+
+	generateMigrationRunner()
+
+	fmt.Printf("Successfully created migration %s in %s\n", base, migrationsDir)
+}
+
+// nextMigrationVersion returns now in UTC as YYYYMMDDHHMMSS, or one more than
+// the newest existing version so that migrations created within the same
+// second still apply in order.
+func nextMigrationVersion(now time.Time) int64 {
+	version, _ := strconv.ParseInt(now.UTC().Format("20060102150405"), 10, 64)
+
+	entries, err := os.ReadDir(migrationsDir)
+	if err != nil {
+		return version
+	}
+	for _, entry := range entries {
+		prefix, _, ok := strings.Cut(entry.Name(), "_")
+		if !ok {
+			continue
+		}
+		if existing, err := strconv.ParseInt(prefix, 10, 64); err == nil && existing >= version {
+			version = existing + 1
+		}
+	}
+	return version
+}
+
+// migrationDialect returns the SQL dialect for the project's database.
+// Projects without a manifest are assumed to use Postgres.
+func migrationDialect() string {
+	switch database := loadProject().Database; database {
+	case "", "postgres":
+		return "postgres"
+	case "mysql":
+		return "mysql"
+	default:
+		fmt.Printf("Migrations need a SQL database, but the project uses %q\n", database)
+		os.Exit(1)
+		return ""
+	}
+}
+
+// sqlDriver returns the database/sql driver name of the dialect and the
+// package registering it.
+func sqlDriver(dialect string) (name, imp string) {
+	if dialect == "mysql" {
+		return "mysql", "github.com/go-sql-driver/mysql"
+	}
+	return "pgx", "github.com/jackc/pgx/v5/stdlib"
+}
+
+// generateDomainMigration writes a CREATE TABLE migration for the entity.
+func generateDomainMigration(spec domainSpec) {
+	dialect := migrationDialect()
+
+	columns := []string{fmt.Sprintf("%s %s PRIMARY KEY", spec.IDColumnName(), sqlIDColumnType(spec, dialect))}
+	for _, f := range spec.Fields {
+		column := f.Column
+		if column == "" {
+			column = toSnake(f.Name)
+		}
+		definition := column + " " + sqlColumnType(spec, f, dialect)
+		if !strings.HasPrefix(f.Type, "*") {
+			definition += " NOT NULL"
+		}
+		columns = append(columns, definition)
+	}
+
+	table := spec.TableName()
+	up := fmt.Sprintf("CREATE TABLE %s (\n    %s\n);\n", table, strings.Join(columns, ",\n    "))
+	down := fmt.Sprintf("DROP TABLE IF EXISTS %s;\n", table)
+	createMigration(mustNames("create_"+table), up, down)
+}
+
+func sqlIDColumnType(spec domainSpec, dialect string) string {
+	switch spec.IDStrategy {
+	case "uuid":
+		if dialect == "mysql" {
+			return "CHAR(36)"
+		}
+		return "UUID"
+	case "ulid":
+		return "CHAR(26)"
+	}
+	if spec.IDBase() == "string" {
+		if dialect == "mysql" {
+			return "VARCHAR(64)"
+		}
+		return "TEXT"
+	}
+	return sqlColumnType(spec, domainField{Type: spec.IDBase()}, dialect)
+}
+
+// sqlColumnType maps a field's Go type to a column type. Value objects map
+// by name, enums are stored as text and anything unknown as JSON.
+func sqlColumnType(spec domainSpec, f domainField, dialect string) string {
+	mysql := dialect == "mysql"
+	pick := func(postgres, mysqlType string) string {
+		if mysql {
+			return mysqlType
+		}
+		return postgres
+	}
+
+	goType := strings.TrimPrefix(f.Type, "*")
+	switch {
+	case f.ValueObject == "Decimal" || goType == "Decimal":
+		return pick("NUMERIC", "DECIMAL(19,4)")
+	case f.ValueObject == "Email" || goType == "Email":
+		return pick("TEXT", "VARCHAR(255)")
+	case f.ValueObject == "Money" || goType == "Money":
+		return "BIGINT"
+	case spec.hasEnum(goType):
+		return pick("TEXT", "VARCHAR(64)")
+	}
+
+	switch goType {
+	case "string":
+		return pick("TEXT", "VARCHAR(255)")
+	case "int", "int64", "uint", "uint64":
+		return "BIGINT"
+	case "int32", "uint32":
+		return "INTEGER"
+	case "int16", "uint16", "int8", "uint8":
+		return "SMALLINT"
+	case "bool":
+		return "BOOLEAN"
+	case "float64":
+		return pick("DOUBLE PRECISION", "DOUBLE")
+	case "float32":
+		return pick("REAL", "FLOAT")
+	case "time.Time":
+		return pick("TIMESTAMPTZ", "DATETIME(6)")
+	case "time.Duration":
+		return "BIGINT"
+	case "[]byte":
+		return pick("BYTEA", "BLOB")
+	case "[]string":
+		return pick("TEXT[]", "JSON")
+	default:
+		return pick("JSONB", "JSON")
+	}
+}
+
+// generateMigrationRunner writes the embedded migrations package, the runner
+// and cmd/migrate unless they already exist.
+func generateMigrationRunner() {
+	dialect := migrationDialect()
+	ph := func(n int) string { return sqlPlaceholder(dialect, n) }
+
+	driver, driverImport := sqlDriver(dialect)
+	runnerPath := filepath.Join("internal", "adapters", "persistence", "migrate")
+	mainPath := filepath.Join("cmd", "migrate")
+	for _, dir := range []string{runnerPath, mainPath} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			fmt.Printf("Error creating directory %s: %v\n", dir, err)
+			os.Exit(1)
+		}
+	}
+
+	data := map[string]string{
+		"MigrationsImport": importPath(migrationsDir),
+		"RunnerImport":     importPath(runnerPath),
+		"Driver":           driver,
+		"DriverImport":     driverImport,
+		"VersionColumn":    "BIGINT PRIMARY KEY",
+		"AppliedColumn":    "TIMESTAMPTZ NOT NULL DEFAULT now()",
+		"Insert":           "INSERT INTO schema_migrations (version) VALUES (" + ph(1) + ")",
+		"Delete":           "DELETE FROM schema_migrations WHERE version = " + ph(1),
+	}
+	if dialect == "mysql" {
+		data["AppliedColumn"] = "DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6)"
+	}
+
+	embedTemplate := `// Package migrations embeds the SQL migrations so the binary can apply them.
+package migrations
+
+import "embed"
+
+// FS holds the <version>_<name>.up.sql and .down.sql files.
+//
+//go:embed *.sql
+var FS embed.FS
+`
+
+	runnerTemplate := `// Package migrate applies the embedded SQL migrations in version order and
+// records the applied versions in the schema_migrations table.
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	createTable    = ` + "`CREATE TABLE IF NOT EXISTS schema_migrations (version {{.VersionColumn}}, applied_at {{.AppliedColumn}})`" + `
+	selectVersions = ` + "`SELECT version FROM schema_migrations ORDER BY version`" + `
+	insertVersion  = ` + "`{{.Insert}}`" + `
+	deleteVersion  = ` + "`{{.Delete}}`" + `
+)
+
+// Migration is one version with its up and down SQL.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status is a migration and whether it has been applied.
+type Status struct {
+	Migration
+	Applied bool
+}
+
+// Migrator applies migrations from a file system such as migrations.FS.
+type Migrator struct {
+	db         *sql.DB
+	migrations fs.FS
+}
+
+func New(db *sql.DB, migrations fs.FS) *Migrator {
+	return &Migrator{db: db, migrations: migrations}
+}
+
+// Up applies every pending migration and returns the ones it applied.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var applied []Migration
+	for _, s := range statuses {
+		if s.Applied {
+			continue
+		}
+		if err := m.apply(ctx, s.Up, insertVersion, s.Version); err != nil {
+			return applied, fmt.Errorf("applying %d_%s: %w", s.Version, s.Name, err)
+		}
+		applied = append(applied, s.Migration)
+	}
+	return applied, nil
+}
+
+// Down reverts the most recently applied migration. It returns nil when no
+// migration has been applied.
+func (m *Migrator) Down(ctx context.Context) (*Migration, error) {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := len(statuses) - 1; i >= 0; i-- {
+		s := statuses[i]
+		if !s.Applied {
+			continue
+		}
+		if err := m.apply(ctx, s.Down, deleteVersion, s.Version); err != nil {
+			return nil, fmt.Errorf("reverting %d_%s: %w", s.Version, s.Name, err)
+		}
+		return &s.Migration, nil
+	}
+	return nil, nil
+}
+
+// Status lists every known migration in version order.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	migrations, err := m.load()
+	if err != nil {
+		return nil, err
+	}
+	applied, err := m.appliedVersions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, len(migrations))
+	for i, mig := range migrations {
+		statuses[i] = Status{Migration: mig, Applied: applied[mig.Version]}
+	}
+	return statuses, nil
+}
+
+// Version returns the newest applied version, or 0 if none has been applied.
+func (m *Migrator) Version(ctx context.Context) (int64, error) {
+	applied, err := m.appliedVersions(ctx)
+	if err != nil {
+		return 0, err
+	}
+	var version int64
+	for v := range applied {
+		if v > version {
+			version = v
+		}
+	}
+	return version, nil
+}
+
+// apply runs a migration and records it in one transaction. Databases with
+// implicit commits on DDL, such as MySQL, cannot roll back a failed migration.
+func (m *Migrator) apply(ctx context.Context, query, record string, version int64) error {
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if strings.TrimSpace(query) != "" {
+		if _, err := tx.ExecContext(ctx, query); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.ExecContext(ctx, record, version); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (m *Migrator) appliedVersions(ctx context.Context) (map[int64]bool, error) {
+	if _, err := m.db.ExecContext(ctx, createTable); err != nil {
+		return nil, err
+	}
+	rows, err := m.db.QueryContext(ctx, selectVersions)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := map[int64]bool{}
+	for rows.Next() {
+		var version int64
+		if err := rows.Scan(&version); err != nil {
+			return nil, err
+		}
+		applied[version] = true
+	}
+	return applied, rows.Err()
+}
+
+// load reads <version>_<name>.up.sql and .down.sql pairs, sorted by version.
+func (m *Migrator) load() ([]Migration, error) {
+	files, err := fs.Glob(m.migrations, "*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int64]*Migration{}
+	for _, file := range files {
+		base, direction, ok := cutDirection(file)
+		if !ok {
+			continue
+		}
+		prefix, name, _ := strings.Cut(base, "_")
+		version, err := strconv.ParseInt(prefix, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: version must be numeric", file)
+		}
+		content, err := fs.ReadFile(m.migrations, file)
+		if err != nil {
+			return nil, err
+		}
+
+		mig, ok := byVersion[version]
+		if !ok {
+			mig = &Migration{Version: version, Name: name}
+			byVersion[version] = mig
+		}
+		if direction == "up" {
+			mig.Up = string(content)
+		} else {
+			mig.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, mig := range byVersion {
+		migrations = append(migrations, *mig)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+func cutDirection(file string) (base, direction string, ok bool) {
+	if base, ok := strings.CutSuffix(file, ".up.sql"); ok {
+		return base, "up", true
+	}
+	if base, ok := strings.CutSuffix(file, ".down.sql"); ok {
+		return base, "down", true
+	}
+	return "", "", false
+}
+`
+
+	mainTemplate := `// Command migrate applies the embedded migrations to DATABASE_URL.
+//
+//	go run ./cmd/migrate up|down|status|version
+{{- if eq .Driver "mysql"}}
+//
+// Migrations with several statements need multiStatements=true in the DSN.
+{{- end}}
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+
+	_ "{{.DriverImport}}"
+
+	"{{.MigrationsImport}}"
+	"{{.RunnerImport}}"
+)
+
+func main() {
+	if len(os.Args) != 2 {
+		fmt.Fprintln(os.Stderr, "usage: migrate up|down|status|version")
+		os.Exit(2)
+	}
+
+	dsn := os.Getenv("DATABASE_URL")
+	if dsn == "" {
+		fmt.Fprintln(os.Stderr, "DATABASE_URL is not set")
+		os.Exit(1)
+	}
+	db, err := sql.Open("{{.Driver}}", dsn)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening database: %v\n", err)
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	if err := run(context.Background(), migrate.New(db, migrations.FS), os.Args[1]); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, m *migrate.Migrator, command string) error {
+	switch command {
+	case "up":
+		applied, err := m.Up(ctx)
+		for _, mig := range applied {
+			fmt.Printf("applied %d_%s\n", mig.Version, mig.Name)
+		}
+		if err == nil && len(applied) == 0 {
+			fmt.Println("no pending migrations")
+		}
+		return err
+	case "down":
+		reverted, err := m.Down(ctx)
+		if err != nil {
+			return err
+		}
+		if reverted == nil {
+			fmt.Println("no applied migrations")
+		} else {
+			fmt.Printf("reverted %d_%s\n", reverted.Version, reverted.Name)
+		}
+		return nil
+	case "status":
+		statuses, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, s := range statuses {
+			state := "pending"
+			if s.Applied {
+				state = "applied"
+			}
+			fmt.Printf("%-8s %d_%s\n", state, s.Version, s.Name)
+		}
+		return nil
+	case "version":
+		version, err := m.Version(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Println(version)
+		return nil
+	default:
+		return fmt.Errorf("unknown command %q: use up, down, status or version", command)
+	}
+}
+`
+
+	for _, file := range []struct{ path, tmpl string }{
+		{filepath.Join(migrationsDir, "migrations.go"), embedTemplate},
+		{filepath.Join(runnerPath, "migrate.go"), runnerTemplate},
+		{filepath.Join(mainPath, "main.go"), mainTemplate},
+	} {
+		if _, err := os.Stat(file.path); err == nil {
+			continue
+		}
+		generateFile(file.path, file.tmpl, data)
+	}
+}
+
+func InitGenMigration(rootCmd *cobra.Command) {
+	rootCmd.AddCommand(migrationCmd)
+	migrationCmd.AddCommand(migrationCreateCmd)
+}
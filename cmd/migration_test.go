@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestNextMigrationVersion(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 30, 45, 0, time.FixedZone("CET", 3600))
+	tests := []struct {
+		name     string
+		existing []string
+		want     int64
+	}{
+		{"no migrations", nil, 20240301113045},
+		{"older migrations", []string{"20240101000000_create_users.up.sql", "20240101000000_create_users.down.sql"}, 20240301113045},
+		{"same second", []string{"20240301113045_create_users.up.sql"}, 20240301113046},
+		{"created ahead in the same second", []string{"20240301113045_a.up.sql", "20240301113046_b.up.sql"}, 20240301113047},
+		{"sequential versions", []string{"0001_init.up.sql", "0002_users.up.sql"}, 20240301113045},
+		{"other files", []string{"migrations.go", "README.md", "next_steps.sql"}, 20240301113045},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inModule(t, "app")
+			if tt.existing != nil {
+				if err := os.MkdirAll(migrationsDir, 0755); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for _, name := range tt.existing {
+				if err := os.WriteFile(filepath.Join(migrationsDir, name), nil, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if got := nextMigrationVersion(now); got != tt.want {
+				t.Errorf("nextMigrationVersion() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSQLColumnType(t *testing.T) {
+	spec := domainSpec{Enums: []string{"Status"}}
+	tests := []struct {
+		field    domainField
+		postgres string
+		mysql    string
+	}{
+		{domainField{Type: "string"}, "TEXT", "VARCHAR(255)"},
+		{domainField{Type: "*int64"}, "BIGINT", "BIGINT"},
+		{domainField{Type: "time.Time"}, "TIMESTAMPTZ", "DATETIME(6)"},
+		{domainField{Type: "Decimal", ValueObject: "Decimal"}, "NUMERIC", "DECIMAL(19,4)"},
+		{domainField{Type: "*Decimal", ValueObject: "Decimal"}, "NUMERIC", "DECIMAL(19,4)"},
+		{domainField{Type: "Money"}, "BIGINT", "BIGINT"},
+		{domainField{Type: "Status"}, "TEXT", "VARCHAR(64)"},
+		{domainField{Type: "[]byte"}, "BYTEA", "BLOB"},
+		{domainField{Type: "[]string"}, "TEXT[]", "JSON"},
+		{domainField{Type: "Address"}, "JSONB", "JSON"},
+	}
+	for _, tt := range tests {
+		if got := sqlColumnType(spec, tt.field, "postgres"); got != tt.postgres {
+			t.Errorf("sqlColumnType(%s, postgres) = %q, want %q", tt.field.Type, got, tt.postgres)
+		}
+		if got := sqlColumnType(spec, tt.field, "mysql"); got != tt.mysql {
+			t.Errorf("sqlColumnType(%s, mysql) = %q, want %q", tt.field.Type, got, tt.mysql)
+		}
+	}
+}
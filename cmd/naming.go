@@ -0,0 +1,218 @@
+package cmd
+
+import (
+	"fmt"
+	"go/token"
+	"os"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+// Names holds every form of a user supplied name that the generators need.
+// Directories and packages use Package, exported types use Pascal and
+// routes use Kebab.
+type Names struct {
+	Raw     string
+	Snake   string
+	Kebab   string
+	Camel   string
+	Pascal  string
+	Plural  string
+	Package string
+}
+
+// commonInitialisms are kept upper case in Pascal and camel case forms.
+var commonInitialisms = map[string]bool{
+	"api": true, "cpu": true, "css": true, "dns": true, "grpc": true,
+	"html": true, "http": true, "https": true, "id": true, "ip": true,
+	"json": true, "sql": true, "tcp": true, "tls": true, "ttl": true,
+	"udp": true, "ui": true, "uid": true, "uri": true, "url": true,
+	"uuid": true, "xml": true,
+}
+
+var irregularPlurals = map[string]string{
+	"person": "people",
+	"child":  "children",
+	"man":    "men",
+	"woman":  "women",
+	"mouse":  "mice",
+}
+
+var templateFuncs = template.FuncMap{
+	"snake":  toSnake,
+	"kebab":  toKebab,
+	"camel":  toCamel,
+	"pascal": toPascal,
+	"plural": toPlural,
+	"pkg":    toPackage,
+	"join":   strings.Join,
+}
+
+func newNames(raw string) (Names, error) {
+	if strings.TrimSpace(raw) == "" {
+		return Names{}, fmt.Errorf("name must not be empty")
+	}
+	for _, r := range raw {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-' && r != '_' && r != ' ' {
+			return Names{}, fmt.Errorf("invalid character %q in name %q", r, raw)
+		}
+	}
+
+	n := Names{
+		Raw:     raw,
+		Snake:   toSnake(raw),
+		Kebab:   toKebab(raw),
+		Camel:   toCamel(raw),
+		Pascal:  toPascal(raw),
+		Plural:  toPlural(toKebab(raw)),
+		Package: toPackage(raw),
+	}
+
+	if n.Package == "" || !token.IsIdentifier(n.Pascal) || !unicode.IsLetter([]rune(n.Package)[0]) {
+		return Names{}, fmt.Errorf("%q does not produce a valid Go identifier", raw)
+	}
+	if token.IsKeyword(n.Package) || token.IsKeyword(n.Camel) {
+		return Names{}, fmt.Errorf("%q is a Go keyword", raw)
+	}
+	return n, nil
+}
+
+// mustNames is newNames for command handlers: it reports the error and exits.
+func mustNames(raw string) Names {
+	n, err := newNames(raw)
+	if err != nil {
+		fmt.Printf("Invalid name: %v\n", err)
+		os.Exit(1)
+	}
+	return n
+}
+
+// splitWords breaks a name on separators and case changes, so "user-profile",
+// "user_profile", "UserProfile" and "userProfile" all yield [user profile]
+// and "UserIDs" yields [user ids].
+func splitWords(s string) []string {
+	var words []string
+	var current []rune
+	runes := []rune(s)
+
+	flush := func() {
+		if len(current) > 0 {
+			words = append(words, strings.ToLower(string(current)))
+			current = nil
+		}
+	}
+
+	for i, r := range runes {
+		switch {
+		case r == '-' || r == '_' || r == ' ':
+			flush()
+			continue
+		case unicode.IsUpper(r) && i > 0:
+			prev := runes[i-1]
+			nextIsLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			// A trailing s pluralises an initialism, as in IDs
+			if nextIsLower && runes[i+1] == 's' && (i+2 == len(runes) || !unicode.IsLower(runes[i+2])) {
+				nextIsLower = false
+			}
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextIsLower) {
+				flush()
+			}
+		}
+		current = append(current, r)
+	}
+	flush()
+	return words
+}
+
+func toSnake(s string) string {
+	return strings.Join(splitWords(s), "_")
+}
+
+func toKebab(s string) string {
+	return strings.Join(splitWords(s), "-")
+}
+
+func toPackage(s string) string {
+	return strings.Join(splitWords(s), "")
+}
+
+func toPascal(s string) string {
+	var b strings.Builder
+	for _, w := range splitWords(s) {
+		b.WriteString(capitalize(w))
+	}
+	return b.String()
+}
+
+func toCamel(s string) string {
+	words := splitWords(s)
+	if len(words) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString(words[0])
+	for _, w := range words[1:] {
+		b.WriteString(capitalize(w))
+	}
+	return b.String()
+}
+
+// toPlural pluralises the last word of s and leaves the rest untouched.
+func toPlural(s string) string {
+	lower := strings.ToLower(s)
+	if words := splitWords(s); len(words) > 0 {
+		last := words[len(words)-1]
+		if plural, ok := irregularPlurals[last]; ok && strings.HasSuffix(lower, last) {
+			return s[:len(s)-len(last)] + matchCase(s[len(s)-len(last):], plural)
+		}
+	}
+
+	switch {
+	case strings.HasSuffix(lower, "s"), strings.HasSuffix(lower, "x"), strings.HasSuffix(lower, "z"),
+		strings.HasSuffix(lower, "ch"), strings.HasSuffix(lower, "sh"):
+		return s + "es"
+	case strings.HasSuffix(lower, "y") && len(lower) > 1 && !strings.ContainsRune("aeiou", rune(lower[len(lower)-2])):
+		return s[:len(s)-1] + "ies"
+	}
+	return s + "s"
+}
+
+// toSingular reverses toPlural for the common cases, e.g. table names.
+func toSingular(s string) string {
+	lower := strings.ToLower(s)
+	for singular, plural := range irregularPlurals {
+		if strings.HasSuffix(lower, plural) {
+			return s[:len(s)-len(plural)] + matchCase(s[len(s)-len(plural):], singular)
+		}
+	}
+
+	switch {
+	case strings.HasSuffix(lower, "ies") && len(lower) > 3:
+		return s[:len(s)-3] + "y"
+	case strings.HasSuffix(lower, "sses"), strings.HasSuffix(lower, "xes"), strings.HasSuffix(lower, "zes"),
+		strings.HasSuffix(lower, "ches"), strings.HasSuffix(lower, "shes"), strings.HasSuffix(lower, "uses"):
+		return s[:len(s)-2]
+	case strings.HasSuffix(lower, "ss"), strings.HasSuffix(lower, "us"), strings.HasSuffix(lower, "is"):
+		return s
+	case strings.HasSuffix(lower, "s"):
+		return s[:len(s)-1]
+	}
+	return s
+}
+
+func capitalize(w string) string {
+	if commonInitialisms[w] {
+		return strings.ToUpper(w)
+	}
+	r := []rune(w)
+	r[0] = unicode.ToUpper(r[0])
+	return string(r)
+}
+
+func matchCase(original, replacement string) string {
+	if original != "" && unicode.IsUpper([]rune(original)[0]) {
+		return capitalize(replacement)
+	}
+	return replacement
+}
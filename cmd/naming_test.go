@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitWords(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"user-profile", []string{"user", "profile"}},
+		{"user_profile", []string{"user", "profile"}},
+		{"user profile", []string{"user", "profile"}},
+		{"UserProfile", []string{"user", "profile"}},
+		{"userProfile", []string{"user", "profile"}},
+		{"HTTPServer", []string{"http", "server"}},
+		{"userID", []string{"user", "id"}},
+		{"UserIDs", []string{"user", "ids"}},
+		{"IDsByUser", []string{"ids", "by", "user"}},
+		{"HTTPStatus", []string{"http", "status"}},
+		{"v2Api", []string{"v2", "api"}},
+		{"--user--", []string{"user"}},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		if got := splitWords(tt.in); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitWords(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestToPlural(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"user", "users"},
+		{"person", "people"},
+		{"Person", "People"},
+		{"sales-person", "sales-people"},
+		{"category", "categories"},
+		{"day", "days"},
+		{"status", "statuses"},
+		{"box", "boxes"},
+		{"match", "matches"},
+		{"wish", "wishes"},
+		{"child", "children"},
+		{"user-profile", "user-profiles"},
+	}
+	for _, tt := range tests {
+		if got := toPlural(tt.in); got != tt.want {
+			t.Errorf("toPlural(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestToSingular(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"users", "user"},
+		{"people", "person"},
+		{"women", "woman"},
+		{"categories", "category"},
+		{"statuses", "status"},
+		{"status", "status"},
+		{"addresses", "address"},
+		{"boxes", "box"},
+		{"matches", "match"},
+		{"analysis", "analysis"},
+		{"user", "user"},
+		{"order_items", "order_item"},
+	}
+	for _, tt := range tests {
+		if got := toSingular(tt.in); got != tt.want {
+			t.Errorf("toSingular(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestNewNames(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Names
+		wantErr bool
+	}{
+		{
+			in: "user-profile",
+			want: Names{Raw: "user-profile", Snake: "user_profile", Kebab: "user-profile", Camel: "userProfile",
+				Pascal: "UserProfile", Plural: "user-profiles", Package: "userprofile"},
+		},
+		{
+			in: "HTTPServer",
+			want: Names{Raw: "HTTPServer", Snake: "http_server", Kebab: "http-server", Camel: "httpServer",
+				Pascal: "HTTPServer", Plural: "http-servers", Package: "httpserver"},
+		},
+		{
+			in: "userID",
+			want: Names{Raw: "userID", Snake: "user_id", Kebab: "user-id", Camel: "userID",
+				Pascal: "UserID", Plural: "user-ids", Package: "userid"},
+		},
+		{
+			in: "person",
+			want: Names{Raw: "person", Snake: "person", Kebab: "person", Camel: "person",
+				Pascal: "Person", Plural: "people", Package: "person"},
+		},
+		{
+			in: "category",
+			want: Names{Raw: "category", Snake: "category", Kebab: "category", Camel: "category",
+				Pascal: "Category", Plural: "categories", Package: "category"},
+		},
+		{
+			in: "status",
+			want: Names{Raw: "status", Snake: "status", Kebab: "status", Camel: "status",
+				Pascal: "Status", Plural: "statuses", Package: "status"},
+		},
+		{in: "type", wantErr: true},
+		{in: "Func", wantErr: true},
+		{in: "range", wantErr: true},
+		{in: "2fa", wantErr: true},
+		{in: "3d-model", wantErr: true},
+		{in: "user.profile", wantErr: true},
+		{in: " ", wantErr: true},
+		{in: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := newNames(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("newNames(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && got != tt.want {
+			t.Errorf("newNames(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,746 @@
+package cmd
+
+import (
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// openapiOperation is an operation of an OpenAPI document and the handler
+// serving it.
+type openapiOperation struct {
+	Usecase Names
+	Handler string
+	Method  string
+	Path    string
+	// Status is the status code of a successful response and Response the
+	// type of its body, empty without one
+	Status   string
+	Response string
+	// Body is where the JSON body is decoded to, empty without one
+	Body     string
+	Bindings []string
+	// Types declares the request and response of the operation
+	Types string
+}
+
+// openapiFile is openapi.go of a handler package, with the operations of
+// the document served by the package and the types they use.
+type openapiFile struct {
+	Source     string
+	Package    Names
+	Router     httpRouter
+	Operations []*openapiOperation
+	Decls      []string
+	endpoint   *usecaseEndpoint
+	doc        *schemaDocument
+	decls      map[string]bool
+	// comments documents declared types that are not schemas of their own
+	comments map[string]string
+}
+
+// Imports renders the import block of openapi.go with every package it
+// may use; the unused ones are removed once it is written.
+func (f *openapiFile) Imports() string {
+	paths := append([]string{"encoding/json", "time"}, f.Router.Imports...)
+	for p := range f.endpoint.imports {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	return renderImports(paths)
+}
+
+// generateOpenAPIHandlers generates a handler for every operation of the
+// OpenAPI document at path. Operations go into the handler package names,
+// or else the package of their first tag. Running it again rewrites the
+// types and routing in openapi.go and keeps the handlers. It stops first
+// while operations the document dropped still have handlers.
+func generateOpenAPIHandlers(path string, names *Names) {
+	doc, err := loadSchemaDocument(path)
+	if err != nil {
+		fmt.Printf("Error reading OpenAPI document: %v\n", err)
+		os.Exit(1)
+	}
+	paths := schemaNodeGet(doc.root, "paths")
+	if paths == nil {
+		fmt.Printf("%s has no paths\n", path)
+		os.Exit(1)
+	}
+
+	router := projectRouter()
+	ensureValidationPackage()
+	files := map[string]*openapiFile{}
+	var order []string
+	for i := 0; i+1 < len(paths.Content); i += 2 {
+		route := paths.Content[i].Value
+		item, err := doc.deref(paths.Content[i+1])
+		if err != nil {
+			fmt.Printf("Error reading %s: %v\n", route, err)
+			os.Exit(1)
+		}
+		for j := 0; j+1 < len(item.Content); j += 2 {
+			method := strings.ToUpper(item.Content[j].Value)
+			switch method {
+			case "GET", "POST", "PUT", "PATCH", "DELETE":
+			default:
+				continue
+			}
+			op := item.Content[j+1]
+
+			pkg := "api"
+			if names != nil {
+				pkg = names.Raw
+			} else if tags := schemaNodeGet(op, "tags"); tags != nil && len(tags.Content) > 0 {
+				pkg = tags.Content[0].Value
+			}
+			pkgNames := mustNames(pkg)
+			f, ok := files[pkgNames.Package]
+			if !ok {
+				f = &openapiFile{
+					Source:   filepath.ToSlash(path),
+					Package:  pkgNames,
+					Router:   router,
+					endpoint: &usecaseEndpoint{Router: router, imports: map[string]bool{importPath(validationPath()): true}},
+					doc:      doc,
+					decls:    map[string]bool{},
+					comments: map[string]string{},
+				}
+				files[pkgNames.Package] = f
+				order = append(order, pkgNames.Package)
+			}
+			if err := f.addOperation(method, route, op, schemaNodeGet(item, "parameters")); err != nil {
+				fmt.Printf("Error converting %s %s: %v\n", method, route, err)
+				os.Exit(1)
+			}
+		}
+	}
+	if len(files) == 0 {
+		fmt.Printf("%s has no operations\n", path)
+		os.Exit(1)
+	}
+
+	// Dropping the types of an operation breaks its handler, so the
+	// handlers of removed operations go first
+	removed := false
+	for _, pkg := range order {
+		f := files[pkg]
+		for _, usecase := range f.removedOperations() {
+			fmt.Printf("%s is no longer in %s; remove its handler first with: go-ddd-skel remove handler %s --usecase %s\n", usecase, f.Source, f.Package.Package, usecase)
+			removed = true
+		}
+	}
+	if removed {
+		os.Exit(1)
+	}
+
+	imp := importer.ForCompiler(token.NewFileSet(), "source", nil)
+	for _, pkg := range order {
+		files[pkg].write(imp)
+	}
+	if router.Name != "net/http" {
+		tidyModule()
+	}
+}
+
+// write generates openapi.go and the handlers of new operations, with the
+// usecases they serve, and registers their routes.
+func (f *openapiFile) write(imp types.Importer) {
+	dir := f.dir()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		fmt.Printf("Error creating handler directory: %v\n", err)
+		os.Exit(1)
+	}
+	for _, op := range f.Operations {
+		usecaseDir := filepath.Join(layerPath(boundedContext, "usecase"), op.Usecase.Package)
+		if _, err := os.Stat(usecaseDir); err != nil {
+			createUsecaseStructure(op.Usecase, nil)
+		}
+	}
+
+	path := filepath.Join(dir, "openapi.go")
+	generateFile(path, openapiTemplate, f)
+	removeUnusedImports(path)
+	ensureHTTPHelpers(dir, f.Package, f.Router)
+
+	for _, op := range f.Operations {
+		usecaseDir := filepath.Join(layerPath(boundedContext, "usecase"), op.Usecase.Package)
+		writeIfMissing(filepath.Join(dir, op.Usecase.Snake+"_http.go"), openapiHandlerTemplate, map[string]interface{}{
+			"Package":       f.Package.Package,
+			"Op":            op,
+			"UsecaseImport": importPath(usecaseDir),
+		})
+		req, _ := loadUsecase(imp, op.Usecase)
+		registerRoutes(usecaseRegistration(req.pkg, op.Usecase, importPath(dir), f.Package.Package+".New"+op.Handler, "%s.RegisterRoutes(router)"))
+	}
+	fmt.Printf("Successfully generated handler %s from %s in %s\n", f.Package.Pascal, f.Source, dir)
+}
+
+func (f *openapiFile) dir() string {
+	return filepath.Join(layerPath(boundedContext, "interfaces"), f.Package.Package)
+}
+
+// removedOperations returns the usecases of the handlers openapi.go routes
+// to that are still there but whose operations the document no longer has.
+func (f *openapiFile) removedOperations() []string {
+	handlers := servedHandlers(filepath.Join(f.dir(), "openapi.go"))
+	for _, op := range f.Operations {
+		delete(handlers, op.Handler)
+	}
+	var usecases []string
+	for handler := range handlers {
+		usecase := mustNames(strings.TrimSuffix(handler, "HTTPHandler"))
+		if _, err := os.Stat(filepath.Join(f.dir(), usecase.Snake+"_http.go")); err == nil {
+			usecases = append(usecases, usecase.Pascal)
+		}
+	}
+	sort.Strings(usecases)
+	return usecases
+}
+
+// servedHandlers returns the handlers openapi.go at path routes to.
+func servedHandlers(path string) map[string]bool {
+	handlers := map[string]bool{}
+	if _, err := os.Stat(path); err != nil {
+		return handlers
+	}
+	_, _, file := parseGoFile(path)
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Name.Name != "RegisterRoutes" || fn.Recv == nil {
+			continue
+		}
+		if star, ok := fn.Recv.List[0].Type.(*ast.StarExpr); ok {
+			if ident, ok := star.X.(*ast.Ident); ok {
+				handlers[ident.Name] = true
+			}
+		}
+	}
+	return handlers
+}
+
+// addOperation adds the types and routing of the operation op of method
+// and route, whose path item declares the parameters shared.
+func (f *openapiFile) addOperation(method, route string, op, shared *yaml.Node) error {
+	id := schemaNodeString(op, "operationId")
+	if id == "" {
+		id = operationName(method, route)
+	}
+	usecase, err := newNames(id)
+	if err != nil {
+		return err
+	}
+	o := &openapiOperation{
+		Usecase: usecase,
+		Handler: usecase.Pascal + "HTTPHandler",
+		Method:  method,
+		Path:    route,
+		Status:  "http.StatusOK",
+	}
+	request := usecase.Pascal + "Request"
+	f.decls[request] = true
+
+	// The properties of an object body are fields of the request, as the
+	// parameters are
+	var fields []string
+	declared := map[string]bool{}
+	if body := schemaNodeGet(op, "requestBody"); body != nil {
+		body, err := f.doc.deref(body)
+		if err != nil {
+			return err
+		}
+		if schema := jsonSchema(body); schema != nil {
+			object, err := f.doc.deref(schema)
+			if err != nil {
+				return err
+			}
+			if schemaNodeGet(object, "properties") != nil || schemaNodeGet(object, "allOf") != nil {
+				lines, err := f.structFields(request, object, declared)
+				if err != nil {
+					return err
+				}
+				fields = append(fields, lines...)
+				o.Body = "&req"
+			} else {
+				t, _, err := f.goType(request+"Body", schema, true)
+				if err != nil {
+					return err
+				}
+				fields = append(fields, "Body "+t+" `json:\"-\"`")
+				o.Body = "&req.Body"
+			}
+		}
+	}
+
+	params, err := f.parameters(shared, schemaNodeGet(op, "parameters"), route)
+	if err != nil {
+		return err
+	}
+	for _, p := range params {
+		in, name := schemaNodeString(p, "in"), schemaNodeString(p, "name")
+		if in != "path" && in != "query" {
+			fmt.Printf("Bind the %s parameter %s of %s %s by hand\n", in, name, method, route)
+			continue
+		}
+		required := in == "path" || schemaNodeString(p, "required") == "true"
+		schema := schemaNodeGet(p, "schema")
+		if schema == nil {
+			schema = &yaml.Node{Kind: yaml.MappingNode, Content: []*yaml.Node{{Kind: yaml.ScalarNode, Value: "type"}, {Kind: yaml.ScalarNode, Value: "string"}}}
+		}
+		t, rules, err := f.goType(request+toPascal(name), schema, required)
+		if err != nil {
+			return fmt.Errorf("parameter %s: %w", name, err)
+		}
+		field := toPascal(name)
+		if !declared[field] {
+			declared[field] = true
+			fields = append(fields, dtoField(field, name, t, rules, required))
+		}
+
+		src := fmt.Sprintf(f.Router.Query, name)
+		if in == "path" {
+			src = fmt.Sprintf(f.Router.PathParam, name)
+		}
+		paramType := dtoParamType(t)
+		if paramType == nil {
+			fmt.Printf("Bind %s (%s) by hand: its type cannot be read from a parameter\n", name, t)
+			o.Bindings = append(o.Bindings, fmt.Sprintf("// Bind %s (%s) by hand", name, t))
+			continue
+		}
+		o.Bindings = append(o.Bindings, f.endpoint.bind(types.NewVar(token.NoPos, nil, field, paramType), name, src))
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "// %s is the request of %s %s.\ntype %s struct {\n", request, method, route, request)
+	for _, field := range fields {
+		b.WriteString("\t" + field + "\n")
+	}
+	fmt.Fprintf(&b, "}\n\n// Validate returns a *validation.Error listing every invalid field.\nfunc (r *%s) Validate() error {\n\treturn validation.Struct(r)\n}\n", request)
+
+	code, schema, err := f.successResponse(op)
+	if err != nil {
+		return err
+	}
+	if code != "" {
+		o.Status = httpStatus(code)
+	}
+	if schema != nil {
+		o.Response = usecase.Pascal + "Response"
+		comment := fmt.Sprintf("%s is the %s response of %s %s.", o.Response, code, method, route)
+		f.comments[o.Response] = comment
+		t, _, err := f.goType(o.Response, schema, true)
+		if err != nil {
+			return fmt.Errorf("response: %w", err)
+		}
+		if t != o.Response {
+			fmt.Fprintf(&b, "\n// %s\ntype %s = %s\n", comment, o.Response, t)
+		}
+	}
+	o.Types = b.String()
+	f.Operations = append(f.Operations, o)
+	return nil
+}
+
+// parameters returns the parameters of an operation: those of the path
+// item, overridden by the operation's own, and any path parameter of route
+// neither declares.
+func (f *openapiFile) parameters(shared, own *yaml.Node, route string) ([]*yaml.Node, error) {
+	var params []*yaml.Node
+	index := map[string]int{}
+	for _, list := range []*yaml.Node{shared, own} {
+		if list == nil {
+			continue
+		}
+		for _, p := range list.Content {
+			p, err := f.doc.deref(p)
+			if err != nil {
+				return nil, err
+			}
+			key := schemaNodeString(p, "in") + " " + schemaNodeString(p, "name")
+			if i, ok := index[key]; ok {
+				params[i] = p
+				continue
+			}
+			index[key] = len(params)
+			params = append(params, p)
+		}
+	}
+	for _, segment := range strings.Split(route, "/") {
+		if !strings.HasPrefix(segment, "{") || !strings.HasSuffix(segment, "}") {
+			continue
+		}
+		name := strings.TrimSuffix(strings.TrimPrefix(segment, "{"), "}")
+		if _, ok := index["path "+name]; !ok {
+			index["path "+name] = len(params)
+			params = append(params, &yaml.Node{Kind: yaml.MappingNode, Content: []*yaml.Node{
+				{Kind: yaml.ScalarNode, Value: "name"}, {Kind: yaml.ScalarNode, Value: name},
+				{Kind: yaml.ScalarNode, Value: "in"}, {Kind: yaml.ScalarNode, Value: "path"},
+			}})
+		}
+	}
+	return params, nil
+}
+
+// successResponse returns the lowest 2xx status code of the responses of
+// op and the schema of its JSON body, if it has one.
+func (f *openapiFile) successResponse(op *yaml.Node) (string, *yaml.Node, error) {
+	responses := schemaNodeGet(op, "responses")
+	if responses == nil {
+		return "", nil, nil
+	}
+	var codes []string
+	for i := 0; i+1 < len(responses.Content); i += 2 {
+		if code := responses.Content[i].Value; strings.HasPrefix(code, "2") && len(code) == 3 {
+			codes = append(codes, code)
+		}
+	}
+	if len(codes) == 0 {
+		return "", nil, nil
+	}
+	sort.Strings(codes)
+	response, err := f.doc.deref(schemaNodeGet(responses, codes[0]))
+	if err != nil {
+		return "", nil, err
+	}
+	return codes[0], jsonSchema(response), nil
+}
+
+// structFields converts the properties of an object schema into struct
+// fields with json and validate tags, skipping the names in declared.
+func (f *openapiFile) structFields(owner string, schema *yaml.Node, declared map[string]bool) ([]string, error) {
+	properties, required, err := f.doc.properties(schema)
+	if err != nil {
+		return nil, err
+	}
+	var fields []string
+	for i := 0; i+1 < len(properties.Content); i += 2 {
+		prop := properties.Content[i].Value
+		propSchema := properties.Content[i+1]
+		isRequired := required[prop] && schemaNodeString(propSchema, "nullable") != "true"
+
+		t, rules, err := f.goType(owner+toPascal(prop), propSchema, isRequired)
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: %w", owner, prop, err)
+		}
+		if declared[toPascal(prop)] {
+			continue
+		}
+		declared[toPascal(prop)] = true
+		fields = append(fields, dtoField(toPascal(prop), prop, t, rules, isRequired))
+	}
+	return fields, nil
+}
+
+// goType maps a schema to a Go type and the validate rules for it,
+// declaring nested objects on the way. Optional values become pointers
+// and enums strings checked with oneof.
+func (f *openapiFile) goType(suggested string, schema *yaml.Node, required bool) (string, []string, error) {
+	if ref := schemaNodeString(schema, "$ref"); ref != "" {
+		suggested = toPascal(ref[strings.LastIndex(ref, "/")+1:])
+	}
+	schema, err := f.doc.deref(schema)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var rules []string
+	limit := func(rule, key string) {
+		if n := schemaNodeString(schema, key); n != "" {
+			rules = append(rules, rule+"="+n)
+		}
+	}
+	optional := func(t string) string {
+		if required {
+			return t
+		}
+		return "*" + t
+	}
+	// Zero numbers and booleans are valid values, so only the others can
+	// be required
+	requiredRule := func() {
+		if required {
+			rules = append([]string{"required"}, rules...)
+		}
+	}
+
+	switch schemaNodeString(schema, "type") {
+	case "string":
+		t := "string"
+		switch schemaNodeString(schema, "format") {
+		case "date-time":
+			t = "time.Time"
+		case "byte":
+			t = "[]byte"
+		case "email":
+			rules = append(rules, "email")
+		}
+		if enum := schemaNodeGet(schema, "enum"); enum != nil {
+			var values []string
+			for _, v := range enum.Content {
+				if v.Value == "" || strings.ContainsAny(v.Value, " ,") {
+					values = nil
+					break
+				}
+				values = append(values, v.Value)
+			}
+			if len(values) > 0 {
+				rules = append(rules, "oneof="+strings.Join(values, " "))
+			}
+		}
+		if t == "string" {
+			limit("min", "minLength")
+			limit("max", "maxLength")
+		}
+		requiredRule()
+		if t == "[]byte" {
+			return t, rules, nil
+		}
+		return optional(t), rules, nil
+	case "integer", "number":
+		t := "int64"
+		switch {
+		case schemaNodeString(schema, "type") == "number" && schemaNodeString(schema, "format") == "float":
+			t = "float32"
+		case schemaNodeString(schema, "type") == "number":
+			t = "float64"
+		case schemaNodeString(schema, "format") == "int32":
+			t = "int32"
+		}
+		limit("min", "minimum")
+		limit("max", "maximum")
+		return optional(t), rules, nil
+	case "boolean":
+		return optional("bool"), nil, nil
+	case "array":
+		item := "json.RawMessage"
+		if items := schemaNodeGet(schema, "items"); items != nil {
+			if item, _, err = f.goType(toSingular(suggested), items, true); err != nil {
+				return "", nil, err
+			}
+		}
+		limit("min", "minItems")
+		limit("max", "maxItems")
+		requiredRule()
+		return "[]" + item, rules, nil
+	}
+
+	if schemaNodeGet(schema, "properties") != nil || schemaNodeGet(schema, "allOf") != nil {
+		if err := f.declare(suggested, schema); err != nil {
+			return "", nil, err
+		}
+		return optional(suggested), nil, nil
+	}
+	if additional := schemaNodeGet(schema, "additionalProperties"); additional != nil && additional.Kind == yaml.MappingNode {
+		value, _, err := f.goType(suggested+"Value", additional, true)
+		if err != nil {
+			return "", nil, err
+		}
+		requiredRule()
+		return "map[string]" + value, rules, nil
+	}
+	requiredRule()
+	return "json.RawMessage", rules, nil
+}
+
+// declare adds a struct for an object schema once.
+func (f *openapiFile) declare(name string, schema *yaml.Node) error {
+	if f.decls[name] {
+		return nil
+	}
+	f.decls[name] = true
+
+	fields, err := f.structFields(name, schema, map[string]bool{})
+	if err != nil {
+		return err
+	}
+	var b strings.Builder
+	if comment, ok := f.comments[name]; ok {
+		fmt.Fprintf(&b, "// %s\n", comment)
+	} else {
+		fmt.Fprintf(&b, "// %s is a schema of %s.\n", name, f.Source)
+	}
+	fmt.Fprintf(&b, "type %s struct {\n", name)
+	for _, field := range fields {
+		b.WriteString("\t" + field + "\n")
+	}
+	b.WriteString("}\n")
+	f.Decls = append(f.Decls, b.String())
+	return nil
+}
+
+// dtoField declares the field name of type t for the property prop.
+func dtoField(name, prop, t string, rules []string, required bool) string {
+	tag := "json:\"" + prop
+	if !required {
+		tag += ",omitempty"
+	}
+	tag += "\""
+	if len(rules) > 0 {
+		tag += " validate:\"" + strings.Join(rules, ",") + "\""
+	}
+	return fmt.Sprintf("%s %s `%s`", name, t, tag)
+}
+
+// dtoParamType returns the type the parameter binding is generated for,
+// or nil when it cannot be read from a parameter.
+func dtoParamType(t string) types.Type {
+	if elem := strings.TrimPrefix(t, "*"); elem != t {
+		if elemType := dtoParamType(elem); elemType != nil {
+			return types.NewPointer(elemType)
+		}
+		return nil
+	}
+	switch t {
+	case "string":
+		return types.Typ[types.String]
+	case "int32":
+		return types.Typ[types.Int32]
+	case "int64":
+		return types.Typ[types.Int64]
+	case "float32":
+		return types.Typ[types.Float32]
+	case "float64":
+		return types.Typ[types.Float64]
+	case "bool":
+		return types.Typ[types.Bool]
+	case "time.Time":
+		return types.NewNamed(types.NewTypeName(token.NoPos, types.NewPackage("time", "time"), "Time", nil), types.NewStruct(nil, nil), nil)
+	}
+	return nil
+}
+
+// jsonSchema returns the schema of the JSON content of a request body or
+// response.
+func jsonSchema(node *yaml.Node) *yaml.Node {
+	content := schemaNodeGet(node, "content")
+	if content == nil {
+		return nil
+	}
+	for i := 0; i+1 < len(content.Content); i += 2 {
+		mediaType := content.Content[i].Value
+		if mediaType == "application/json" || strings.HasSuffix(mediaType, "+json") {
+			return schemaNodeGet(content.Content[i+1], "schema")
+		}
+	}
+	return nil
+}
+
+// operationName names an operation without an operationId after its
+// method and path, e.g. GetUsersByID for GET /users/{id}.
+func operationName(method, route string) string {
+	name := capitalize(strings.ToLower(method))
+	for _, segment := range strings.Split(route, "/") {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			name += "By" + toPascal(strings.Trim(segment, "{}"))
+		} else {
+			name += toPascal(segment)
+		}
+	}
+	return name
+}
+
+// httpStatus returns the net/http constant of a status code.
+func httpStatus(code string) string {
+	switch code {
+	case "200":
+		return "http.StatusOK"
+	case "201":
+		return "http.StatusCreated"
+	case "202":
+		return "http.StatusAccepted"
+	case "204":
+		return "http.StatusNoContent"
+	}
+	return code
+}
+
+const openapiTemplate = `// Code generated by go-ddd-skel from {{.Source}}; DO NOT EDIT.
+// Change the document and run the handler command with --from-openapi
+// again instead. The handlers are implemented in the _http.go files.
+
+package {{.Package.Package}}
+
+{{.Imports}}
+{{- range .Operations}}
+{{.Types}}
+{{- end}}
+{{- range .Decls}}
+{{.}}
+{{- end}}
+{{- range .Operations}}
+func (h *{{.Handler}}) RegisterRoutes(router {{$.Router.Type}}) {
+	{{$.Router.Route .Method .Path "h.handle"}}
+}
+
+func (h *{{.Handler}}) handle({{$.Router.Params}}){{$.Router.Result}} {
+	if h.usecase == nil {
+		{{$.Router.Fail "errNotImplemented"}}
+	}
+	var req {{.Usecase.Pascal}}Request
+{{- if .Body}}
+	if err := decodeJSON({{$.Router.Body}}, {{.Body}}); err != nil {
+		{{$.Router.Fail "err"}}
+	}
+{{- end}}
+{{- range .Bindings}}
+	{{.}}
+{{- end}}
+	if err := req.Validate(); err != nil {
+		{{$.Router.Fail "err"}}
+	}
+{{- if .Response}}
+	resp, err := h.Handle({{$.Router.Ctx}}, &req)
+	if err != nil {
+		{{$.Router.Fail "err"}}
+	}
+	{{$.Router.Respond .Status "resp"}}
+{{- else}}
+	if err := h.Handle({{$.Router.Ctx}}, &req); err != nil {
+		{{$.Router.Fail "err"}}
+	}
+	{{$.Router.NoContent .Status}}
+{{- end}}
+}
+{{end}}`
+
+const openapiHandlerTemplate = `package {{.Package}}
+
+import (
+	"context"
+
+	"{{.UsecaseImport}}"
+)
+
+// {{.Op.Handler}} serves {{.Op.Method}} {{.Op.Path}} with the {{.Op.Usecase.Package}} usecase.
+// Its request is bound and validated by the code in openapi.go.
+type {{.Op.Handler}} struct {
+	usecase {{.Op.Usecase.Package}}.{{.Op.Usecase.Pascal}}Service
+}
+
+func New{{.Op.Handler}}(usecase {{.Op.Usecase.Package}}.{{.Op.Usecase.Pascal}}Service) *{{.Op.Handler}} {
+	return &{{.Op.Handler}}{usecase: usecase}
+}
+
+// Handle serves a valid request. Errors are answered like usecase errors.
+func (h *{{.Op.Handler}}) Handle(ctx context.Context, req *{{.Op.Usecase.Pascal}}Request) {{if .Op.Response}}(*{{.Op.Response}}, error){{else}}error{{end}} {
+{{- if .Op.Response}}
+	// Map req onto the usecase request and its response onto the result
+	_, err := h.usecase.Execute(ctx, &{{.Op.Usecase.Package}}.Request{})
+	if err != nil {
+		return nil, err
+	}
+	return new({{.Op.Response}}), nil
+{{- else}}
+	// Map req onto the usecase request
+	_, err := h.usecase.Execute(ctx, &{{.Op.Usecase.Package}}.Request{})
+	return err
+{{- end}}
+}
+`
@@ -0,0 +1,255 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const openapiDoc = `
+openapi: 3.0.3
+paths:
+  /users/{id}:
+    parameters:
+      - {name: id, in: path, schema: {type: integer, format: int64}}
+    get:
+      operationId: getUser
+      parameters:
+        - {name: fields, in: query, schema: {type: string}}
+        - {name: X-Request-ID, in: header, schema: {type: string}}
+      responses:
+        "200":
+          content:
+            application/json:
+              schema: {$ref: "#/components/schemas/User"}
+        "404": {description: not found}
+    put:
+      requestBody:
+        content:
+          application/json:
+            schema:
+              type: object
+              required: [name]
+              properties:
+                name: {type: string, minLength: 1}
+                email: {type: string, format: email}
+      responses:
+        "204": {description: updated}
+  /users:
+    post:
+      operationId: createUser
+      requestBody:
+        content:
+          application/json:
+            schema: {type: array, items: {type: string}}
+      responses:
+        "201":
+          content:
+            application/json:
+              schema: {$ref: "#/components/schemas/User"}
+components:
+  schemas:
+    User:
+      type: object
+      required: [id, status]
+      properties:
+        id: {type: integer, format: int64}
+        status: {type: string, enum: [active, banned]}
+        address:
+          type: object
+          properties:
+            city: {type: string}
+`
+
+// newTestOpenAPIFile returns the openapi.go of the users handler package
+// for the document src on net/http.
+func newTestOpenAPIFile(t *testing.T, src string) *openapiFile {
+	t.Helper()
+	router := httpRouters["net/http"]
+	return &openapiFile{
+		Source:   "api.yaml",
+		Package:  mustNames("users"),
+		Router:   router,
+		endpoint: &usecaseEndpoint{Router: router, imports: map[string]bool{}},
+		doc:      parseSchemaDocument(t, src),
+		decls:    map[string]bool{},
+		comments: map[string]string{},
+	}
+}
+
+func TestOpenAPIAddOperation(t *testing.T) {
+	tests := []struct {
+		method, route string
+		usecase       string
+		status        string
+		response      string
+		body          string
+		bindings      []string
+		types         string
+		decls         []string
+	}{
+		{
+			method: "GET", route: "/users/{id}",
+			usecase: "GetUser", status: "http.StatusOK", response: "GetUserResponse",
+			bindings: []string{`r.PathValue("id")`, `r.URL.Query().Get("fields")`},
+			types: `// GetUserRequest is the request of GET /users/{id}.
+type GetUserRequest struct {
+	ID int64 ` + "`json:\"id\"`" + `
+	Fields *string ` + "`json:\"fields,omitempty\"`" + `
+}
+
+// Validate returns a *validation.Error listing every invalid field.
+func (r *GetUserRequest) Validate() error {
+	return validation.Struct(r)
+}
+
+// GetUserResponse is the 200 response of GET /users/{id}.
+type GetUserResponse = User
+`,
+			decls: []string{"UserAddress", "User"},
+		},
+		{
+			method: "PUT", route: "/users/{id}",
+			usecase: "PutUsersByID", status: "http.StatusNoContent", body: "&req",
+			bindings: []string{`r.PathValue("id")`},
+			types: `// PutUsersByIDRequest is the request of PUT /users/{id}.
+type PutUsersByIDRequest struct {
+	Name string ` + "`json:\"name\" validate:\"required,min=1\"`" + `
+	Email *string ` + "`json:\"email,omitempty\" validate:\"email\"`" + `
+	ID int64 ` + "`json:\"id\"`" + `
+}
+
+// Validate returns a *validation.Error listing every invalid field.
+func (r *PutUsersByIDRequest) Validate() error {
+	return validation.Struct(r)
+}
+`,
+		},
+		{
+			method: "POST", route: "/users",
+			usecase: "CreateUser", status: "http.StatusCreated", response: "CreateUserResponse", body: "&req.Body",
+			types: `// CreateUserRequest is the request of POST /users.
+type CreateUserRequest struct {
+	Body []string ` + "`json:\"-\"`" + `
+}
+
+// Validate returns a *validation.Error listing every invalid field.
+func (r *CreateUserRequest) Validate() error {
+	return validation.Struct(r)
+}
+
+// CreateUserResponse is the 201 response of POST /users.
+type CreateUserResponse = User
+`,
+			decls: []string{"UserAddress", "User"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.route, func(t *testing.T) {
+			inModule(t, "app")
+			f := newTestOpenAPIFile(t, openapiDoc)
+			item := schemaNodeGet(schemaNodeGet(f.doc.root, "paths"), tt.route)
+			op := schemaNodeGet(item, strings.ToLower(tt.method))
+			if err := f.addOperation(tt.method, tt.route, op, schemaNodeGet(item, "parameters")); err != nil {
+				t.Fatal(err)
+			}
+			o := f.Operations[0]
+			if o.Usecase.Pascal != tt.usecase || o.Handler != tt.usecase+"HTTPHandler" || o.Status != tt.status || o.Response != tt.response || o.Body != tt.body {
+				t.Errorf("operation = %s %s %s %q %q, want %s %s %s %q %q",
+					o.Usecase.Pascal, o.Handler, o.Status, o.Response, o.Body, tt.usecase, tt.usecase+"HTTPHandler", tt.status, tt.response, tt.body)
+			}
+			if o.Types != tt.types {
+				t.Errorf("types =\n%s\nwant\n%s", o.Types, tt.types)
+			}
+			if len(o.Bindings) != len(tt.bindings) {
+				t.Fatalf("bindings = %q, want ones reading %q", o.Bindings, tt.bindings)
+			}
+			for i, src := range tt.bindings {
+				if !strings.Contains(o.Bindings[i], src) {
+					t.Errorf("binding %d = %q, want it to read %s", i, o.Bindings[i], src)
+				}
+			}
+			var decls []string
+			for _, d := range f.Decls {
+				decls = append(decls, strings.Fields(d[strings.Index(d, "type "):])[1])
+			}
+			if !reflect.DeepEqual(decls, tt.decls) {
+				t.Errorf("declared %q, want %q", decls, tt.decls)
+			}
+		})
+	}
+}
+
+func TestOpenAPIDeclare(t *testing.T) {
+	inModule(t, "app")
+	f := newTestOpenAPIFile(t, openapiDoc)
+	schema := schemaNodeGet(schemaNodeGet(schemaNodeGet(f.doc.root, "components"), "schemas"), "User")
+	if err := f.declare("User", schema); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{`// UserAddress is a schema of api.yaml.
+type UserAddress struct {
+	City *string ` + "`json:\"city,omitempty\"`" + `
+}
+`, `// User is a schema of api.yaml.
+type User struct {
+	ID int64 ` + "`json:\"id\"`" + `
+	Status string ` + "`json:\"status\" validate:\"required,oneof=active banned\"`" + `
+	Address *UserAddress ` + "`json:\"address,omitempty\"`" + `
+}
+`}
+	if !reflect.DeepEqual(f.Decls, want) {
+		t.Errorf("declare() =\n%s\nwant\n%s", strings.Join(f.Decls, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestOperationName(t *testing.T) {
+	tests := []struct {
+		method, route string
+		want          string
+	}{
+		{"GET", "/users", "GetUsers"},
+		{"GET", "/users/{id}", "GetUsersByID"},
+		{"DELETE", "/orders/{order_id}/items/{item_id}", "DeleteOrdersByOrderIDItemsByItemID"},
+		{"POST", "/", "Post"},
+	}
+	for _, tt := range tests {
+		if got := operationName(tt.method, tt.route); got != tt.want {
+			t.Errorf("operationName(%s, %s) = %q, want %q", tt.method, tt.route, got, tt.want)
+		}
+	}
+}
+
+func TestOpenAPIRemovedOperations(t *testing.T) {
+	dir := inModule(t, "app")
+	pkgDir := filepath.Join(dir, "internal", "interfaces", "users")
+	if err := os.MkdirAll(pkgDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	// openapi.go still routes to three handlers; DeleteUser has been
+	// removed already and GetUser is still in the document
+	files := map[string]string{
+		"openapi.go": `package users
+
+func (h *GetUserHTTPHandler) RegisterRoutes(router *http.ServeMux) {}
+
+func (h *ArchiveUserHTTPHandler) RegisterRoutes(router *http.ServeMux) {}
+
+func (h *DeleteUserHTTPHandler) RegisterRoutes(router *http.ServeMux) {}
+`,
+		"get_user_http.go":     "package users\n",
+		"archive_user_http.go": "package users\n",
+	}
+	for name, src := range files {
+		if err := os.WriteFile(filepath.Join(pkgDir, name), []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	f := newTestOpenAPIFile(t, openapiDoc)
+	f.Operations = []*openapiOperation{{Handler: "GetUserHTTPHandler"}}
+	if got, want := f.removedOperations(), []string{"ArchiveUser"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("removedOperations() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,151 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// knownPorts are the ports usecases commonly depend on, with a default
+// implementation where one is obvious.
+var knownPorts = map[string]string{
+	"Clock": `import "time"
+
+// Clock is the port for reading the current time, so usecases can be tested
+// at a fixed time.
+type Clock interface {
+	Now() time.Time
+}
+
+// SystemClock is the Clock backed by time.Now.
+type SystemClock struct{}
+
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+`,
+	"EventBus": `import (
+	"context"
+	"sync"
+)
+
+// Event is something that happened in the domain.
+type Event interface {
+	EventName() string
+}
+
+// EventBus is the port for publishing domain events.
+type EventBus interface {
+	Publish(ctx context.Context, events ...Event) error
+}
+
+// EventHandler handles a published event.
+type EventHandler func(ctx context.Context, event Event) error
+
+// LocalEventBus is the EventBus delivering events to the handlers
+// subscribed in the same process, until one is published to a broker.
+type LocalEventBus struct {
+	mu       sync.RWMutex
+	handlers map[string][]EventHandler
+}
+
+func NewLocalEventBus() *LocalEventBus {
+	return &LocalEventBus{handlers: map[string][]EventHandler{}}
+}
+
+// Subscribe has handler called with the events named name.
+func (b *LocalEventBus) Subscribe(name string, handler EventHandler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers[name] = append(b.handlers[name], handler)
+}
+
+// Publish calls the handlers of each event in turn and stops at the first
+// error.
+func (b *LocalEventBus) Publish(ctx context.Context, events ...Event) error {
+	for _, event := range events {
+		b.mu.RLock()
+		handlers := b.handlers[event.EventName()]
+		b.mu.RUnlock()
+		for _, handle := range handlers {
+			if err := handle(ctx, event); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+`,
+	"Metrics": `// Metrics is the port for recording Prometheus-style metrics, e.g. with a
+// CounterVec and a HistogramVec per name.
+type Metrics interface {
+	IncCounter(name string, labels map[string]string)
+	ObserveHistogram(name string, value float64, labels map[string]string)
+}
+`,
+	"UnitOfWork": `import "context"
+
+// UnitOfWork is the port for making several writes atomically. Do commits
+// them when fn returns nil and rolls them back otherwise. The context passed
+// to fn carries the transaction, so repositories called with it take part.
+type UnitOfWork interface {
+	Do(ctx context.Context, fn func(ctx context.Context) error) error
+}
+`,
+}
+
+// portsPath is the directory of the ports package.
+func portsPath() string {
+	return layerPath(boundedContext, "adapters/ports")
+}
+
+// ensurePort writes the port interface name into the ports package unless
+// it is already declared there. Names ending in ReadModel get a read-model
+// port and other unknown ports an empty interface.
+func ensurePort(name string) {
+	dir := portsPath()
+	if pkg, err := parseSourcePackage(dir); err == nil && pkg.declares(name) {
+		return
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		fmt.Printf("Error creating ports directory: %v\n", err)
+		os.Exit(1)
+	}
+
+	code, ok := knownPorts[name]
+	switch {
+	case ok:
+	case strings.HasSuffix(name, "ReadModel") && name != "ReadModel":
+		code = readModelPort(strings.TrimSuffix(name, "ReadModel"))
+	default:
+		code = fmt.Sprintf("// %s is a port to be implemented by an adapter.\ntype %s interface {\n\t// Add methods here\n}\n", name, name)
+	}
+	path := filepath.Join(dir, toSnake(name)+".go")
+	generateFile(path, "package ports\n\n{{.}}", code)
+
+	fmt.Printf("Created port %s in %s\n", name, path)
+}
+
+// readModelPort returns the port through which queries read views of
+// entity, kept apart from the aggregate repository so the read side can be
+// shaped, stored and scaled for queries.
+func readModelPort(entity string) string {
+	return fmt.Sprintf(`import "context"
+
+// %[1]sView is the read-side projection of a %[1]s, shaped for queries
+// rather than for the aggregate's invariants.
+type %[1]sView struct {
+	ID string
+	// Add the fields queries need
+}
+
+// %[1]sReadModel is the port for querying %[1]s views.
+type %[1]sReadModel interface {
+	// Get returns nil and no error when there is no view with the given id.
+	Get(ctx context.Context, id string) (*%[1]sView, error)
+	// List returns up to limit views after cursor and the cursor of the next page, empty on the last one.
+	List(ctx context.Context, cursor string, limit int) ([]%[1]sView, string, error)
+}
+`, entity)
+}
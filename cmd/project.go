@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// projectFile records the choices made at init and everything later
+// commands need to know about the project.
+const projectFile = ".go-ddd-skel.json"
+
+type projectManifest struct {
+	ProjectConfig
+	Contexts []string `json:"contexts,omitempty"`
+}
+
+// loadProject reads the manifest from the current directory. Projects created
+// before the manifest existed get the defaults of an empty ProjectConfig.
+func loadProject() projectManifest {
+	var m projectManifest
+	data, err := os.ReadFile(projectFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return m
+	}
+	if err != nil {
+		fmt.Printf("Error reading %s: %v\n", projectFile, err)
+		os.Exit(1)
+	}
+	if err := json.Unmarshal(data, &m); err != nil {
+		fmt.Printf("Error parsing %s: %v\n", projectFile, err)
+		os.Exit(1)
+	}
+	return m
+}
+
+// saveProject writes the manifest into the project directory dir.
+func saveProject(dir string, m projectManifest) {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		fmt.Printf("Error encoding %s: %v\n", projectFile, err)
+		os.Exit(1)
+	}
+	if err := os.WriteFile(filepath.Join(dir, projectFile), append(data, '\n'), 0644); err != nil {
+		fmt.Printf("Error writing %s: %v\n", projectFile, err)
+		os.Exit(1)
+	}
+}
+
+func (m projectManifest) hasContext(pkg string) bool {
+	for _, c := range m.Contexts {
+		if c == pkg {
+			return true
+		}
+	}
+	return false
+}
+
+// layerPath returns where a layer such as "core" or "usecase" lives, either
+// at the top of internal/ or inside a bounded context.
+func layerPath(contextName, layer string) string {
+	if contextName == "" {
+		return filepath.Join("internal", layer)
+	}
+
+	names := mustNames(contextName)
+	if !loadProject().hasContext(names.Package) {
+		fmt.Printf("Unknown bounded context %s. Create it first with: go-ddd-skel context %s\n", names.Pascal, names.Pascal)
+		os.Exit(1)
+	}
+	return filepath.Join("internal", "contexts", names.Package, layer)
+}
+
+// importPath returns the import path of a directory inside the project.
+func importPath(dir string) string {
+	return modulePath() + "/" + filepath.ToSlash(dir)
+}
+
+// modulePath reads the module path from go.mod in the current directory,
+// which generated code needs to import packages of the same project.
+func modulePath() string {
+	mod, err := readModulePath()
+	if err != nil {
+		fmt.Printf("Error reading go.mod: %v\nRun this command from the project root.\n", err)
+		os.Exit(1)
+	}
+	return mod
+}
+
+// currentModule is modulePath for callers that can do without one: it
+// returns "" outside a module.
+func currentModule() string {
+	mod, _ := readModulePath()
+	return mod
+}
+
+func readModulePath() (string, error) {
+	f, err := os.Open("go.mod")
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "module ") {
+			return strings.Trim(strings.TrimSpace(strings.TrimPrefix(line, "module ")), `"`), nil
+		}
+	}
+	return "", fmt.Errorf("no module directive found")
+}
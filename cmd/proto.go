@@ -0,0 +1,599 @@
+package cmd
+
+import (
+	"fmt"
+	"go/importer"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// protoService is the gRPC service of a handler package. Its .proto file
+// has an rpc per usecase, with messages made from the fields of the
+// usecase's Request and Response.
+type protoService struct {
+	Package   string
+	GoPackage string
+	Service   string
+	RPCs      []string
+	Messages  []*protoMessage
+	Imports   []string
+	pb        string
+	byType    map[string]*protoMessage
+	// numbers keeps the field numbers of the existing file, so regenerating
+	// it does not break clients, and reserved those of removed fields
+	numbers  map[string]map[string]int
+	reserved map[string][]int
+}
+
+// protoMessage is a message and the functions mapping it to and from the
+// Go type it was made from.
+type protoMessage struct {
+	Name     string
+	GoType   string
+	PBType   string
+	ToFunc   string
+	FromFunc string
+	Fields   []protoField
+	Skipped  []string
+	// Reserved are the numbers of removed fields, never to be used again
+	Reserved []int
+}
+
+// protoField is a message field. ToProto and FromProto are the statements
+// copying it from src to dst in either direction.
+type protoField struct {
+	Label     string
+	Type      string
+	Name      string
+	Number    int
+	ToProto   string
+	FromProto string
+}
+
+// goImports collects the imports of a generated Go file.
+type goImports struct {
+	self  string
+	paths map[string]bool
+}
+
+func (g *goImports) qualifier(p *types.Package) string {
+	if p.Path() == g.self {
+		return ""
+	}
+	g.paths[p.Path()] = true
+	return p.Name()
+}
+
+// Imports renders the import block.
+func (g *goImports) Imports() string {
+	var paths []string
+	for p := range g.paths {
+		paths = append(paths, p)
+	}
+	return renderImports(paths)
+}
+
+var (
+	protoRPC      = regexp.MustCompile(`(?m)^\s*rpc (\w+)\(`)
+	protoMessages = regexp.MustCompile(`(?s)\nmessage (\w+) \{(.*?)\n\}`)
+	protoFields   = regexp.MustCompile(`(?m)^\s*(?:optional |repeated )?[\w.<>, ]+ (\w+) = (\d+);`)
+	protoReserved = regexp.MustCompile(`(?m)^\s*reserved ([\d, ]+);`)
+)
+
+// writtenProtos are the .proto files written by this run, so crud, which
+// adds an rpc at a time, tells how to generate their Go code once.
+var writtenProtos = map[string]bool{}
+
+// writeProtoService writes the .proto of the service of the handler
+// package into pbDir, with an rpc for every usecase it has and for the
+// usecase given, and the Go code mapping the usecase's types to and from
+// its messages. It returns the name of the Go package protoc generates.
+func writeProtoService(dir string, names Names, pbDir string, usecase *Names) string {
+	path := filepath.Join(pbDir, names.Package+".proto")
+	s := newProtoService(names, pbDir)
+	verb := "Created"
+	if src, err := os.ReadFile(path); err == nil {
+		s.parse(string(src))
+		verb = "Updated"
+	}
+	if usecase != nil && !containsString(s.RPCs, usecase.Pascal) {
+		s.RPCs = append(s.RPCs, usecase.Pascal)
+	}
+
+	imp := importer.ForCompiler(token.NewFileSet(), "source", nil)
+	mapper := &goImports{self: importPath(dir), paths: map[string]bool{importPath(pbDir): true}}
+	var mapped []*protoMessage
+	var rpcs []string
+	for _, rpc := range s.RPCs {
+		names := mustNames(rpc)
+		if _, err := os.Stat(filepath.Join(layerPath(boundedContext, "usecase"), names.Package)); err != nil {
+			fmt.Printf("Dropped rpc %s from %s: usecase %s not found\n", rpc, path, names.Pascal)
+			continue
+		}
+		rpcs = append(rpcs, rpc)
+		req, _ := loadUsecase(imp, names)
+		resp := loadMapperType(imp, req.Dir+".Response")
+
+		// Only the mapping of the usecase given is written
+		q, collect := &goImports{paths: map[string]bool{}}, new([]*protoMessage)
+		if usecase != nil && rpc == usecase.Pascal {
+			q, collect = mapper, &mapped
+		}
+		s.message(rpc+"Request", req.pkg.Scope().Lookup("Request").Type(), rpc, true, q, collect)
+		s.message(rpc+"Response", resp.pkg.Scope().Lookup("Response").Type(), rpc, true, q, collect)
+	}
+	s.RPCs = rpcs
+	sort.Strings(s.Imports)
+
+	if err := os.MkdirAll(pbDir, 0755); err != nil {
+		fmt.Printf("Error creating %s: %v\n", pbDir, err)
+		os.Exit(1)
+	}
+	generateFile(path, protoTemplate, s)
+	if !writtenProtos[path] {
+		writtenProtos[path] = true
+		fmt.Printf("%s %s; generate its Go code with:\n  protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative %s\n", verb, path, filepath.ToSlash(path))
+	}
+
+	if usecase != nil {
+		writeProtoMapper(filepath.Join(dir, usecase.Snake+"_grpc_mapper.go"), names.Package, path, mapper, mapped)
+		for _, m := range mapped {
+			for _, skipped := range m.Skipped {
+				fmt.Printf("Unmapped %s.%s\n", m.GoType, skipped)
+			}
+		}
+	}
+	return s.pb
+}
+
+func newProtoService(names Names, pbDir string) *protoService {
+	return &protoService{
+		Package:   names.Package,
+		GoPackage: importPath(pbDir),
+		Service:   names.Pascal + "Service",
+		pb:        filepath.Base(pbDir),
+		byType:    map[string]*protoMessage{},
+		numbers:   map[string]map[string]int{},
+		reserved:  map[string][]int{},
+	}
+}
+
+// parse reads the rpcs and the field numbers of the existing .proto src.
+func (s *protoService) parse(src string) {
+	for _, m := range protoRPC.FindAllStringSubmatch(src, -1) {
+		s.RPCs = append(s.RPCs, m[1])
+	}
+	for _, m := range protoMessages.FindAllStringSubmatch(src, -1) {
+		s.numbers[m[1]] = map[string]int{}
+		for _, f := range protoFields.FindAllStringSubmatch(m[2], -1) {
+			s.numbers[m[1]][f[1]], _ = strconv.Atoi(f[2])
+		}
+		for _, r := range protoReserved.FindAllStringSubmatch(m[2], -1) {
+			for _, n := range strings.Split(r[1], ",") {
+				if n, err := strconv.Atoi(strings.TrimSpace(n)); err == nil {
+					s.reserved[m[1]] = append(s.reserved[m[1]], n)
+				}
+			}
+		}
+	}
+}
+
+// writeProtoMapper writes the functions mapping the messages of the .proto
+// at proto to and from their Go types.
+func writeProtoMapper(path, pkg, proto string, imports *goImports, messages []*protoMessage) {
+	generateFile(path, protoMapperTemplate, map[string]interface{}{
+		"Package":  pkg,
+		"Proto":    filepath.ToSlash(proto),
+		"Imports":  imports.Imports(),
+		"Messages": messages,
+	})
+	removeUnusedImports(path)
+}
+
+// message returns the message made from the struct type t, adding it and
+// the messages of its struct fields to the service first if needed.
+// Exported messages get exported mapping functions.
+func (s *protoService) message(name string, t types.Type, usecase string, exported bool, q *goImports, collect *[]*protoMessage) *protoMessage {
+	key := types.TypeString(t, nil)
+	if m, ok := s.byType[key]; ok {
+		return m
+	}
+	fn := name
+	if !exported {
+		fn = strings.ToLower(name[:1]) + name[1:]
+	}
+	m := &protoMessage{
+		Name:     name,
+		GoType:   types.TypeString(t, q.qualifier),
+		PBType:   s.pb + "." + name,
+		ToFunc:   fn + "ToProto",
+		FromFunc: fn + "FromProto",
+	}
+	s.byType[key] = m
+	s.Messages = append(s.Messages, m)
+	*collect = append(*collect, m)
+
+	st := t.Underlying().(*types.Struct)
+	numbers := s.numbers[name]
+	next := 0
+	for _, n := range numbers {
+		next = max(next, n)
+	}
+	for _, n := range s.reserved[name] {
+		next = max(next, n)
+	}
+	for _, sf := range protoStructFields(st) {
+		v := sf.v
+		f := protoField{Name: toSnake(jsonName(v, sf.tag))}
+		if f.Name == "" {
+			f.Name = toSnake(v.Name())
+		}
+		if !s.field(&f, v, usecase, q, collect) {
+			m.Skipped = append(m.Skipped, fmt.Sprintf("%s: %s has no proto equivalent", v.Name(), types.TypeString(v.Type(), q.qualifier)))
+			continue
+		}
+		if n, ok := numbers[f.Name]; ok {
+			f.Number = n
+		} else {
+			next++
+			f.Number = next
+		}
+		m.Fields = append(m.Fields, f)
+	}
+
+	// Numbers of fields that are gone stay reserved
+	m.Reserved = append(m.Reserved, s.reserved[name]...)
+	for field, n := range numbers {
+		if !m.hasField(field) {
+			m.Reserved = append(m.Reserved, n)
+		}
+	}
+	sort.Ints(m.Reserved)
+	return m
+}
+
+func (m *protoMessage) hasField(name string) bool {
+	for _, f := range m.Fields {
+		if f.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+type structField struct {
+	v   *types.Var
+	tag string
+}
+
+// protoStructFields returns the exported fields of st. The fields of
+// embedded structs are included in their place, as encoding/json does, and
+// are mapped through Go's promoted fields. Fields tagged json:"-" are left
+// out, as they are of the JSON API.
+func protoStructFields(st *types.Struct) []structField {
+	var fields []structField
+	for i := 0; i < st.NumFields(); i++ {
+		v := st.Field(i)
+		if jsonName(v, st.Tag(i)) == "-" {
+			continue
+		}
+		if v.Anonymous() && jsonName(v, st.Tag(i)) == v.Name() {
+			if embedded, ok := v.Type().Underlying().(*types.Struct); ok {
+				if _, isPtr := v.Type().(*types.Pointer); !isPtr {
+					fields = append(fields, protoStructFields(embedded)...)
+					continue
+				}
+			}
+		}
+		if v.Exported() {
+			fields = append(fields, structField{v, st.Tag(i)})
+		}
+	}
+	return fields
+}
+
+// field works out the proto type of the Go field v and the statements
+// mapping it. It reports false for types proto has no equivalent of.
+func (s *protoService) field(f *protoField, v *types.Var, usecase string, q *goImports, collect *[]*protoMessage) bool {
+	g, p := v.Name(), goCamelCase(f.Name)
+	t := v.Type()
+	elem, isPtr := t, false
+	if ptr, ok := t.(*types.Pointer); ok {
+		elem, isPtr = ptr.Elem(), true
+	}
+
+	// Well-known types
+	if wk, ok := protoWellKnown(elem); ok {
+		f.Type = wk.proto
+		s.addImport(wk.file)
+		q.paths[wk.pkg] = true
+		if isPtr {
+			f.ToProto = fmt.Sprintf("if src.%s != nil {\ndst.%s = %s(*src.%s)\n}", g, p, wk.to, g)
+			f.FromProto = fmt.Sprintf("if src.%s != nil {\nv := src.%s.%s()\ndst.%s = &v\n}", p, p, wk.from, g)
+		} else {
+			f.ToProto = fmt.Sprintf("dst.%s = %s(src.%s)", p, wk.to, g)
+			f.FromProto = fmt.Sprintf("if src.%s != nil {\ndst.%s = src.%s.%s()\n}", p, g, p, wk.from)
+		}
+		return true
+	}
+
+	// Scalars, optional when pointers
+	if proto, goType, ok := protoScalar(elem); ok {
+		f.Type = proto
+		to, from := s.convert(elem, goType, q)
+		if !isPtr {
+			f.ToProto = fmt.Sprintf("dst.%s = %s", p, fmt.Sprintf(to, "src."+g))
+			f.FromProto = fmt.Sprintf("dst.%s = %s", g, fmt.Sprintf(from, "src."+p))
+			return true
+		}
+		if goType == "[]byte" {
+			return false
+		}
+		f.Label = "optional "
+		f.ToProto = fmt.Sprintf("if src.%s != nil {\nv := %s\ndst.%s = &v\n}", g, fmt.Sprintf(to, "*src."+g), p)
+		f.FromProto = fmt.Sprintf("if src.%s != nil {\nv := %s\ndst.%s = &v\n}", p, fmt.Sprintf(from, "*src."+p), g)
+		return true
+	}
+
+	// Structs become messages of their own
+	if m, ok := s.nested(elem, usecase, q, collect); ok {
+		f.Type = m.Name
+		if isPtr {
+			f.ToProto = fmt.Sprintf("dst.%s = %s(src.%s)", p, m.ToFunc, g)
+			f.FromProto = fmt.Sprintf("dst.%s = %s(src.%s)", g, m.FromFunc, p)
+		} else {
+			f.ToProto = fmt.Sprintf("dst.%s = %s(&src.%s)", p, m.ToFunc, g)
+			f.FromProto = fmt.Sprintf("if m := %s(src.%s); m != nil {\ndst.%s = *m\n}", m.FromFunc, p, g)
+		}
+		return true
+	}
+	if isPtr {
+		return false
+	}
+
+	switch u := t.Underlying().(type) {
+	case *types.Slice:
+		f.Label = "repeated "
+		el := u.Elem()
+		if proto, goType, ok := protoScalar(el); ok && goType != "[]byte" {
+			f.Type = proto
+			if types.Identical(t, types.NewSlice(types.Universe.Lookup(goType).Type())) {
+				f.ToProto = fmt.Sprintf("dst.%s = src.%s", p, g)
+				f.FromProto = fmt.Sprintf("dst.%s = src.%s", g, p)
+				return true
+			}
+			to, from := s.convert(el, goType, q)
+			f.ToProto = fmt.Sprintf("for _, v := range src.%s {\ndst.%s = append(dst.%s, %s)\n}", g, p, p, fmt.Sprintf(to, "v"))
+			f.FromProto = fmt.Sprintf("for _, v := range src.%s {\ndst.%s = append(dst.%s, %s)\n}", p, g, g, fmt.Sprintf(from, "v"))
+			return true
+		}
+		elPtr := false
+		if ptr, ok := el.(*types.Pointer); ok {
+			el, elPtr = ptr.Elem(), true
+		}
+		m, ok := s.nested(el, usecase, q, collect)
+		if !ok {
+			return false
+		}
+		f.Type = m.Name
+		if elPtr {
+			f.ToProto = fmt.Sprintf("for _, v := range src.%s {\ndst.%s = append(dst.%s, %s(v))\n}", g, p, p, m.ToFunc)
+			f.FromProto = fmt.Sprintf("for _, v := range src.%s {\ndst.%s = append(dst.%s, %s(v))\n}", p, g, g, m.FromFunc)
+		} else {
+			f.ToProto = fmt.Sprintf("for i := range src.%s {\ndst.%s = append(dst.%s, %s(&src.%s[i]))\n}", g, p, p, m.ToFunc, g)
+			f.FromProto = fmt.Sprintf("for _, v := range src.%s {\nif m := %s(v); m != nil {\ndst.%s = append(dst.%s, *m)\n}\n}", p, m.FromFunc, g, g)
+		}
+		return true
+	case *types.Map:
+		key, keyGo, ok := protoScalar(u.Key())
+		val, valGo, ok2 := protoScalar(u.Elem())
+		if !ok || !ok2 || key == "bytes" || key == "double" || key == "float" {
+			return false
+		}
+		f.Type = fmt.Sprintf("map<%s, %s>", key, val)
+		keyTo, keyFrom := s.convert(u.Key(), keyGo, q)
+		valTo, valFrom := s.convert(u.Elem(), valGo, q)
+		if keyTo == "%s" && valTo == "%s" && types.Identical(t, u) {
+			f.ToProto = fmt.Sprintf("dst.%s = src.%s", p, g)
+			f.FromProto = fmt.Sprintf("dst.%s = src.%s", g, p)
+			return true
+		}
+		f.ToProto = fmt.Sprintf("if src.%s != nil {\ndst.%s = make(map[%s]%s, len(src.%s))\nfor k, v := range src.%s {\ndst.%s[%s] = %s\n}\n}",
+			g, p, keyGo, valGo, g, g, p, fmt.Sprintf(keyTo, "k"), fmt.Sprintf(valTo, "v"))
+		f.FromProto = fmt.Sprintf("if src.%s != nil {\ndst.%s = make(%s, len(src.%s))\nfor k, v := range src.%s {\ndst.%s[%s] = %s\n}\n}",
+			p, g, types.TypeString(t, q.qualifier), p, p, g, fmt.Sprintf(keyFrom, "k"), fmt.Sprintf(valFrom, "v"))
+		return true
+	}
+	return false
+}
+
+// nested returns the message of the named struct type t, named after the
+// usecase and the type, e.g. CreateUserAddress.
+func (s *protoService) nested(t types.Type, usecase string, q *goImports, collect *[]*protoMessage) (*protoMessage, bool) {
+	named, ok := t.(*types.Named)
+	if !ok {
+		return nil, false
+	}
+	if _, ok := named.Underlying().(*types.Struct); !ok {
+		return nil, false
+	}
+	name := named.Obj().Name()
+	if !strings.HasPrefix(name, usecase) {
+		name = usecase + name
+	}
+	return s.message(name, t, usecase, false, q, collect), true
+}
+
+// convert returns the formats converting a value of the Go type t to the
+// Go type protoc uses for it and back.
+func (s *protoService) convert(t types.Type, goType string, q *goImports) (string, string) {
+	if types.TypeString(t, nil) == goType {
+		return "%s", "%s"
+	}
+	return goType + "(%s)", types.TypeString(t, q.qualifier) + "(%s)"
+}
+
+func (s *protoService) addImport(file string) {
+	if !containsString(s.Imports, file) {
+		s.Imports = append(s.Imports, file)
+	}
+}
+
+// protoScalar returns the proto scalar type of t and the Go type protoc
+// generates for it.
+func protoScalar(t types.Type) (string, string, bool) {
+	if _, ok := protoWellKnown(t); ok {
+		return "", "", false
+	}
+	if slice, ok := t.Underlying().(*types.Slice); ok {
+		if b, ok := slice.Elem().Underlying().(*types.Basic); ok && b.Kind() == types.Byte {
+			return "bytes", "[]byte", true
+		}
+		return "", "", false
+	}
+	basic, ok := t.Underlying().(*types.Basic)
+	if !ok {
+		return "", "", false
+	}
+	switch basic.Kind() {
+	case types.String:
+		return "string", "string", true
+	case types.Bool:
+		return "bool", "bool", true
+	case types.Int, types.Int64:
+		return "int64", "int64", true
+	case types.Int8, types.Int16, types.Int32:
+		return "int32", "int32", true
+	case types.Uint, types.Uint64:
+		return "uint64", "uint64", true
+	case types.Uint8, types.Uint16, types.Uint32:
+		return "uint32", "uint32", true
+	case types.Float32:
+		return "float", "float32", true
+	case types.Float64:
+		return "double", "float64", true
+	}
+	return "", "", false
+}
+
+// wellKnown is a Go type with a well-known proto type: the .proto it is
+// declared in, the package of its Go type and the functions converting
+// to and from it.
+type wellKnown struct {
+	proto, file, pkg, to, from string
+}
+
+func protoWellKnown(t types.Type) (wellKnown, bool) {
+	named, ok := t.(*types.Named)
+	if !ok || named.Obj().Pkg() == nil || named.Obj().Pkg().Path() != "time" {
+		return wellKnown{}, false
+	}
+	switch named.Obj().Name() {
+	case "Time":
+		return wellKnown{"google.protobuf.Timestamp", "google/protobuf/timestamp.proto",
+			"google.golang.org/protobuf/types/known/timestamppb", "timestamppb.New", "AsTime"}, true
+	case "Duration":
+		return wellKnown{"google.protobuf.Duration", "google/protobuf/duration.proto",
+			"google.golang.org/protobuf/types/known/durationpb", "durationpb.New", "AsDuration"}, true
+	}
+	return wellKnown{}, false
+}
+
+// goCamelCase returns the Go name protoc-gen-go gives the proto field name.
+func goCamelCase(s string) string {
+	var b []byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '_' && i == 0:
+			b = append(b, 'X')
+		case c == '_' && i+1 < len(s) && 'a' <= s[i+1] && s[i+1] <= 'z':
+		case '0' <= c && c <= '9':
+			b = append(b, c)
+		default:
+			if 'a' <= c && c <= 'z' {
+				c -= 'a' - 'A'
+			}
+			b = append(b, c)
+			for ; i+1 < len(s) && 'a' <= s[i+1] && s[i+1] <= 'z'; i++ {
+				b = append(b, s[i+1])
+			}
+		}
+	}
+	return string(b)
+}
+
+const protoTemplate = `// Code generated by go-ddd-skel from the usecase types; DO NOT EDIT.
+// Change the usecases and run the handler command again instead. Field
+// numbers are kept when the file is generated again.
+
+syntax = "proto3";
+
+package {{.Package}};
+{{if .Imports}}
+{{range .Imports}}import "{{.}}";
+{{end}}{{end}}
+option go_package = "{{.GoPackage}}";
+
+service {{.Service}} {
+{{- range .RPCs}}
+  rpc {{.}}({{.}}Request) returns ({{.}}Response);
+{{- end}}
+}
+{{range .Messages}}
+message {{.Name}} {
+{{- range .Skipped}}
+  // Not mapped: {{.}}
+{{- end}}
+{{- if .Reserved}}
+  reserved {{range $i, $n := .Reserved}}{{if $i}}, {{end}}{{$n}}{{end}};
+{{- end}}
+{{- range .Fields}}
+  {{.Label}}{{.Type}} {{.Name}} = {{.Number}};
+{{- end}}
+}
+{{end}}`
+
+const protoMapperTemplate = `// Code generated by go-ddd-skel from {{.Proto}}; DO NOT EDIT.
+
+package {{.Package}}
+
+{{.Imports}}
+{{- range .Messages}}
+// {{.ToFunc}} copies a {{.GoType}} into a new {{.PBType}}.
+{{- if .Skipped}}
+//
+// Left unmapped:
+{{- range .Skipped}}
+//   - {{.}}
+{{- end}}
+{{- end}}
+func {{.ToFunc}}(src *{{.GoType}}) *{{.PBType}} {
+	if src == nil {
+		return nil
+	}
+	dst := &{{.PBType}}{}
+{{- range .Fields}}
+	{{.ToProto}}
+{{- end}}
+	return dst
+}
+
+// {{.FromFunc}} copies a {{.PBType}} into a new {{.GoType}}.
+func {{.FromFunc}}(src *{{.PBType}}) *{{.GoType}} {
+	if src == nil {
+		return nil
+	}
+	dst := &{{.GoType}}{}
+{{- range .Fields}}
+	{{.FromProto}}
+{{- end}}
+	return dst
+}
+{{end}}`
@@ -0,0 +1,172 @@
+package cmd
+
+import (
+	"flag"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// protoUsecase is the usecase the .proto renderer is tested with.
+const protoUsecase = `package createuser
+
+import "time"
+
+type Status string
+
+type Audit struct {
+	CreatedBy string ` + "`json:\"created_by\"`" + `
+}
+
+type Address struct {
+	Street string  ` + "`json:\"street\"`" + `
+	Zip    *string ` + "`json:\"zip,omitempty\"`" + `
+}
+
+type Request struct {
+	Audit
+	Name      string ` + "`json:\"name\"`" + `
+	Age       int
+	Score     float64
+	Active    bool
+	Status    Status
+	Nickname  *string ` + "`json:\"nickname,omitempty\"`" + `
+	Limit     *int32
+	Avatar    []byte
+	Tags      []string
+	Levels    []Status
+	Labels    map[string]string
+	Counts    map[Status]int
+	BirthDate time.Time
+	ExpiresAt *time.Time
+	Timeout   time.Duration
+	Address   Address
+	Previous  []Address
+	Billing   *Address
+	Weird     chan int ` + "`json:\"-\"`" + `
+	Callback  func()
+	internal  string
+}
+
+type Response struct {
+	ID string ` + "`json:\"id\"`" + `
+}
+`
+
+// renderProto renders the .proto and mapper of the CreateUser rpc of the
+// user service, generated again over existing when it is not empty.
+func renderProto(t *testing.T, existing string) (string, string) {
+	t.Helper()
+	dir := inModule(t, "app")
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "createuser.go", protoUsecase, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := types.Config{Importer: importer.Default()}
+	pkg, err := conf.Check("app/internal/usecase/createuser", fset, []*ast.File{file}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := newProtoService(mustNames("user"), filepath.Join("internal", "handler", "grpc", "user", "userpb"))
+	s.parse(existing)
+	if !containsString(s.RPCs, "CreateUser") {
+		s.RPCs = append(s.RPCs, "CreateUser")
+	}
+	q := &goImports{self: "app/internal/handler/grpc/user", paths: map[string]bool{s.GoPackage: true}}
+	var mapped []*protoMessage
+	s.message("CreateUserRequest", pkg.Scope().Lookup("Request").Type(), "CreateUser", true, q, &mapped)
+	s.message("CreateUserResponse", pkg.Scope().Lookup("Response").Type(), "CreateUser", true, q, &mapped)
+	sort.Strings(s.Imports)
+
+	protoPath := filepath.Join(dir, "user.proto")
+	mapperPath := filepath.Join(dir, "create_user_grpc_mapper.go")
+	generateFile(protoPath, protoTemplate, s)
+	writeProtoMapper(mapperPath, "user", "internal/handler/grpc/user/userpb/user.proto", q, mapped)
+
+	proto, err := os.ReadFile(protoPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mapper, err := os.ReadFile(mapperPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(proto), string(mapper)
+}
+
+// golden compares got with testdata/name, rewriting it with -update.
+func golden(t *testing.T, wd, name, got string) {
+	t.Helper()
+	path := filepath.Join(wd, "testdata", name)
+	if *update {
+		if err := os.WriteFile(path, []byte(got), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != string(want) {
+		t.Errorf("%s differs:\n%s\nwant\n%s", name, got, want)
+	}
+}
+
+func TestProtoService(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	proto, mapper := renderProto(t, "")
+	golden(t, wd, "user.proto.golden", proto)
+	golden(t, wd, "create_user_grpc_mapper.go.golden", mapper)
+}
+
+func TestProtoServiceKeepsFieldNumbers(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Name was renumbered by hand and removed was dropped from the usecase,
+	// so new fields are numbered after it and its number is reserved, as
+	// are those reserved already
+	existing := `syntax = "proto3";
+
+package user;
+
+service UserService {
+  rpc CreateUser(CreateUserRequest) returns (CreateUserResponse);
+}
+
+message CreateUserRequest {
+  string created_by = 1;
+  string name = 12;
+  int64 age = 3;
+  optional string nickname = 7;
+  map<string, string> labels = 14;
+  string removed = 30;
+}
+
+message CreateUserAddress {
+  optional string zip = 1;
+  string street = 2;
+}
+
+message CreateUserResponse {
+  reserved 1, 2;
+}
+`
+	proto, _ := renderProto(t, existing)
+	golden(t, wd, "user_regenerated.proto.golden", proto)
+}
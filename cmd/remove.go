@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var removeUsecase string
+
+var removeCmd = &cobra.Command{
+	Use:   "remove",
+	Short: "Remove generated code",
+	Long:  `Removes generated code along with the wiring that refers to it.`,
+}
+
+var removeHandlerCmd = &cobra.Command{
+	Use:   "handler [name]",
+	Short: "Remove a handler and its registrations",
+	Long: `Deletes the handler package, and the package of its gRPC handlers in
+internal/interfaces/grpc, and takes their registrations out of
+internal/interfaces/http/routes.go, internal/interfaces/grpc/services.go and
+internal/interfaces/messaging/consumers.go. An admin command of that name is
+deleted from cmd/admin and cmd/admin/commands.go too.
+With --usecase only the handlers of that usecase are removed.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		removeHandler(mustNames(args[0]), removeUsecase)
+	},
+}
+
+func removeHandler(names Names, usecase string) {
+	// The gRPC handlers of a service have a package of their own
+	var dirs []string
+	for _, dir := range []string{filepath.Join(layerPath(boundedContext, "interfaces"), names.Package), grpcHandlerPath(names)} {
+		if _, err := os.Stat(dir); err == nil {
+			dirs = append(dirs, dir)
+		}
+	}
+	command := usecase == "" && removeCommand(names)
+	if len(dirs) == 0 {
+		if command {
+			return
+		}
+		fmt.Printf("No handler %s in %s\n", names.Pascal, filepath.Join(layerPath(boundedContext, "interfaces"), names.Package))
+		os.Exit(1)
+	}
+	if usecase != "" {
+		usecase = mustNames(usecase).Pascal
+	}
+	for _, dir := range dirs {
+		unregister(routesPath(), "RegisterRoutes", importPath(dir), usecase)
+		unregister(servicesPath(), "RegisterServices", importPath(dir), usecase)
+		unregister(consumersPath(), "RegisterConsumers", importPath(dir), usecase)
+	}
+
+	if usecase == "" {
+		for _, dir := range dirs {
+			if err := os.RemoveAll(dir); err != nil {
+				fmt.Printf("Error removing %s: %v\n", dir, err)
+				os.Exit(1)
+			}
+		}
+		fmt.Printf("Successfully removed handler %s\n", names.Pascal)
+		return
+	}
+
+	snake := mustNames(usecase).Snake
+	removed := false
+	for _, dir := range dirs {
+		for _, suffix := range []string{"_http.go", "_http_test.go", "_grpc.go", "_grpc_mapper.go"} {
+			path := filepath.Join(dir, snake+suffix)
+			if err := os.Remove(path); err == nil {
+				removed = true
+			} else if !os.IsNotExist(err) {
+				fmt.Printf("Error removing %s: %v\n", path, err)
+				os.Exit(1)
+			}
+		}
+		serverPath := filepath.Join(dir, "grpc_server.go")
+		if _, err := os.Stat(serverPath); err == nil {
+			removeStructField(serverPath, names.Pascal+"GRPCServer", "*"+usecase+"GRPCHandler")
+		}
+		// The routing openapi.go has for the handler goes with it; the types
+		// of its operation go once openapi.go is generated again
+		openapiPath := filepath.Join(dir, "openapi.go")
+		if _, err := os.Stat(openapiPath); err == nil && removeMethods(openapiPath, usecase+"HTTPHandler") > 0 {
+			removeUnusedImports(openapiPath)
+		}
+	}
+	if !removed {
+		fmt.Printf("No handler for %s in %s\n", usecase, strings.Join(dirs, " or "))
+		os.Exit(1)
+	}
+	fmt.Printf("Successfully removed the %s handlers of %s\n", usecase, names.Pascal)
+}
+
+func InitRemove(rootCmd *cobra.Command) {
+	removeHandlerCmd.Flags().StringVar(&removeUsecase, "usecase", "", "Remove only the handlers of this usecase")
+	addContextFlag(removeHandlerCmd)
+	removeCmd.AddCommand(removeHandlerCmd)
+	rootCmd.AddCommand(removeCmd)
+}
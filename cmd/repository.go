@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	repositoryAdapter string
+)
+
+var repositoryCmd = &cobra.Command{
+	Use:   "repository [domain]",
+	Short: "Generate a repository adapter for a domain",
+	Long: `Creates a persistence adapter implementing a domain's repository interface.
+The entity and interface are read from the domain's source, so fields added
+by hand are included.
+
+Adapters:
+- sql: database/sql with hand-written queries
+- sqlc: sql/queries/<name>.sql and sqlc.yaml, the code sqlc generates from
+  them, and an adapter mapping between the sqlc structs and the entity. The
+  generated code compiles without the sqlc binary; run sqlc generate after
+  editing the queries.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if repositoryAdapter != "sql" && repositoryAdapter != "sqlc" {
+			fmt.Println("Unsupported adapter. Use --adapter [sql|sqlc]")
+			os.Exit(1)
+		}
+		createRepositoryAdapter(mustNames(args[0]), repositoryAdapter)
+	},
+}
+
+func createRepositoryAdapter(names Names, adapter string) {
+	spec, pkg := loadDomainSpec(names)
+
+	methods, err := pkg.interfaceMethods(spec.Repository())
+	if err != nil {
+		fmt.Printf("Error reading repository interface: %v\n", err)
+		os.Exit(1)
+	}
+	// The generators below read the repository style of the interface
+	repoStyle = "simple"
+	for _, m := range methods {
+		if m.Name == "List" {
+			repoStyle = "context"
+		}
+	}
+
+	// Fields without a column representation are left to the developer
+	id, ok := newSQLCColumn(pkg, "ID", spec.IDColumnName(), spec.IDType)
+	if !ok {
+		fmt.Printf("Unsupported ID type %s\n", spec.IDType)
+		os.Exit(1)
+	}
+	columns := []sqlcColumn{id}
+	var fields []domainField
+	for _, f := range spec.Fields {
+		col, ok := newSQLCColumn(pkg, f.Name, f.Column, f.Type)
+		if !ok {
+			fmt.Printf("Warning: %s.%s of type %s has no column mapping and is not persisted\n", names.Pascal, f.Name, f.Type)
+			continue
+		}
+		columns = append(columns, col)
+		fields = append(fields, f)
+	}
+	spec.Fields = fields
+
+	if !pkg.declares("ErrNotFound") {
+		if _, err := os.Stat(filepath.Join(pkg.Dir, "errors.go")); err == nil {
+			fmt.Printf("The adapter returns %s.ErrNotFound, but it is not declared in %s\n", names.Package, pkg.Dir)
+			os.Exit(1)
+		}
+		generateDomainErrors(pkg.Dir, spec)
+	}
+
+	switch adapter {
+	case "sqlc":
+		generateSQLCRepository(spec, columns)
+	default:
+		generateSQLRepository(spec)
+	}
+
+	fmt.Printf("Successfully created %s repository adapter for %s\n", adapter, names.Pascal)
+}
+
+func InitGenRepository(rootCmd *cobra.Command) {
+	rootCmd.AddCommand(repositoryCmd)
+	repositoryCmd.Flags().StringVar(&repositoryAdapter, "adapter", "sql", "Persistence adapter (sql|sqlc)")
+	addContextFlag(repositoryCmd)
+}
@@ -0,0 +1,320 @@
+package cmd
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+	"os"
+	"path/filepath"
+)
+
+// routesPath is the file registering the routes of every HTTP handler.
+func routesPath() string {
+	return filepath.Join("internal", "interfaces", "http", "routes.go")
+}
+
+// servicesPath is the file registering every gRPC service.
+func servicesPath() string {
+	return filepath.Join("internal", "interfaces", "grpc", "services.go")
+}
+
+// consumersPath is the file registering every message consumer.
+func consumersPath() string {
+	return filepath.Join("internal", "interfaces", "messaging", "consumers.go")
+}
+
+// registration wires a handler into routes.go, services.go, consumers.go
+// or the commands of cmd/admin.
+type registration struct {
+	Imports []string
+	// Field is the field of Usecases holding the usecase the handler
+	// serves, of type Type
+	Field string
+	Type  string
+	// NewService constructs the usecase of Field in bootstrap.New
+	NewService *types.Func
+	Stmts      []string
+	// Before is the statement the others are inserted ahead of once the
+	// function has it
+	Before string
+}
+
+// registerRoutes adds reg to RegisterRoutes, creating routes.go for the
+// project's router first if needed. Registering again changes nothing.
+func registerRoutes(reg registration) {
+	path := routesPath()
+	if _, err := os.Stat(path); err != nil {
+		writeIfMissing(path, routesTemplate, projectRouter())
+		removeUnusedImports(path)
+		bootstrapField("App", "HTTP", "httpapi.Usecases", importPath(filepath.Dir(path)))
+		fmt.Printf("Created %s; serve the routes by calling its RegisterRoutes from main with app.HTTP\n", path)
+	}
+	register(path, "RegisterRoutes", reg)
+}
+
+// registerService adds reg to RegisterServices, creating services.go and
+// cmd/grpc, which serves them, first if needed. Registering again changes
+// nothing.
+func registerService(reg registration) {
+	path := servicesPath()
+	if _, err := os.Stat(path); err != nil {
+		writeIfMissing(path, servicesTemplate, nil)
+		fmt.Printf("Created %s; cmd/grpc serves the services it registers\n", path)
+	}
+	ensureGRPCEntrypoint()
+	register(path, "RegisterServices", reg)
+}
+
+// registerConsumer adds reg to RegisterConsumers, creating consumers.go
+// first if needed. Registering again changes nothing.
+func registerConsumer(reg registration) {
+	path := consumersPath()
+	if _, err := os.Stat(path); err != nil {
+		writeIfMissing(path, consumersTemplate, importPath(filepath.Join(messagingPath(), "consumer")))
+		fmt.Printf("Created %s; cmd/consumer runs the consumers it registers\n", path)
+	}
+	register(path, "RegisterConsumers", reg)
+}
+
+func register(path, fn string, reg registration) {
+	for _, imp := range reg.Imports {
+		addImport(path, imp)
+	}
+	if reg.Field != "" {
+		addStructField(path, "Usecases", reg.Field, reg.Type)
+	}
+	for _, stmt := range reg.Stmts {
+		addStatement(path, fn, stmt, reg.Before)
+	}
+	fmt.Printf("Registered in %s\n", path)
+	if reg.Field != "" {
+		wireUsecase(appFields[path], reg.Field, reg.NewService)
+	}
+}
+
+// replaceRegistration removes the statements of the function fn in path
+// constructing a handler with ctor, e.g. NewOrderPlacedConsumer, that reg
+// does not have, so a handler generated again for another usecase stays
+// registered once.
+func replaceRegistration(path, fn, ctor string, reg registration) {
+	if _, err := os.Stat(path); err != nil {
+		return
+	}
+	keep := map[string]bool{}
+	for _, stmt := range reg.Stmts {
+		keep[sameCode([]byte(stmt))] = true
+	}
+	removed := removeStatements(path, fn, func(s ast.Stmt, code string) bool {
+		return refersTo(s, ctor) && !keep[code]
+	})
+	if removed > 0 {
+		removeUnusedImports(path)
+		fmt.Printf("Replaced the registration of %s in %s\n", ctor, path)
+	}
+}
+
+// appFields are the fields of App in bootstrap.go holding the Usecases of
+// each registration file.
+var appFields = map[string]string{
+	routesPath():    "HTTP",
+	servicesPath():  "GRPC",
+	consumersPath(): "Consumers",
+}
+
+// grpcMainPath is the entrypoint serving the services of services.go.
+func grpcMainPath() string {
+	return filepath.Join("cmd", "grpc", "main.go")
+}
+
+// ensureGRPCEntrypoint writes cmd/grpc unless it exists and gives App in
+// bootstrap.go the Usecases of the services.
+func ensureGRPCEntrypoint() {
+	writeIfMissing(grpcMainPath(), grpcMainTemplate, map[string]string{
+		"Bootstrap": importPath(filepath.Dir(bootstrapPath())),
+		"Services":  importPath(filepath.Dir(servicesPath())),
+	})
+	bootstrapField("App", "GRPC", "grpcapi.Usecases", importPath(filepath.Dir(servicesPath())))
+}
+
+// usecaseRegistration registers the handler constructed by ctor, e.g.
+// user.NewCreateUserHTTPHandler, for the usecase in pkg with the statement
+// format. A usecase whose NewService needs no dependencies is constructed
+// in place; the others are taken from Usecases, where bootstrap.New sets
+// them, and handlers given nil answer that they are not implemented.
+func usecaseRegistration(pkg *types.Package, usecase Names, handlerImport, ctor, format string) registration {
+	reg := registration{Imports: []string{handlerImport, pkg.Path()}}
+	fn, ok := pkg.Scope().Lookup("NewService").(*types.Func)
+	if ok {
+		sig := fn.Type().(*types.Signature)
+		if sig.Params().Len() == 0 || sig.Params().Len() == 1 && sig.Variadic() {
+			reg.Stmts = []string{fmt.Sprintf(format, ctor+"("+pkg.Name()+".NewService())")}
+			return reg
+		}
+	}
+	reg.Field = usecase.Pascal
+	reg.Type = pkg.Name() + "." + usecase.Pascal + "Service"
+	reg.NewService = fn
+	reg.Stmts = []string{fmt.Sprintf(format, ctor+"(usecases."+usecase.Pascal+")")}
+	return reg
+}
+
+// unregister removes the registrations of the handler package imported
+// as handlerImport from the function fn in path, or only those of the
+// usecase when one is given, along with the Usecases fields and imports
+// nothing refers to any more. It reports whether there were any.
+func unregister(path, fn, handlerImport, usecase string) bool {
+	if _, err := os.Stat(path); err != nil {
+		return false
+	}
+	_, _, file := parseGoFile(path)
+	local := ""
+	for _, imp := range file.Imports {
+		if imp.Path.Value == fmt.Sprintf("%q", handlerImport) {
+			local = importName(imp)
+		}
+	}
+	if local == "" {
+		return false
+	}
+
+	removed := removeStatements(path, fn, func(s ast.Stmt, _ string) bool {
+		if usecase != "" {
+			return refersTo(s, "New"+usecase+"HTTPHandler") || refersTo(s, "New"+usecase+"GRPCHandler")
+		}
+		return refersTo(s, local) || refersTo(s, local+"Server")
+	})
+
+	_, _, file = parseGoFile(path)
+	var unused []string
+	for _, f := range findStruct(file, path, "Usecases").Fields.List {
+		for _, name := range f.Names {
+			used := false
+			ast.Inspect(file, func(n ast.Node) bool {
+				if sel, ok := n.(*ast.SelectorExpr); ok && sel.Sel.Name == name.Name {
+					if x, ok := sel.X.(*ast.Ident); ok && x.Name == "usecases" {
+						used = true
+					}
+				}
+				return !used
+			})
+			if !used {
+				unused = append(unused, name.Name)
+			}
+		}
+	}
+	for _, name := range unused {
+		removeStructField(path, "Usecases", name)
+		unwireUsecase(appFields[path], name)
+	}
+	removeUnusedImports(path)
+	if removed > 0 {
+		fmt.Printf("Removed %d registrations from %s\n", removed, path)
+	}
+	return removed > 0
+}
+
+const routesTemplate = `package http
+
+import (
+{{- range .Imports}}
+	"{{.}}"
+{{- end}}
+)
+
+// Usecases are the usecases the HTTP handlers serve, set by bootstrap.New.
+// Handlers of usecases left nil answer 501 Not Implemented.
+type Usecases struct {
+}
+
+// RegisterRoutes registers the routes of every handler with router. The
+// handler command adds new handlers here.
+func RegisterRoutes(router {{.Type}}, usecases Usecases) {
+}
+`
+
+const servicesTemplate = `package grpc
+
+import (
+	"google.golang.org/grpc"
+)
+
+// Usecases are the usecases the gRPC services serve, set by bootstrap.New.
+// Rpcs of usecases left nil answer Unimplemented.
+type Usecases struct {
+}
+
+// RegisterServices registers every gRPC service with server. The handler
+// command adds new services here.
+func RegisterServices(server *grpc.Server, usecases Usecases) {
+}
+`
+
+const consumersTemplate = `package messaging
+
+import (
+	"{{.}}"
+)
+
+// Usecases are the usecases the message consumers serve, set by
+// bootstrap.New. Consumers of usecases left nil are not run, so their
+// messages wait in the broker.
+type Usecases struct {
+}
+
+// RegisterConsumers registers every message consumer with runner. The
+// handler command adds new consumers here.
+func RegisterConsumers(runner *consumer.Runner, usecases Usecases) {
+}
+`
+
+const grpcMainTemplate = `// Command grpc serves the gRPC services registered in
+// internal/interfaces/grpc, with the usecases of internal/bootstrap.
+// GRPC_ADDR is the address it listens on, :9090 by default.
+package main
+
+import (
+	"context"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"google.golang.org/grpc"
+
+	"{{.Bootstrap}}"
+	grpcapi "{{.Services}}"
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	app, err := bootstrap.New(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer app.Close()
+
+	addr := os.Getenv("GRPC_ADDR")
+	if addr == "" {
+		addr = ":9090"
+	}
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	server := grpc.NewServer()
+	grpcapi.RegisterServices(server, app.GRPC)
+	go func() {
+		<-ctx.Done()
+		server.GracefulStop()
+	}()
+
+	log.Printf("Serving gRPC on %s", addr)
+	if err := server.Serve(lis); err != nil {
+		log.Fatal(err)
+	}
+}
+`
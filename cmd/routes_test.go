@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReplaceRegistration(t *testing.T) {
+	const src = `package messaging
+
+import (
+	"app/internal/adapters/messaging/consumer"
+	"app/internal/interfaces/orderplaced"
+	"app/internal/interfaces/usercreated"
+	"app/internal/usecase/handleorderplaced"
+	"app/internal/usecase/welcomeuser"
+)
+
+func RegisterConsumers(runner *consumer.Runner, usecases Usecases) {
+	runner.Register(orderplaced.OrderPlacedTopic, orderplaced.NewOrderPlacedConsumer(handleorderplaced.NewService()))
+	runner.Register(usercreated.UserCreatedTopic, usercreated.NewUserCreatedConsumer(welcomeuser.NewService()))
+}
+`
+	tests := []struct {
+		name string
+		stmt string
+		want string
+	}{
+		{
+			name: "another usecase",
+			stmt: "if usecases.ShipOrder != nil {\nrunner.Register(orderplaced.OrderPlacedTopic, orderplaced.NewOrderPlacedConsumer(usecases.ShipOrder))\n}",
+			want: `package messaging
+
+import (
+	"app/internal/adapters/messaging/consumer"
+	"app/internal/interfaces/usercreated"
+	"app/internal/usecase/welcomeuser"
+)
+
+func RegisterConsumers(runner *consumer.Runner, usecases Usecases) {
+	runner.Register(usercreated.UserCreatedTopic, usercreated.NewUserCreatedConsumer(welcomeuser.NewService()))
+}
+`,
+		},
+		{
+			name: "same usecase",
+			stmt: "runner.Register(orderplaced.OrderPlacedTopic,\n\torderplaced.NewOrderPlacedConsumer(handleorderplaced.NewService()))",
+			want: src,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := inModule(t, "app")
+			path := filepath.Join(dir, "consumers.go")
+			if err := os.WriteFile(path, []byte(src), 0644); err != nil {
+				t.Fatal(err)
+			}
+			replaceRegistration(path, "RegisterConsumers", "NewOrderPlacedConsumer", registration{Stmts: []string{tt.stmt}})
+			got, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("replaceRegistration() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,837 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// sqlcPrimitive is how sqlc represents a Go type with database/sql: the
+// column type, and the sql.Null type, its field and value type when nullable.
+type sqlcPrimitive struct {
+	Storage   string
+	Null      string
+	NullField string
+	NullValue string
+}
+
+var sqlcPrimitives = map[string]sqlcPrimitive{
+	"string":          {"string", "sql.NullString", "String", "string"},
+	"int":             {"int64", "sql.NullInt64", "Int64", "int64"},
+	"int64":           {"int64", "sql.NullInt64", "Int64", "int64"},
+	"int32":           {"int32", "sql.NullInt32", "Int32", "int32"},
+	"int16":           {"int16", "sql.NullInt16", "Int16", "int16"},
+	"bool":            {"bool", "sql.NullBool", "Bool", "bool"},
+	"float64":         {"float64", "sql.NullFloat64", "Float64", "float64"},
+	"float32":         {"float32", "sql.NullFloat64", "Float64", "float64"},
+	"time.Time":       {"time.Time", "sql.NullTime", "Time", "time.Time"},
+	"time.Duration":   {"int64", "sql.NullInt64", "Int64", "int64"},
+	"[]byte":          {"[]byte", "", "", ""},
+	"json.RawMessage": {"json.RawMessage", "", "", ""},
+}
+
+// sqlcColumn is a column of the sqlc row struct. The Row and Entity fragments
+// are the adapter code converting between the entity field and the column:
+// Init parts go into a composite literal, Fixup parts handle NULLs after it.
+type sqlcColumn struct {
+	Column string
+	Field  string
+	Type   string
+
+	RowInit     string
+	RowFixup    string
+	EntityInit  string
+	EntityFixup string
+}
+
+// newSQLCColumn maps an entity field to a column. It reports false for types
+// that have no plain column representation, such as nested structs.
+func newSQLCColumn(pkg *sourcePackage, name, column, goType string) (sqlcColumn, bool) {
+	nullable := strings.HasPrefix(goType, "*")
+	base := strings.TrimPrefix(goType, "*")
+	prim, qualified := base, base
+	if pkg.declares(base) {
+		prim = pkg.underlying(base)
+		qualified = "domain." + base
+	}
+
+	p, ok := sqlcPrimitives[prim]
+	if !ok || (nullable && p.Null == "") {
+		return sqlcColumn{}, false
+	}
+	conv := func(expr, from, to string) string {
+		if from == to {
+			return expr
+		}
+		return to + "(" + expr + ")"
+	}
+
+	col := sqlcColumn{Column: column, Field: sqlcFieldName(column)}
+	if !nullable {
+		col.Type = p.Storage
+		col.RowInit = fmt.Sprintf("%s: %s,", col.Field, conv("e."+name, base, p.Storage))
+		col.EntityInit = fmt.Sprintf("%s: %s,", name, conv("row."+col.Field, p.Storage, qualified))
+		return col, true
+	}
+
+	col.Type = p.Null
+	col.RowFixup = fmt.Sprintf("if e.%s != nil {\nrow.%s = %s{%s: %s, Valid: true}\n}",
+		name, col.Field, p.Null, p.NullField, conv("*e."+name, base, p.NullValue))
+	col.EntityFixup = fmt.Sprintf("if row.%s.Valid {\nv := %s\ne.%s = &v\n}",
+		col.Field, conv("row."+col.Field+"."+p.NullField, p.NullValue, qualified), name)
+	return col, true
+}
+
+// sqlcFieldName names a struct field after a column the way sqlc does.
+func sqlcFieldName(column string) string {
+	var b strings.Builder
+	for _, part := range strings.Split(column, "_") {
+		if part == "id" {
+			b.WriteString("ID")
+		} else {
+			b.WriteString(capitalize(part))
+		}
+	}
+	return b.String()
+}
+
+// sqlcQuery is one annotated query and the Go method sqlc generates for it.
+type sqlcQuery struct {
+	Name   string
+	Kind   string
+	SQL    string
+	Params []sqlcColumn
+}
+
+func (q sqlcQuery) Const() string { return toCamel(q.Name) }
+
+// ParamsStruct reports whether sqlc passes the parameters as an XParams struct.
+func (q sqlcQuery) ParamsStruct() bool { return len(q.Params) > 1 }
+
+func (q sqlcQuery) Signature() string {
+	switch len(q.Params) {
+	case 0:
+		return "ctx context.Context"
+	case 1:
+		return fmt.Sprintf("ctx context.Context, %s %s", toCamel(q.Params[0].Column), q.Params[0].Type)
+	default:
+		return fmt.Sprintf("ctx context.Context, arg %sParams", q.Name)
+	}
+}
+
+func (q sqlcQuery) Args() string {
+	var args []string
+	for _, p := range q.Params {
+		if q.ParamsStruct() {
+			args = append(args, "arg."+p.Field)
+		} else {
+			args = append(args, toCamel(p.Column))
+		}
+	}
+	if len(args) == 0 {
+		return ""
+	}
+	return ", " + strings.Join(args, ", ")
+}
+
+type sqlcData struct {
+	Spec        domainSpec
+	Package     string
+	Model       string
+	ID          sqlcColumn
+	Columns     []sqlcColumn
+	Queries     []sqlcQuery
+	Get         sqlcQuery
+	List        sqlcQuery
+	Create      sqlcQuery
+	Upsert      *sqlcQuery
+	Update      *sqlcQuery
+	Delete      sqlcQuery
+	CoreImport  string
+	DBImport    string
+	TxImport    string
+	QueriesFile string
+	ContextRepo bool
+}
+
+// IDArg converts the domain id parameter to the column type.
+func (d sqlcData) IDArg() string {
+	if d.Spec.IDType == d.ID.Type {
+		return "id"
+	}
+	return d.ID.Type + "(id)"
+}
+
+// RowArg passes a row struct named row to an insert query, whose parameters
+// are all columns in order.
+func (d sqlcData) RowArg(q sqlcQuery) string {
+	if !q.ParamsStruct() {
+		return "row." + q.Params[0].Field
+	}
+	return fmt.Sprintf("%s.%sParams(row)", d.Package, q.Name)
+}
+
+// newSQLCData builds the queries for spec. Versioned and audited entities get
+// the same compare-and-swap and soft delete semantics as the sql adapter.
+func newSQLCData(spec domainSpec, columns []sqlcColumn, queriesFile, dbDir string) sqlcData {
+	database := loadProject().Database
+	ph := func(n int) string { return sqlPlaceholder(database, n) }
+	table := spec.TableName()
+	id := columns[0]
+
+	names := make([]string, len(columns))
+	placeholders := make([]string, len(columns))
+	var updates []sqlcColumn
+	var deletedAt sqlcColumn
+	for i, col := range columns {
+		names[i] = col.Column
+		placeholders[i] = ph(i + 1)
+		switch {
+		case col.Column == "deleted_at":
+			deletedAt = col
+		case i == 0, col.Column == "created_at", col.Column == "created_by", spec.Versioned && col.Column == "version":
+		default:
+			updates = append(updates, col)
+		}
+	}
+	selectColumns := fmt.Sprintf("SELECT %s FROM %s", strings.Join(names, ", "), table)
+	notDeleted := ""
+	if spec.Audited {
+		notDeleted = " AND deleted_at IS NULL"
+	}
+
+	entity := spec.Names.Pascal
+	data := sqlcData{
+		Spec:        spec,
+		Package:     spec.Names.Package + "db",
+		Model:       entity,
+		ID:          id,
+		Columns:     columns,
+		CoreImport:  importPath(spec.CoreDir()),
+		DBImport:    importPath(dbDir),
+		TxImport:    importPath(sqlTxPath()),
+		QueriesFile: queriesFile,
+		ContextRepo: repoStyle == "context",
+	}
+
+	data.Get = sqlcQuery{
+		Name:   "Get" + entity,
+		Kind:   "one",
+		SQL:    fmt.Sprintf("%s\nWHERE %s = %s%s LIMIT 1", selectColumns, id.Column, ph(1), notDeleted),
+		Params: []sqlcColumn{id},
+	}
+	data.List = sqlcQuery{
+		Name:   "List" + toPascal(spec.Names.Plural),
+		Kind:   "many",
+		SQL:    fmt.Sprintf("%s\nWHERE %s > %s%s\nORDER BY %s\nLIMIT %s", selectColumns, id.Column, ph(1), notDeleted, id.Column, ph(2)),
+		Params: []sqlcColumn{id, {Column: "limit", Field: "Limit", Type: "int32"}},
+	}
+	insert := fmt.Sprintf("INSERT INTO %s (%s)\nVALUES (%s)", table, strings.Join(names, ", "), strings.Join(placeholders, ", "))
+	data.Create = sqlcQuery{Name: "Create" + entity, Kind: "exec", SQL: insert, Params: columns}
+
+	if !spec.Versioned {
+		var assignments []string
+		for _, col := range updates {
+			if database == "mysql" {
+				assignments = append(assignments, fmt.Sprintf("%s = VALUES(%s)", col.Column, col.Column))
+			} else {
+				assignments = append(assignments, fmt.Sprintf("%s = EXCLUDED.%s", col.Column, col.Column))
+			}
+		}
+		upsert := insert
+		switch {
+		case len(assignments) == 0 && database == "mysql":
+			upsert = strings.Replace(insert, "INSERT", "INSERT IGNORE", 1)
+		case len(assignments) == 0:
+			upsert += fmt.Sprintf("\nON CONFLICT (%s) DO NOTHING", id.Column)
+		case database == "mysql":
+			upsert += "\nON DUPLICATE KEY UPDATE " + strings.Join(assignments, ", ")
+		default:
+			upsert += fmt.Sprintf("\nON CONFLICT (%s) DO UPDATE SET %s", id.Column, strings.Join(assignments, ", "))
+		}
+		data.Upsert = &sqlcQuery{Name: "Upsert" + entity, Kind: "exec", SQL: upsert, Params: columns}
+	}
+
+	if len(updates) > 0 || spec.Versioned {
+		// The id comes first so the update parameters line up with the columns
+		params := append([]sqlcColumn{id}, updates...)
+		var assignments []string
+		for i, col := range updates {
+			assignments = append(assignments, fmt.Sprintf("%s = %s", col.Column, ph(i+2)))
+		}
+		where := fmt.Sprintf("%s = %s", id.Column, ph(1))
+		if spec.Versioned {
+			assignments = append(assignments, "version = version + 1")
+			for _, col := range columns {
+				if col.Column == "version" {
+					params = append(params, col)
+				}
+			}
+			where += " AND version = " + ph(len(params))
+		}
+		data.Update = &sqlcQuery{
+			Name:   "Update" + entity,
+			Kind:   "execrows",
+			SQL:    fmt.Sprintf("UPDATE %s SET %s\nWHERE %s%s", table, strings.Join(assignments, ", "), where, notDeleted),
+			Params: params,
+		}
+	}
+
+	data.Delete = sqlcQuery{
+		Name:   "Delete" + entity,
+		Kind:   "execrows",
+		SQL:    fmt.Sprintf("DELETE FROM %s\nWHERE %s = %s", table, id.Column, ph(1)),
+		Params: []sqlcColumn{id},
+	}
+	if spec.Audited {
+		// Audited entities are soft deleted
+		data.Delete.SQL = fmt.Sprintf("UPDATE %s SET deleted_at = %s\nWHERE %s = %s AND deleted_at IS NULL", table, ph(2), id.Column, ph(1))
+		data.Delete.Params = []sqlcColumn{id, deletedAt}
+	}
+
+	data.Queries = []sqlcQuery{data.Get, data.List, data.Create}
+	if data.Upsert != nil {
+		data.Queries = append(data.Queries, *data.Upsert)
+	}
+	if data.Update != nil {
+		data.Queries = append(data.Queries, *data.Update)
+	}
+	data.Queries = append(data.Queries, data.Delete)
+	return data
+}
+
+// generateSQLCRepository writes the query file, adds it to sqlc.yaml, and
+// generates both the code sqlc would generate from it and an adapter from
+// the sqlc structs to the domain. Running sqlc generate later replaces only
+// the generated package.
+func generateSQLCRepository(spec domainSpec, columns []sqlcColumn) {
+	ensureUnitOfWork()
+
+	persistencePath := filepath.Join(layerPath(boundedContext, "adapters/persistence"), spec.Names.Package)
+	dbDir := filepath.Join(persistencePath, spec.Names.Package+"db")
+	queriesDir := filepath.Join("sql", "queries")
+	if boundedContext != "" {
+		queriesDir = filepath.Join(queriesDir, mustNames(boundedContext).Package)
+	}
+	for _, dir := range []string{dbDir, queriesDir} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			fmt.Printf("Error creating directory %s: %v\n", dir, err)
+			os.Exit(1)
+		}
+	}
+
+	queriesFile := filepath.Join(queriesDir, spec.Names.Snake+".sql")
+	data := newSQLCData(spec, columns, queriesFile, dbDir)
+
+	queriesTemplate := `{{range $i, $q := .Queries}}{{if $i}}
+{{end}}-- name: {{$q.Name}} :{{$q.Kind}}
+{{$q.SQL}};
+{{end}}`
+	generateFile(queriesFile, queriesTemplate, data)
+
+	header := `// Code generated by go-ddd-skel from {{.QueriesFile}} in the layout of sqlc. DO NOT EDIT.
+// Regenerate with: sqlc generate
+`
+
+	dbTemplate := header + `
+package {{.Package}}
+
+import (
+	"context"
+	"database/sql"
+)
+
+type DBTX interface {
+	ExecContext(context.Context, string, ...interface{}) (sql.Result, error)
+	PrepareContext(context.Context, string) (*sql.Stmt, error)
+	QueryContext(context.Context, string, ...interface{}) (*sql.Rows, error)
+	QueryRowContext(context.Context, string, ...interface{}) *sql.Row
+}
+
+func New(db DBTX) *Queries {
+	return &Queries{db: db}
+}
+
+type Queries struct {
+	db DBTX
+}
+
+func (q *Queries) WithTx(tx *sql.Tx) *Queries {
+	return &Queries{
+		db: tx,
+	}
+}
+`
+	generateFile(filepath.Join(dbDir, "db.go"), dbTemplate, data)
+
+	modelsTemplate := header + `
+package {{.Package}}
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+type {{.Model}} struct {
+{{- range .Columns}}
+	{{.Field}} {{.Type}}
+{{- end}}
+}
+`
+	modelsPath := filepath.Join(dbDir, "models.go")
+	generateFile(modelsPath, modelsTemplate, data)
+	removeUnusedImports(modelsPath)
+
+	queriesGoTemplate := header + `
+package {{.Package}}
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+{{range .Queries}}
+const {{.Const}} = ` + "`-- name: {{.Name}} :{{.Kind}}\n{{.SQL}}\n`" + `
+{{if .ParamsStruct}}
+type {{.Name}}Params struct {
+{{- range .Params}}
+	{{.Field}} {{.Type}}
+{{- end}}
+}
+{{end}}
+{{- if eq .Kind "one"}}
+func (q *Queries) {{.Name}}({{.Signature}}) ({{$.Model}}, error) {
+	row := q.db.QueryRowContext(ctx, {{.Const}}{{.Args}})
+	var i {{$.Model}}
+	err := row.Scan(
+{{- range $.Columns}}
+		&i.{{.Field}},
+{{- end}}
+	)
+	return i, err
+}
+{{- else if eq .Kind "many"}}
+func (q *Queries) {{.Name}}({{.Signature}}) ([]{{$.Model}}, error) {
+	rows, err := q.db.QueryContext(ctx, {{.Const}}{{.Args}})
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []{{$.Model}}
+	for rows.Next() {
+		var i {{$.Model}}
+		if err := rows.Scan(
+{{- range $.Columns}}
+			&i.{{.Field}},
+{{- end}}
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+{{- else if eq .Kind "execrows"}}
+func (q *Queries) {{.Name}}({{.Signature}}) (int64, error) {
+	result, err := q.db.ExecContext(ctx, {{.Const}}{{.Args}})
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+{{- else}}
+func (q *Queries) {{.Name}}({{.Signature}}) error {
+	_, err := q.db.ExecContext(ctx, {{.Const}}{{.Args}})
+	return err
+}
+{{- end}}
+{{end}}`
+	queriesGoPath := filepath.Join(dbDir, spec.Names.Snake+".sql.go")
+	generateFile(queriesGoPath, queriesGoTemplate, data)
+	removeUnusedImports(queriesGoPath)
+
+	adapterTemplate := `package {{.Spec.Domain}}
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	domain "{{.CoreImport}}"
+	"{{.DBImport}}"
+	"{{.TxImport}}"
+)
+
+// {{.Spec.Entity}}Repository stores {{.Spec.Entity}} entities with the sqlc queries in {{.QueriesFile}}.
+type {{.Spec.Entity}}Repository struct {
+	q *{{.Package}}.Queries
+{{- if .Spec.Audited}}
+	now func() time.Time
+{{- end}}
+}
+
+var _ domain.{{.Spec.Repository}} = (*{{.Spec.Entity}}Repository)(nil)
+
+// New{{.Spec.Entity}}Repository accepts a *sql.DB, or a *sql.Tx to take part in a transaction.
+func New{{.Spec.Entity}}Repository(db {{.Package}}.DBTX) *{{.Spec.Entity}}Repository {
+	return &{{.Spec.Entity}}Repository{q: {{.Package}}.New(db){{if .Spec.Audited}}, now: time.Now{{end}}}
+}
+
+// queries runs in the transaction of the sqltx unit of work running ctx, if any.
+func (r *{{.Spec.Entity}}Repository) queries(ctx context.Context) *{{.Package}}.Queries {
+	if tx, ok := sqltx.FromContext(ctx); ok {
+		return r.q.WithTx(tx)
+	}
+	return r.q
+}
+
+{{if .ContextRepo}}func (r *{{.Spec.Entity}}Repository) Save(ctx context.Context, e *domain.{{.Spec.Entity}}) error {
+{{- else}}func (r *{{.Spec.Entity}}Repository) Save(e *domain.{{.Spec.Entity}}) error {
+	ctx := context.Background()
+{{- end}}
+{{- if .Spec.Audited}}
+	now := r.now().UTC()
+	if e.CreatedAt.IsZero() {
+		e.CreatedAt = now
+	}
+	e.UpdatedAt = now
+{{- end}}
+{{- if .Spec.Versioned}}
+
+	// A zero version means the entity has never been stored
+	if e.Version == 0 {
+		e.Version = 1
+		row := to{{.Model}}Row(e)
+		if err := r.queries(ctx).{{.Create.Name}}(ctx, {{.RowArg .Create}}); err != nil {
+			e.Version = 0
+			return err
+		}
+		return nil
+	}
+
+	// Compare-and-swap: the update only applies if nobody saved a newer version
+	row := to{{.Model}}Row(e)
+	n, err := r.queries(ctx).{{.Update.Name}}(ctx, {{.Package}}.{{.Update.Name}}Params{
+{{- range .Update.Params}}
+		{{.Field}}: row.{{.Field}},
+{{- end}}
+	})
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return &domain.ErrConcurrentModification{ID: e.ID, Version: e.Version}
+	}
+	e.Version++
+	return nil
+{{- else}}
+	row := to{{.Model}}Row(e)
+	return r.queries(ctx).{{.Upsert.Name}}(ctx, {{.RowArg .Upsert}})
+{{- end}}
+}
+
+{{if .ContextRepo}}func (r *{{.Spec.Entity}}Repository) FindByID(ctx context.Context, id {{.Spec.QualifiedIDType}}) (*domain.{{.Spec.Entity}}, error) {
+{{- else}}func (r *{{.Spec.Entity}}Repository) FindByID(id {{.Spec.QualifiedIDType}}) (*domain.{{.Spec.Entity}}, error) {
+	ctx := context.Background()
+{{- end}}
+	row, err := r.queries(ctx).{{.Get.Name}}(ctx, {{.IDArg}})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return to{{.Model}}(row), nil
+}
+{{- if .ContextRepo}}
+
+func (r *{{.Spec.Entity}}Repository) Delete(ctx context.Context, id {{.Spec.QualifiedIDType}}) error {
+{{- if .Spec.Audited}}
+	// Audited entities are soft deleted
+	n, err := r.queries(ctx).{{.Delete.Name}}(ctx, {{.Package}}.{{.Delete.Name}}Params{
+		{{.ID.Field}}: {{.IDArg}},
+		DeletedAt: sql.NullTime{Time: r.now().UTC(), Valid: true},
+	})
+{{- else}}
+	n, err := r.queries(ctx).{{.Delete.Name}}(ctx, {{.IDArg}})
+{{- end}}
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+func (r *{{.Spec.Entity}}Repository) List(ctx context.Context, filter domain.ListFilter, cursor string, limit int) (*domain.Page, error) {
+	// Apply filter fields to the query here
+{{- if eq .ID.Type "string"}}
+	after := cursor
+{{- else}}
+	var after {{.ID.Type}}
+	if cursor != "" {
+		n, err := strconv.ParseInt(cursor, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cursor %q: %w", cursor, err)
+		}
+		after = {{if eq .ID.Type "int64"}}n{{else}}{{.ID.Type}}(n){{end}}
+	}
+{{- end}}
+
+	rows, err := r.queries(ctx).{{.List.Name}}(ctx, {{.Package}}.{{.List.Name}}Params{ {{- .ID.Field}}: after, Limit: int32(limit)})
+	if err != nil {
+		return nil, err
+	}
+
+	page := &domain.Page{}
+	for _, row := range rows {
+		page.Items = append(page.Items, to{{.Model}}(row))
+	}
+	if limit > 0 && len(rows) == limit {
+		page.NextCursor = fmt.Sprint(rows[len(rows)-1].{{.ID.Field}})
+	}
+	return page, nil
+}
+{{- end}}
+
+// to{{.Model}}Row converts a domain entity into the sqlc row struct.
+func to{{.Model}}Row(e *domain.{{.Spec.Entity}}) {{.Package}}.{{.Model}} {
+	row := {{.Package}}.{{.Model}}{
+{{- range .Columns}}{{with .RowInit}}
+		{{.}}
+{{- end}}{{end}}
+	}
+{{- range .Columns}}{{with .RowFixup}}
+	{{.}}
+{{- end}}{{end}}
+	return row
+}
+
+// to{{.Model}} converts a sqlc row struct back into a domain entity.
+func to{{.Model}}(row {{.Package}}.{{.Model}}) *domain.{{.Spec.Entity}} {
+	e := &domain.{{.Spec.Entity}}{
+{{- range .Columns}}{{with .EntityInit}}
+		{{.}}
+{{- end}}{{end}}
+	}
+{{- range .Columns}}{{with .EntityFixup}}
+	{{.}}
+{{- end}}{{end}}
+	return e
+}
+`
+	adapterPath := filepath.Join(persistencePath, "repository.go")
+	generateFile(adapterPath, adapterTemplate, data)
+	removeUnusedImports(adapterPath)
+
+	addSQLCConfig(queriesFile, dbDir, data.Package, sqlcSchema(spec.TableName()), sqlcOverrides(spec.TableName(), columns))
+}
+
+// sqlcGoType is the go_type of an sqlc override for a column type, false
+// for types sqlc cannot be told to use.
+func sqlcGoType(typ string) (string, bool) {
+	switch {
+	case strings.HasPrefix(typ, "[]"):
+		return "", false
+	case strings.HasPrefix(typ, "sql."):
+		return "database/sql." + strings.TrimPrefix(typ, "sql."), true
+	case strings.HasPrefix(typ, "json."):
+		return "encoding/json." + strings.TrimPrefix(typ, "json."), true
+	}
+	return typ, true
+}
+
+// sqlcOverride pins the Go type sqlc generates for a column.
+type sqlcOverride struct {
+	Column string `yaml:"column"`
+	GoType string `yaml:"go_type"`
+}
+
+// sqlcOverrides pins the type of every column of table to the one the
+// adapter converts from, which sqlc would otherwise pick from the database
+// type, e.g. uuid.NullUUID for a nullable UUID or pqtype.NullRawMessage for
+// a nullable JSONB column.
+func sqlcOverrides(table string, columns []sqlcColumn) []sqlcOverride {
+	var overrides []sqlcOverride
+	for _, col := range columns {
+		if goType, ok := sqlcGoType(col.Type); ok {
+			overrides = append(overrides, sqlcOverride{Column: table + "." + col.Column, GoType: goType})
+		}
+	}
+	return overrides
+}
+
+// sqlcSchema returns the schema sqlc reads table from: the migrations when
+// one creates it, or else the first other .sql file of the project that does,
+// such as the schema a domain was generated from with --from-sql.
+func sqlcSchema(table string) string {
+	if files, _ := filepath.Glob(filepath.Join(migrationsDir, "*.up.sql")); createsTable(files, table) {
+		return migrationsDir
+	}
+	var schema string
+	filepath.WalkDir(".", func(path string, d fs.DirEntry, err error) error {
+		switch {
+		case err != nil:
+			return nil
+		case schema != "":
+			return filepath.SkipAll
+		case d.IsDir() && path != "." && (strings.HasPrefix(d.Name(), ".") || d.Name() == "vendor" || d.Name() == "node_modules" || path == migrationsDir):
+			return filepath.SkipDir
+		case !d.IsDir() && strings.HasSuffix(path, ".sql") && createsTable([]string{path}, table):
+			schema = filepath.ToSlash(path)
+		}
+		return nil
+	})
+	if schema == "" {
+		fmt.Printf("No migration creates table %s yet; add one to %s before running sqlc generate\n", table, migrationsDir)
+		return migrationsDir
+	}
+	return schema
+}
+
+// createsTable reports whether one of the SQL files creates table.
+func createsTable(files []string, table string) bool {
+	for _, file := range files {
+		src, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		tables, _ := parseSQLSchema(string(src))
+		for _, t := range tables {
+			if strings.EqualFold(t.Name, table) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// addSQLCConfig adds the query file to sqlc.yaml, creating it if needed.
+// Existing entries, comments and key order are kept; only the overrides of
+// the query file's entry are replaced when it is generated again.
+func addSQLCConfig(queriesFile, out, pkg, schema string, overrides []sqlcOverride) {
+	const configFile = "sqlc.yaml"
+	engine := "postgresql"
+	if loadProject().Database == "mysql" {
+		engine = "mysql"
+	}
+
+	var doc yaml.Node
+	content, err := os.ReadFile(configFile)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		content = []byte("version: \"2\"\nsql: []\n")
+	case err != nil:
+		fmt.Printf("Error reading %s: %v\n", configFile, err)
+		os.Exit(1)
+	}
+	if err := yaml.Unmarshal(content, &doc); err != nil || len(doc.Content) == 0 {
+		fmt.Printf("Error parsing %s: %v\n", configFile, err)
+		os.Exit(1)
+	}
+
+	root := doc.Content[0]
+	var entries *yaml.Node
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == "sql" {
+			entries = root.Content[i+1]
+		}
+	}
+	if entries == nil {
+		entries = &yaml.Node{Kind: yaml.SequenceNode}
+		root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: "sql"}, entries)
+	}
+
+	var overridesNode yaml.Node
+	if err := overridesNode.Encode(overrides); err != nil {
+		fmt.Printf("Error building %s overrides: %v\n", configFile, err)
+		os.Exit(1)
+	}
+	for _, override := range overridesNode.Content {
+		for i := 1; i < len(override.Content); i += 2 {
+			override.Content[i].Style = yaml.DoubleQuotedStyle
+		}
+	}
+
+	queries := filepath.ToSlash(queriesFile)
+	var existing *yaml.Node
+	for _, entry := range entries.Content {
+		if mappingValue(entry, "queries") != nil && mappingValue(entry, "queries").Value == queries {
+			existing = entry
+		}
+	}
+	if existing != nil {
+		goGen := mappingValue(mappingValue(existing, "gen"), "go")
+		if goGen == nil {
+			return
+		}
+		if v := mappingValue(goGen, "overrides"); v != nil {
+			*v = overridesNode
+		} else {
+			goGen.Content = append(goGen.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: "overrides"}, &overridesNode)
+		}
+	} else {
+		// sqlc ignores the down files when the schema is the migrations
+		entry := fmt.Sprintf(`engine: %q
+queries: %q
+schema: %q
+gen:
+  go:
+    package: %q
+    out: %q
+    sql_package: "database/sql"
+`, engine, queries, schema, pkg, filepath.ToSlash(out))
+		var entryDoc yaml.Node
+		if err := yaml.Unmarshal([]byte(entry), &entryDoc); err != nil {
+			fmt.Printf("Error building %s entry: %v\n", configFile, err)
+			os.Exit(1)
+		}
+		goGen := mappingValue(mappingValue(entryDoc.Content[0], "gen"), "go")
+		goGen.Content = append(goGen.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: "overrides"}, &overridesNode)
+		entries.Style = 0
+		entries.Content = append(entries.Content, entryDoc.Content[0])
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		fmt.Printf("Error encoding %s: %v\n", configFile, err)
+		os.Exit(1)
+	}
+	if err := os.WriteFile(configFile, buf.Bytes(), 0644); err != nil {
+		fmt.Printf("Error writing %s: %v\n", configFile, err)
+		os.Exit(1)
+	}
+}
+
+// mappingValue returns the value of key in the YAML mapping node, or nil.
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
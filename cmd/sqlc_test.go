@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"go/ast"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestNewSQLCColumn(t *testing.T) {
+	pkg := &sourcePackage{
+		types: map[string]ast.Expr{"Decimal": ast.NewIdent("string"), "Address": &ast.StructType{Fields: &ast.FieldList{}}},
+		decls: map[string]bool{"Decimal": true, "Address": true},
+	}
+	tests := []struct {
+		goType string
+		want   sqlcColumn
+		ok     bool
+	}{
+		{"string", sqlcColumn{Type: "string", RowInit: "Note: e.Note,", EntityInit: "Note: row.Note,"}, true},
+		{"int", sqlcColumn{Type: "int64", RowInit: "Note: int64(e.Note),", EntityInit: "Note: int(row.Note),"}, true},
+		{"Decimal", sqlcColumn{Type: "string", RowInit: "Note: string(e.Note),", EntityInit: "Note: domain.Decimal(row.Note),"}, true},
+		{"*string", sqlcColumn{
+			Type:        "sql.NullString",
+			RowFixup:    "if e.Note != nil {\nrow.Note = sql.NullString{String: *e.Note, Valid: true}\n}",
+			EntityFixup: "if row.Note.Valid {\nv := row.Note.String\ne.Note = &v\n}",
+		}, true},
+		{"*Decimal", sqlcColumn{
+			Type:        "sql.NullString",
+			RowFixup:    "if e.Note != nil {\nrow.Note = sql.NullString{String: string(*e.Note), Valid: true}\n}",
+			EntityFixup: "if row.Note.Valid {\nv := domain.Decimal(row.Note.String)\ne.Note = &v\n}",
+		}, true},
+		{"*time.Time", sqlcColumn{
+			Type:        "sql.NullTime",
+			RowFixup:    "if e.Note != nil {\nrow.Note = sql.NullTime{Time: *e.Note, Valid: true}\n}",
+			EntityFixup: "if row.Note.Valid {\nv := row.Note.Time\ne.Note = &v\n}",
+		}, true},
+		{"json.RawMessage", sqlcColumn{Type: "json.RawMessage", RowInit: "Note: e.Note,", EntityInit: "Note: row.Note,"}, true},
+		{"*[]byte", sqlcColumn{}, false},
+		{"Address", sqlcColumn{}, false},
+		{"map[string]string", sqlcColumn{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := newSQLCColumn(pkg, "Note", "note", tt.goType)
+		if tt.ok {
+			tt.want.Column, tt.want.Field = "note", "Note"
+		}
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("newSQLCColumn(%q) = %+v, %v, want %+v, %v", tt.goType, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestSQLCOverrides(t *testing.T) {
+	columns := []sqlcColumn{
+		{Column: "id", Type: "string"},
+		{Column: "data", Type: "json.RawMessage"},
+		{Column: "owner_id", Type: "sql.NullString"},
+		{Column: "created_at", Type: "time.Time"},
+		{Column: "avatar", Type: "[]byte"},
+	}
+	want := []sqlcOverride{
+		{"products.id", "string"},
+		{"products.data", "encoding/json.RawMessage"},
+		{"products.owner_id", "database/sql.NullString"},
+		{"products.created_at", "time.Time"},
+	}
+	if got := sqlcOverrides("products", columns); !reflect.DeepEqual(got, want) {
+		t.Errorf("sqlcOverrides() = %+v, want %+v", got, want)
+	}
+}
+
+func TestSQLCSchema(t *testing.T) {
+	inModule(t, "app")
+	files := map[string]string{
+		"sql/schema.sql":                           "CREATE TABLE products (id UUID PRIMARY KEY);",
+		"sql/queries/product.sql":                  "-- name: GetProduct :one\nSELECT id FROM products WHERE id = $1;",
+		"migrations/20240101000000_users.up.sql":   "CREATE TABLE users (id UUID PRIMARY KEY);",
+		"migrations/20240101000000_users.down.sql": "DROP TABLE users;",
+	}
+	for name, src := range files {
+		if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(name, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := []struct {
+		table string
+		want  string
+	}{
+		{"users", "migrations"},
+		{"products", "sql/schema.sql"},
+		{"orders", "migrations"},
+	}
+	for _, tt := range tests {
+		if got := sqlcSchema(tt.table); got != tt.want {
+			t.Errorf("sqlcSchema(%q) = %q, want %q", tt.table, got, tt.want)
+		}
+	}
+}
+
+func TestAddSQLCConfig(t *testing.T) {
+	inModule(t, "app")
+	addSQLCConfig("sql/queries/product.sql", "internal/adapters/persistence/product/productdb", "productdb", "sql/schema.sql",
+		[]sqlcOverride{{"products.id", "string"}, {"products.data", "encoding/json.RawMessage"}})
+	// Generated again with a column less, only the overrides change
+	addSQLCConfig("sql/queries/product.sql", "internal/adapters/persistence/product/productdb", "productdb", "migrations",
+		[]sqlcOverride{{"products.id", "string"}})
+	got, err := os.ReadFile("sqlc.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `version: "2"
+sql:
+  - engine: "postgresql"
+    queries: "sql/queries/product.sql"
+    schema: "sql/schema.sql"
+    gen:
+      go:
+        package: "productdb"
+        out: "internal/adapters/persistence/product/productdb"
+        sql_package: "database/sql"
+        overrides:
+          - column: "products.id"
+            go_type: "string"
+`
+	if string(got) != want {
+		t.Errorf("sqlc.yaml =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,307 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// sqlRepositoryData holds the queries and argument lists of a generated
+// database/sql repository. Args are Go expressions on the entity e.
+type sqlRepositoryData struct {
+	Spec        domainSpec
+	CoreImport  string
+	TxImport    string
+	ContextRepo bool
+
+	SelectByID string
+	Insert     string
+	InsertArgs []string
+	Upsert     string
+	UpdateCAS  string
+	UpdateArgs []string
+	Delete     string
+	ListFirst  string
+	ListAfter  string
+	ScanArgs   []string
+}
+
+// sqlPlaceholder returns the n-th (1-based) bind parameter for the project's database.
+func sqlPlaceholder(database string, n int) string {
+	if database == "mysql" {
+		return "?"
+	}
+	return fmt.Sprintf("$%d", n)
+}
+
+func newSQLRepositoryData(spec domainSpec) sqlRepositoryData {
+	database := loadProject().Database
+	table := spec.TableName()
+	idColumn := spec.IDColumnName()
+	ph := func(n int) string { return sqlPlaceholder(database, n) }
+
+	columns := []string{idColumn}
+	scanArgs := []string{"&e.ID"}
+	insertArgs := []string{"e.ID"}
+	var updateColumns, updateArgs []string
+	for _, f := range spec.Fields {
+		columns = append(columns, f.Column)
+		scanArgs = append(scanArgs, "&e."+f.Name)
+		insertArgs = append(insertArgs, "e."+f.Name)
+		// Creation, deletion and version columns are never overwritten by a plain update
+		if f.Column == "created_at" || f.Column == "created_by" || f.Column == "deleted_at" || (spec.Versioned && f.Column == "version") {
+			continue
+		}
+		updateColumns = append(updateColumns, f.Column)
+		updateArgs = append(updateArgs, "e."+f.Name)
+	}
+
+	notDeleted := ""
+	if spec.Audited {
+		notDeleted = " AND deleted_at IS NULL"
+	}
+
+	placeholders := make([]string, len(columns))
+	for i := range columns {
+		placeholders[i] = ph(i + 1)
+	}
+	insert := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", table, strings.Join(columns, ", "), strings.Join(placeholders, ", "))
+
+	var assignments, upsertAssignments []string
+	for i, col := range updateColumns {
+		assignments = append(assignments, fmt.Sprintf("%s = %s", col, ph(i+1)))
+		if database == "mysql" {
+			upsertAssignments = append(upsertAssignments, fmt.Sprintf("%s = VALUES(%s)", col, col))
+		} else {
+			upsertAssignments = append(upsertAssignments, fmt.Sprintf("%s = EXCLUDED.%s", col, col))
+		}
+	}
+
+	upsert := insert
+	switch {
+	case len(upsertAssignments) == 0 && database == "mysql":
+		upsert = strings.Replace(insert, "INSERT", "INSERT IGNORE", 1)
+	case len(upsertAssignments) == 0:
+		upsert += fmt.Sprintf(" ON CONFLICT (%s) DO NOTHING", idColumn)
+	case database == "mysql":
+		upsert += " ON DUPLICATE KEY UPDATE " + strings.Join(upsertAssignments, ", ")
+	default:
+		upsert += fmt.Sprintf(" ON CONFLICT (%s) DO UPDATE SET %s", idColumn, strings.Join(upsertAssignments, ", "))
+	}
+
+	n := len(updateColumns)
+	updateCAS := fmt.Sprintf("UPDATE %s SET %s WHERE %s = %s AND version = %s%s",
+		table, strings.Join(append(assignments, "version = version + 1"), ", "), idColumn, ph(n+1), ph(n+2), notDeleted)
+
+	deleteQuery := fmt.Sprintf("DELETE FROM %s WHERE %s = %s", table, idColumn, ph(1))
+	if spec.Audited {
+		deleteQuery = fmt.Sprintf("UPDATE %s SET deleted_at = %s WHERE %s = %s AND deleted_at IS NULL", table, ph(1), idColumn, ph(2))
+	}
+
+	selectColumns := fmt.Sprintf("SELECT %s FROM %s", strings.Join(columns, ", "), table)
+	listWhere := ""
+	if spec.Audited {
+		listWhere = " WHERE deleted_at IS NULL"
+	}
+	listAfterWhere := " WHERE "
+	if spec.Audited {
+		listAfterWhere = " WHERE deleted_at IS NULL AND "
+	}
+
+	return sqlRepositoryData{
+		Spec:        spec,
+		CoreImport:  importPath(spec.CoreDir()),
+		TxImport:    importPath(sqlTxPath()),
+		ContextRepo: repoStyle == "context",
+		SelectByID:  fmt.Sprintf("%s WHERE %s = %s%s", selectColumns, idColumn, ph(1), notDeleted),
+		Insert:      insert,
+		InsertArgs:  insertArgs,
+		Upsert:      upsert,
+		UpdateCAS:   updateCAS,
+		UpdateArgs:  updateArgs,
+		Delete:      deleteQuery,
+		ListFirst:   fmt.Sprintf("%s%s ORDER BY %s LIMIT %s", selectColumns, listWhere, idColumn, ph(1)),
+		ListAfter:   fmt.Sprintf("%s%s%s > %s ORDER BY %s LIMIT %s", selectColumns, listAfterWhere, idColumn, ph(1), idColumn, ph(2)),
+		ScanArgs:    scanArgs,
+	}
+}
+
+// generateSQLRepository writes a database/sql implementation of the domain
+// repository. Versioned entities are saved with compare-and-swap updates and
+// audited entities are soft deleted and filtered out of every query.
+func generateSQLRepository(spec domainSpec) {
+	ensureUnitOfWork()
+
+	// Create persistence directory
+	persistencePath := filepath.Join(layerPath(boundedContext, "adapters/persistence"), spec.Names.Package)
+	if err := os.MkdirAll(persistencePath, 0755); err != nil {
+		fmt.Printf("Error creating persistence directory: %v\n", err)
+		os.Exit(1)
+	}
+
+	repoTemplate := `package {{.Spec.Domain}}
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+{{- if .ContextRepo}}
+	"fmt"
+{{- end}}
+{{- if .Spec.Audited}}
+	"time"
+{{- end}}
+
+	domain "{{.CoreImport}}"
+	"{{.TxImport}}"
+)
+
+const (
+	select{{.Spec.Entity}}ByID = ` + "`{{.SelectByID}}`" + `
+{{- if .Spec.Versioned}}
+	insert{{.Spec.Entity}} = ` + "`{{.Insert}}`" + `
+	update{{.Spec.Entity}} = ` + "`{{.UpdateCAS}}`" + `
+{{- else}}
+	upsert{{.Spec.Entity}} = ` + "`{{.Upsert}}`" + `
+{{- end}}
+{{- if .ContextRepo}}
+	delete{{.Spec.Entity}} = ` + "`{{.Delete}}`" + `
+	list{{.Spec.Entity}}First = ` + "`{{.ListFirst}}`" + `
+	list{{.Spec.Entity}}After = ` + "`{{.ListAfter}}`" + `
+{{- end}}
+)
+
+// {{.Spec.Entity}}Repository stores {{.Spec.Entity}} entities with database/sql.
+// Called with the context of a sqltx unit of work it uses its transaction.
+type {{.Spec.Entity}}Repository struct {
+	db  sqltx.DBTX
+{{- if .Spec.Audited}}
+	now func() time.Time
+{{- end}}
+}
+
+var _ domain.{{.Spec.Repository}} = (*{{.Spec.Entity}}Repository)(nil)
+
+func New{{.Spec.Entity}}Repository(db *sql.DB) *{{.Spec.Entity}}Repository {
+	return &{{.Spec.Entity}}Repository{db: db{{if .Spec.Audited}}, now: time.Now{{end}}}
+}
+
+// New{{.Spec.Entity}}RepositoryTx returns a repository working in tx.
+func New{{.Spec.Entity}}RepositoryTx(tx *sql.Tx) *{{.Spec.Entity}}Repository {
+	return &{{.Spec.Entity}}Repository{db: tx{{if .Spec.Audited}}, now: time.Now{{end}}}
+}
+
+{{if .ContextRepo}}func (r *{{.Spec.Entity}}Repository) Save(ctx context.Context, e *domain.{{.Spec.Entity}}) error {
+{{- else}}func (r *{{.Spec.Entity}}Repository) Save(e *domain.{{.Spec.Entity}}) error {
+	ctx := context.Background()
+{{- end}}
+{{- if .Spec.Audited}}
+	now := r.now().UTC()
+	if e.CreatedAt.IsZero() {
+		e.CreatedAt = now
+	}
+	e.UpdatedAt = now
+{{- end}}
+{{- if .Spec.Versioned}}
+
+	// A zero version means the entity has never been stored
+	if e.Version == 0 {
+		e.Version = 1
+		if _, err := sqltx.Conn(ctx, r.db).ExecContext(ctx, insert{{.Spec.Entity}}, {{join .InsertArgs ", "}}); err != nil {
+			e.Version = 0
+			return err
+		}
+		return nil
+	}
+
+	// Compare-and-swap: the update only applies if nobody saved a newer version
+	res, err := sqltx.Conn(ctx, r.db).ExecContext(ctx, update{{.Spec.Entity}}, {{range .UpdateArgs}}{{.}}, {{end}}e.ID, e.Version)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return &domain.ErrConcurrentModification{ID: e.ID, Version: e.Version}
+	}
+	e.Version++
+	return nil
+{{- else}}
+	_, err := sqltx.Conn(ctx, r.db).ExecContext(ctx, upsert{{.Spec.Entity}}, {{join .InsertArgs ", "}})
+	return err
+{{- end}}
+}
+
+{{if .ContextRepo}}func (r *{{.Spec.Entity}}Repository) FindByID(ctx context.Context, id {{.Spec.QualifiedIDType}}) (*domain.{{.Spec.Entity}}, error) {
+{{- else}}func (r *{{.Spec.Entity}}Repository) FindByID(id {{.Spec.QualifiedIDType}}) (*domain.{{.Spec.Entity}}, error) {
+	ctx := context.Background()
+{{- end}}
+	e := &domain.{{.Spec.Entity}}{}
+	err := sqltx.Conn(ctx, r.db).QueryRowContext(ctx, select{{.Spec.Entity}}ByID, id).Scan({{join .ScanArgs ", "}})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+{{- if .ContextRepo}}
+
+func (r *{{.Spec.Entity}}Repository) Delete(ctx context.Context, id {{.Spec.QualifiedIDType}}) error {
+{{- if .Spec.Audited}}
+	// Audited entities are soft deleted
+	res, err := sqltx.Conn(ctx, r.db).ExecContext(ctx, delete{{.Spec.Entity}}, r.now().UTC(), id)
+{{- else}}
+	res, err := sqltx.Conn(ctx, r.db).ExecContext(ctx, delete{{.Spec.Entity}}, id)
+{{- end}}
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+func (r *{{.Spec.Entity}}Repository) List(ctx context.Context, filter domain.ListFilter, cursor string, limit int) (*domain.Page, error) {
+	// Apply filter fields to the query here
+	var rows *sql.Rows
+	var err error
+	if cursor == "" {
+		rows, err = sqltx.Conn(ctx, r.db).QueryContext(ctx, list{{.Spec.Entity}}First, limit)
+	} else {
+		rows, err = sqltx.Conn(ctx, r.db).QueryContext(ctx, list{{.Spec.Entity}}After, cursor, limit)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	page := &domain.Page{}
+	for rows.Next() {
+		e := &domain.{{.Spec.Entity}}{}
+		if err := rows.Scan({{join .ScanArgs ", "}}); err != nil {
+			return nil, err
+		}
+		page.Items = append(page.Items, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if limit > 0 && len(page.Items) == limit {
+		page.NextCursor = fmt.Sprint(page.Items[len(page.Items)-1].ID)
+	}
+	return page, nil
+}
+{{- end}}
+`
+	generateFile(filepath.Join(persistencePath, "repository.go"), repoTemplate, newSQLRepositoryData(spec))
+}
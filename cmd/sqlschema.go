@@ -0,0 +1,348 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// sqlTable is a table parsed from a CREATE TABLE statement.
+type sqlTable struct {
+	Name       string
+	Columns    []sqlColumn
+	PrimaryKey []string
+}
+
+// sqlColumn is a single column definition. Type is the lower-cased base type
+// without its length or precision, e.g. "varchar" or "double precision".
+type sqlColumn struct {
+	Name       string
+	Type       string
+	Unsigned   bool
+	Array      bool
+	Length     string
+	NotNull    bool
+	PrimaryKey bool
+}
+
+// columnConstraintWords end the type part of a column definition.
+var columnConstraintWords = map[string]bool{
+	"not": true, "null": true, "primary": true, "default": true, "unique": true,
+	"references": true, "check": true, "auto_increment": true, "comment": true,
+	"collate": true, "generated": true, "constraint": true, "on": true,
+	"identity": true, "key": true,
+}
+
+// parseSQLSchema extracts every CREATE TABLE statement from a schema file.
+// It understands the common Postgres and MySQL column syntax and ignores
+// every other statement.
+func parseSQLSchema(src string) ([]sqlTable, error) {
+	var tables []sqlTable
+	for _, stmt := range splitSQLStatements(stripSQLComments(src)) {
+		tokens := tokenizeSQL(stmt)
+		if len(tokens) < 3 || !strings.EqualFold(tokens[0], "create") {
+			continue
+		}
+
+		i := 1
+		for i < len(tokens) && isSQLWord(tokens[i], "temporary", "temp", "unlogged", "or", "replace") {
+			i++
+		}
+		if i >= len(tokens) || !strings.EqualFold(tokens[i], "table") {
+			continue
+		}
+		i++
+		if i+2 < len(tokens) && isSQLWord(tokens[i], "if") && isSQLWord(tokens[i+1], "not") && isSQLWord(tokens[i+2], "exists") {
+			i += 3
+		}
+		if i+1 >= len(tokens) || !strings.HasPrefix(tokens[i+1], "(") {
+			return nil, fmt.Errorf("unsupported CREATE TABLE statement: %.60s", stmt)
+		}
+
+		table, err := parseSQLTable(unquoteSQLIdent(tokens[i]), tokens[i+1])
+		if err != nil {
+			return nil, err
+		}
+		tables = append(tables, table)
+	}
+	return tables, nil
+}
+
+func parseSQLTable(name, body string) (sqlTable, error) {
+	table := sqlTable{Name: name}
+	for _, def := range splitSQLList(strings.TrimSuffix(strings.TrimPrefix(body, "("), ")")) {
+		tokens := tokenizeSQL(def)
+		if len(tokens) == 0 {
+			continue
+		}
+
+		switch strings.ToLower(tokens[0]) {
+		case "primary":
+			table.PrimaryKey = append(table.PrimaryKey, sqlGroupIdents(tokens)...)
+			continue
+		case "constraint":
+			for j, t := range tokens {
+				if isSQLWord(t, "primary") {
+					table.PrimaryKey = append(table.PrimaryKey, sqlGroupIdents(tokens[j:])...)
+				}
+			}
+			continue
+		case "unique", "key", "index", "foreign", "check", "fulltext", "spatial", "exclude":
+			continue
+		}
+
+		if len(tokens) < 2 {
+			return sqlTable{}, fmt.Errorf("table %s: invalid column definition %q", name, def)
+		}
+		table.Columns = append(table.Columns, parseSQLColumn(tokens))
+	}
+
+	for _, pk := range table.PrimaryKey {
+		for i := range table.Columns {
+			if table.Columns[i].Name == pk {
+				table.Columns[i].PrimaryKey = true
+				table.Columns[i].NotNull = true
+			}
+		}
+	}
+	return table, nil
+}
+
+func parseSQLColumn(tokens []string) sqlColumn {
+	col := sqlColumn{Name: unquoteSQLIdent(tokens[0])}
+
+	var typeWords []string
+	i := 1
+	for ; i < len(tokens); i++ {
+		t := strings.ToLower(tokens[i])
+		if columnConstraintWords[t] || (t == "character" && i+1 < len(tokens) && isSQLWord(tokens[i+1], "set")) {
+			break
+		}
+		switch {
+		case strings.HasPrefix(t, "("):
+			col.Length = strings.Trim(t, "()")
+		case t == "[]" || strings.HasSuffix(t, "[]"):
+			col.Array = true
+			if t != "[]" {
+				typeWords = append(typeWords, strings.TrimSuffix(t, "[]"))
+			}
+		case t == "unsigned":
+			col.Unsigned = true
+		case t == "signed" || t == "zerofill":
+		default:
+			typeWords = append(typeWords, t)
+		}
+	}
+	col.Type = strings.Join(typeWords, " ")
+
+	for ; i < len(tokens); i++ {
+		switch {
+		case isSQLWord(tokens[i], "not") && i+1 < len(tokens) && isSQLWord(tokens[i+1], "null"):
+			col.NotNull = true
+			i++
+		case isSQLWord(tokens[i], "primary"):
+			col.PrimaryKey = true
+			col.NotNull = true
+		}
+	}
+	return col
+}
+
+// sqlGoType maps a column to its Go type. A non-empty value object name means
+// the column is better represented by a domain value object of that name.
+func sqlGoType(col sqlColumn) (goType string, valueObject string) {
+	switch col.Type {
+	case "tinyint":
+		if col.Length == "1" {
+			goType = "bool"
+		} else {
+			goType = "int8"
+		}
+	case "smallint", "int2", "smallserial", "serial2", "year":
+		goType = "int16"
+	case "int", "integer", "int4", "mediumint", "serial", "serial4":
+		goType = "int32"
+	case "bigint", "int8", "bigserial", "serial8":
+		goType = "int64"
+	case "real", "float4", "float":
+		goType = "float32"
+	case "double", "double precision", "float8":
+		goType = "float64"
+	case "numeric", "decimal", "money", "dec", "fixed":
+		goType, valueObject = "string", "Decimal"
+	case "boolean", "bool", "bit":
+		goType = "bool"
+	case "date", "time", "timetz", "timestamp", "timestamptz", "datetime",
+		"timestamp with time zone", "timestamp without time zone",
+		"time with time zone", "time without time zone":
+		goType = "time.Time"
+	case "json", "jsonb":
+		goType = "json.RawMessage"
+	case "bytea", "blob", "tinyblob", "mediumblob", "longblob", "binary", "varbinary":
+		goType = "[]byte"
+	default:
+		// char, varchar, text, uuid, enum, inet and everything unknown
+		goType = "string"
+	}
+
+	if col.Unsigned && strings.HasPrefix(goType, "int") {
+		goType = "u" + goType
+	}
+	if goType == "string" && valueObject == "" && (col.Name == "email" || strings.HasSuffix(col.Name, "_email")) {
+		valueObject = "Email"
+	}
+	if col.Array {
+		return "[]" + goType, ""
+	}
+	return goType, valueObject
+}
+
+func stripSQLComments(src string) string {
+	var b strings.Builder
+	inString := false
+	for i := 0; i < len(src); i++ {
+		c := src[i]
+		switch {
+		case c == '\'':
+			inString = !inString
+		case !inString && c == '-' && i+1 < len(src) && src[i+1] == '-':
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+		case !inString && c == '#':
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+		case !inString && c == '/' && i+1 < len(src) && src[i+1] == '*':
+			end := strings.Index(src[i+2:], "*/")
+			if end < 0 {
+				return b.String()
+			}
+			i += end + 3
+			continue
+		}
+		if i < len(src) {
+			b.WriteByte(src[i])
+		}
+	}
+	return b.String()
+}
+
+func splitSQLStatements(src string) []string {
+	return splitSQLOn(src, ';')
+}
+
+func splitSQLList(src string) []string {
+	return splitSQLOn(src, ',')
+}
+
+// splitSQLOn splits src on sep, ignoring separators inside parentheses and
+// quoted strings or identifiers.
+func splitSQLOn(src string, sep byte) []string {
+	var parts []string
+	depth, start := 0, 0
+	var quote byte
+	for i := 0; i < len(src); i++ {
+		c := src[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case c == sep && depth == 0:
+			parts = append(parts, strings.TrimSpace(src[start:i]))
+			start = i + 1
+		}
+	}
+	if rest := strings.TrimSpace(src[start:]); rest != "" {
+		parts = append(parts, rest)
+	}
+	return parts
+}
+
+// tokenizeSQL splits a statement into words, quoted strings and identifiers,
+// keeping each parenthesised group as a single token.
+func tokenizeSQL(src string) []string {
+	var tokens []string
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case unicode.IsSpace(rune(c)):
+			i++
+		case c == '(':
+			depth, j := 0, i
+			for ; j < len(src); j++ {
+				if src[j] == '(' {
+					depth++
+				} else if src[j] == ')' {
+					depth--
+					if depth == 0 {
+						break
+					}
+				}
+			}
+			tokens = append(tokens, src[i:min(j+1, len(src))])
+			i = j + 1
+		default:
+			// Words run up to a space, parenthesis or comma, except inside
+			// quotes, so "public"."users" and 'a, b'::text are one token
+			j := i
+			for j < len(src) && !unicode.IsSpace(rune(src[j])) && src[j] != '(' && src[j] != ',' {
+				if q := src[j]; q == '\'' || q == '"' || q == '`' {
+					end := strings.IndexByte(src[j+1:], q)
+					if end < 0 {
+						j = len(src)
+						break
+					}
+					j += end + 2
+					continue
+				}
+				j++
+			}
+			if j == i {
+				j++
+			}
+			tokens = append(tokens, src[i:j])
+			i = j
+		}
+	}
+	return tokens
+}
+
+// sqlGroupIdents returns the identifiers in the first parenthesised group,
+// as in PRIMARY KEY (a, b).
+func sqlGroupIdents(tokens []string) []string {
+	for _, t := range tokens {
+		if strings.HasPrefix(t, "(") {
+			var idents []string
+			for _, part := range splitSQLList(strings.Trim(t, "()")) {
+				idents = append(idents, unquoteSQLIdent(part))
+			}
+			return idents
+		}
+	}
+	return nil
+}
+
+// unquoteSQLIdent drops quoting and any schema qualifier from an identifier.
+func unquoteSQLIdent(ident string) string {
+	if i := strings.LastIndexByte(ident, '.'); i >= 0 {
+		ident = ident[i+1:]
+	}
+	return strings.Trim(ident, "\"`[]")
+}
+
+func isSQLWord(token string, words ...string) bool {
+	for _, w := range words {
+		if strings.EqualFold(token, w) {
+			return true
+		}
+	}
+	return false
+}
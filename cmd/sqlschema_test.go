@@ -0,0 +1,218 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseSQLSchema(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want []sqlTable
+	}{
+		{
+			name: "quoted identifiers",
+			src: `CREATE TABLE "public"."users" (
+				"id" UUID PRIMARY KEY,
+				` + "`Full Name`" + ` VARCHAR(255) NOT NULL
+			);`,
+			want: []sqlTable{{
+				Name: "users",
+				Columns: []sqlColumn{
+					{Name: "id", Type: "uuid", NotNull: true, PrimaryKey: true},
+					{Name: "Full Name", Type: "varchar", Length: "255", NotNull: true},
+				},
+			}},
+		},
+		{
+			name: "default with a comma",
+			src: `CREATE TABLE tags (
+				name TEXT NOT NULL DEFAULT 'a,b',
+				note TEXT DEFAULT 'it''s; fine'
+			);`,
+			want: []sqlTable{{
+				Name: "tags",
+				Columns: []sqlColumn{
+					{Name: "name", Type: "text", NotNull: true},
+					{Name: "note", Type: "text"},
+				},
+			}},
+		},
+		{
+			name: "table level primary key",
+			src: `CREATE TABLE order_items (
+				order_id BIGINT,
+				line INT,
+				PRIMARY KEY (order_id, line)
+			);`,
+			want: []sqlTable{{
+				Name: "order_items",
+				Columns: []sqlColumn{
+					{Name: "order_id", Type: "bigint", NotNull: true, PrimaryKey: true},
+					{Name: "line", Type: "int", NotNull: true, PrimaryKey: true},
+				},
+				PrimaryKey: []string{"order_id", "line"},
+			}},
+		},
+		{
+			name: "constraint lines",
+			src: `CREATE TABLE IF NOT EXISTS accounts (
+				id BIGSERIAL,
+				owner_id BIGINT NOT NULL,
+				CONSTRAINT accounts_pkey PRIMARY KEY ("id"),
+				CONSTRAINT accounts_owner_fk FOREIGN KEY (owner_id) REFERENCES owners (id),
+				CONSTRAINT balance_check CHECK (owner_id > 0),
+				UNIQUE (owner_id)
+			);`,
+			want: []sqlTable{{
+				Name: "accounts",
+				Columns: []sqlColumn{
+					{Name: "id", Type: "bigserial", NotNull: true, PrimaryKey: true},
+					{Name: "owner_id", Type: "bigint", NotNull: true},
+				},
+				PrimaryKey: []string{"id"},
+			}},
+		},
+		{
+			name: "arrays",
+			src: `CREATE TABLE posts (
+				tags TEXT[] NOT NULL,
+				scores INTEGER []
+			);`,
+			want: []sqlTable{{
+				Name: "posts",
+				Columns: []sqlColumn{
+					{Name: "tags", Type: "text", Array: true, NotNull: true},
+					{Name: "scores", Type: "integer", Array: true},
+				},
+			}},
+		},
+		{
+			name: "mysql types and table options",
+			src: "CREATE TABLE `products` (\n" +
+				"  `id` INT UNSIGNED NOT NULL AUTO_INCREMENT,\n" +
+				"  `active` TINYINT(1) NOT NULL DEFAULT 1,\n" +
+				"  `price` DECIMAL(10,2) NOT NULL,\n" +
+				"  `name` VARCHAR(100) CHARACTER SET utf8mb4 COLLATE utf8mb4_bin,\n" +
+				"  PRIMARY KEY (`id`),\n" +
+				"  KEY `idx_name` (`name`)\n" +
+				") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;",
+			want: []sqlTable{{
+				Name: "products",
+				Columns: []sqlColumn{
+					{Name: "id", Type: "int", Unsigned: true, NotNull: true, PrimaryKey: true},
+					{Name: "active", Type: "tinyint", Length: "1", NotNull: true},
+					{Name: "price", Type: "decimal", Length: "10,2", NotNull: true},
+					{Name: "name", Type: "varchar", Length: "100"},
+				},
+				PrimaryKey: []string{"id"},
+			}},
+		},
+		{
+			name: "other statements and comments are skipped",
+			src: `-- users; of the app
+				CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
+				/* CREATE TABLE ignored (id int); */
+				CREATE TABLE t (id INT PRIMARY KEY);
+				CREATE INDEX t_id ON t (id);`,
+			want: []sqlTable{{
+				Name:    "t",
+				Columns: []sqlColumn{{Name: "id", Type: "int", NotNull: true, PrimaryKey: true}},
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseSQLSchema(tt.src)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseSQLSchema() =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSQLGoType(t *testing.T) {
+	tests := []struct {
+		col             sqlColumn
+		wantType        string
+		wantValueObject string
+	}{
+		{sqlColumn{Name: "active", Type: "tinyint", Length: "1"}, "bool", ""},
+		{sqlColumn{Name: "level", Type: "tinyint", Length: "4"}, "int8", ""},
+		{sqlColumn{Name: "id", Type: "int", Unsigned: true}, "uint32", ""},
+		{sqlColumn{Name: "id", Type: "bigint", Unsigned: true}, "uint64", ""},
+		{sqlColumn{Name: "tags", Type: "text", Array: true}, "[]string", ""},
+		{sqlColumn{Name: "scores", Type: "integer", Array: true}, "[]int32", ""},
+		{sqlColumn{Name: "price", Type: "decimal", Length: "10,2"}, "string", "Decimal"},
+		{sqlColumn{Name: "contact_email", Type: "varchar"}, "string", "Email"},
+		{sqlColumn{Name: "created_at", Type: "timestamp with time zone"}, "time.Time", ""},
+		{sqlColumn{Name: "data", Type: "jsonb"}, "json.RawMessage", ""},
+		{sqlColumn{Name: "ratio", Type: "double precision"}, "float64", ""},
+		{sqlColumn{Name: "addr", Type: "inet"}, "string", ""},
+	}
+	for _, tt := range tests {
+		goType, valueObject := sqlGoType(tt.col)
+		if goType != tt.wantType || valueObject != tt.wantValueObject {
+			t.Errorf("sqlGoType(%+v) = %q, %q, want %q, %q", tt.col, goType, valueObject, tt.wantType, tt.wantValueObject)
+		}
+	}
+}
+
+func TestDomainSpecFromTableFields(t *testing.T) {
+	tests := []struct {
+		col  sqlColumn
+		want domainField
+	}{
+		{
+			sqlColumn{Name: "price", Type: "numeric", Length: "10,2", NotNull: true},
+			domainField{Name: "Price", Column: "price", Type: "Decimal", StorageType: "string", ValueObject: "Decimal"},
+		},
+		{
+			sqlColumn{Name: "discount", Type: "numeric", Length: "10,2"},
+			domainField{Name: "Discount", Column: "discount", Type: "*Decimal", StorageType: "*string", ValueObject: "Decimal"},
+		},
+		{
+			sqlColumn{Name: "note", Type: "text"},
+			domainField{Name: "Note", Column: "note", Type: "*string", StorageType: "*string"},
+		},
+		{
+			sqlColumn{Name: "tags", Type: "text", Array: true},
+			domainField{Name: "Tags", Column: "tags", Type: "[]string", StorageType: "[]string"},
+		},
+		{
+			sqlColumn{Name: "data", Type: "jsonb"},
+			domainField{Name: "Data", Column: "data", Type: "json.RawMessage", StorageType: "json.RawMessage"},
+		},
+	}
+	for _, tt := range tests {
+		table := sqlTable{Name: "products", Columns: []sqlColumn{{Name: "id", Type: "bigint", PrimaryKey: true, NotNull: true}, tt.col}, PrimaryKey: []string{"id"}}
+		spec := domainSpecFromTable(mustNames("product"), table)
+		if len(spec.Fields) != 1 || spec.Fields[0] != tt.want {
+			t.Errorf("domainSpecFromTable(%+v).Fields = %+v, want [%+v]", tt.col, spec.Fields, tt.want)
+		}
+	}
+}
+
+func TestDomainFieldConversions(t *testing.T) {
+	tests := []struct {
+		field      domainField
+		wantModel  string
+		wantEntity string
+	}{
+		{domainField{Name: "Name", Type: "string", StorageType: "string"}, "e.Name", "m.Name"},
+		{domainField{Name: "Price", Type: "Decimal", StorageType: "string", ValueObject: "Decimal"}, "string(e.Price)", "domain.Decimal(m.Price)"},
+		{domainField{Name: "Discount", Type: "*Decimal", StorageType: "*string", ValueObject: "Decimal"}, "(*string)(e.Discount)", "(*domain.Decimal)(m.Discount)"},
+	}
+	for _, tt := range tests {
+		if got := tt.field.ModelValue(); got != tt.wantModel {
+			t.Errorf("%s.ModelValue() = %q, want %q", tt.field.Name, got, tt.wantModel)
+		}
+		if got := tt.field.EntityValue(); got != tt.wantEntity {
+			t.Errorf("%s.EntityValue() = %q, want %q", tt.field.Name, got, tt.wantEntity)
+		}
+	}
+}
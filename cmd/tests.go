@@ -2,8 +2,10 @@ package cmd
 
 import (
 	"fmt"
+	"go/ast"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/spf13/cobra"
 )
@@ -22,22 +24,20 @@ var testsCmd = &cobra.Command{
 - Repositories`,
 	Args: cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
-		componentName := args[0]
-		createTestStructure(componentName)
+		names := mustNames(args[0])
+		createTestStructure(names)
 
 		if withMocks {
-			generateMocks(componentName)
+			generateMocks(names)
 		}
 	},
 }
 
-func generateMocks(componentName string) {
+func generateMocks(names Names) {
 	// Generate mock files using mockery
-	mockTemplate := `package {{.Component}}_test
+	mockTemplate := `package mocks
 
 import (
-	"testing"
-
 	"github.com/stretchr/testify/mock"
 )
 
@@ -47,30 +47,42 @@ type Mock{{.Component}} struct {
 
 // Add mock methods here
 `
-	generateFile(filepath.Join("internal", "mocks", componentName+"_mock.go"), mockTemplate, map[string]string{
-		"Component": componentName,
+	mocksPath := filepath.Join("internal", "mocks")
+	if err := os.MkdirAll(mocksPath, 0755); err != nil {
+		fmt.Printf("Error creating mocks directory: %v\n", err)
+		os.Exit(1)
+	}
+	generateFile(filepath.Join(mocksPath, names.Snake+"_mock.go"), mockTemplate, map[string]string{
+		"Component": names.Pascal,
 	})
 
-	fmt.Printf("Generated mocks for %s\n", componentName)
+	fmt.Printf("Generated mocks for %s\n", names.Pascal)
 }
 
-func createTestStructure(componentName string) {
+func createTestStructure(names Names) {
 	// Determine test file path based on component type
 	var testPath string
 	switch {
-	case isDomain(componentName):
-		testPath = filepath.Join("internal/core", componentName)
-	case isUsecase(componentName):
-		testPath = filepath.Join("internal/usecase", componentName)
-	case isHandler(componentName):
-		testPath = filepath.Join("internal/interfaces", componentName)
+	case isDomain(names.Package):
+		testPath = filepath.Join(layerPath(boundedContext, "core"), names.Package)
+	case isUsecase(names.Package):
+		testPath = filepath.Join(layerPath(boundedContext, "usecase"), names.Package)
+	case isHandler(names.Package):
+		testPath = filepath.Join(layerPath(boundedContext, "interfaces"), names.Package)
 	default:
-		fmt.Printf("Unknown component type: %s\n", componentName)
+		fmt.Printf("Unknown component type: %s\n", names.Raw)
 		os.Exit(1)
 	}
 
 	// Generate test file
-	testTemplate := `package {{.Component}}
+	generateTestStub(filepath.Join(testPath, names.Snake+"_test.go"), names.Package, names.Pascal)
+
+	fmt.Printf("Successfully created test stubs for %s in %s\n", names.Pascal, testPath)
+}
+
+// generateTestStub writes an empty test for component in package pkg.
+func generateTestStub(path, pkg, component string) {
+	testTemplate := `package {{.Package}}
 
 import (
 	"testing"
@@ -80,32 +92,102 @@ func Test{{.Component}}(t *testing.T) {
 	// Add test cases here
 }
 `
-	generateFile(filepath.Join(testPath, componentName+"_test.go"), testTemplate, map[string]string{
-		"Component": componentName,
+	generateFile(path, testTemplate, map[string]string{
+		"Package":   pkg,
+		"Component": component,
 	})
+}
 
-	fmt.Printf("Successfully created test stubs for %s in %s\n", componentName, testPath)
+// renderFake returns a fake implementing the interface name from pkg, as seen
+// from a package importing pkg under qualifier. Each method calls an optional
+// func field, e.g. saveFunc for Save, and otherwise returns zero values.
+func renderFake(pkg *sourcePackage, name, qualifier string) string {
+	methods, err := pkg.interfaceMethods(name)
+	if err != nil {
+		fmt.Printf("Error reading %s: %v\n", name, err)
+		os.Exit(1)
+	}
+
+	fake := "fake" + name
+	var fields, impls strings.Builder
+	for _, m := range methods {
+		var params, args []string
+		for i, field := range m.Type.Params.List {
+			typ := pkg.qualify(field.Type, qualifier)
+			_, variadic := field.Type.(*ast.Ellipsis)
+			names := field.Names
+			if len(names) == 0 {
+				names = []*ast.Ident{ast.NewIdent("_")}
+			}
+			for j, n := range names {
+				arg := n.Name
+				if arg == "_" {
+					arg = fmt.Sprintf("p%d", i+j)
+				}
+				params = append(params, arg+" "+typ)
+				if variadic {
+					arg += "..."
+				}
+				args = append(args, arg)
+			}
+		}
+
+		var results, resultTypes []string
+		if m.Type.Results != nil {
+			for _, field := range m.Type.Results.List {
+				typ := pkg.qualify(field.Type, qualifier)
+				for n := max(len(field.Names), 1); n > 0; n-- {
+					results = append(results, fmt.Sprintf("r%d %s", len(results), typ))
+					resultTypes = append(resultTypes, typ)
+				}
+			}
+		}
+
+		fn := toCamel(m.Name) + "Func"
+		signature := "(" + strings.Join(params, ", ") + ")"
+		call := fmt.Sprintf("f.%s(%s)", fn, strings.Join(args, ", "))
+		switch len(resultTypes) {
+		case 0:
+			fmt.Fprintf(&fields, "\t%s func%s\n", fn, signature)
+			fmt.Fprintf(&impls, "\nfunc (f *%s) %s%s {\n\tif f.%s != nil {\n\t\t%s\n\t}\n}\n", fake, m.Name, signature, fn, call)
+			continue
+		case 1:
+			fmt.Fprintf(&fields, "\t%s func%s %s\n", fn, signature, resultTypes[0])
+		default:
+			fmt.Fprintf(&fields, "\t%s func%s (%s)\n", fn, signature, strings.Join(resultTypes, ", "))
+		}
+		fmt.Fprintf(&impls, "\nfunc (f *%s) %s%s (%s) {\n\tif f.%s != nil {\n\t\treturn %s\n\t}\n\treturn\n}\n",
+			fake, m.Name, signature, strings.Join(results, ", "), fn, call)
+	}
+
+	body := "struct{}"
+	if fields.Len() > 0 {
+		body = "struct {\n" + fields.String() + "}"
+	}
+	return fmt.Sprintf("// %s implements %s.%s for tests. Set a func field to\n// control a method; unset methods return zero values.\ntype %s %s\n%s",
+		fake, qualifier, name, fake, body, impls.String())
 }
 
 func isDomain(name string) bool {
 	// Check if component is a domain
-	_, err := os.Stat(filepath.Join("internal/core", name))
+	_, err := os.Stat(filepath.Join(layerPath(boundedContext, "core"), name))
 	return err == nil
 }
 
 func isUsecase(name string) bool {
 	// Check if component is a usecase
-	_, err := os.Stat(filepath.Join("internal/usecase", name))
+	_, err := os.Stat(filepath.Join(layerPath(boundedContext, "usecase"), name))
 	return err == nil
 }
 
 func isHandler(name string) bool {
 	// Check if component is a handler
-	_, err := os.Stat(filepath.Join("internal/interfaces", name))
+	_, err := os.Stat(filepath.Join(layerPath(boundedContext, "interfaces"), name))
 	return err == nil
 }
 
 func InitGenTests(rootCmd *cobra.Command) {
 	rootCmd.AddCommand(testsCmd)
+	addContextFlag(testsCmd)
 	testsCmd.Flags().BoolVarP(&withMocks, "with-mocks", "m", false, "Generate mock implementations")
 }
@@ -0,0 +1,220 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// sqlTxPath is the directory of the database/sql unit of work, which also
+// lets repositories find the transaction of the unit of work they run in.
+func sqlTxPath() string {
+	return filepath.Join(layerPath(boundedContext, "adapters/persistence"), "sqltx")
+}
+
+// memTxPath is the directory of the in-memory unit of work.
+func memTxPath() string {
+	return filepath.Join(layerPath(boundedContext, "adapters/persistence"), "memtx")
+}
+
+// ensureUnitOfWork writes the UnitOfWork port and its database/sql and
+// in-memory implementations, skipping files that exist.
+func ensureUnitOfWork() {
+	ensurePort("UnitOfWork")
+	data := map[string]string{"Ports": importPath(portsPath())}
+	writeIfMissing(filepath.Join(sqlTxPath(), "sqltx.go"), sqlTxTemplate, data)
+	writeIfMissing(filepath.Join(memTxPath(), "memtx.go"), memTxTemplate, data)
+	writeIfMissing(filepath.Join(memTxPath(), "memtx_test.go"), memTxTestTemplate, data)
+}
+
+// writeIfMissing renders tmpl into path unless the file exists.
+func writeIfMissing(path, tmpl string, data interface{}) {
+	if _, err := os.Stat(path); err == nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		fmt.Printf("Error creating directory %s: %v\n", filepath.Dir(path), err)
+		os.Exit(1)
+	}
+	generateFile(path, tmpl, data)
+}
+
+const sqlTxTemplate = `// Package sqltx runs units of work in database/sql transactions. The
+// transaction travels in the context, so repositories called with that
+// context take part in it.
+package sqltx
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"{{.Ports}}"
+)
+
+// DBTX is what repositories need from a *sql.DB or *sql.Tx.
+type DBTX interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+type txKey struct{}
+
+// UnitOfWork implements ports.UnitOfWork with database/sql transactions.
+type UnitOfWork struct {
+	db *sql.DB
+}
+
+var _ ports.UnitOfWork = (*UnitOfWork)(nil)
+
+func NewUnitOfWork(db *sql.DB) *UnitOfWork {
+	return &UnitOfWork{db: db}
+}
+
+// Do runs fn in a transaction that is committed when fn returns nil and
+// rolled back when it fails or panics. Nested calls join the outer
+// transaction.
+func (u *UnitOfWork) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := FromContext(ctx); ok {
+		return fn(ctx)
+	}
+
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+	}()
+	if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		return errors.Join(err, tx.Rollback())
+	}
+	return tx.Commit()
+}
+
+// FromContext returns the transaction of the unit of work running ctx.
+func FromContext(ctx context.Context) (*sql.Tx, bool) {
+	tx, ok := ctx.Value(txKey{}).(*sql.Tx)
+	return tx, ok
+}
+
+// Conn returns the transaction of the unit of work running ctx, or db
+// outside of one.
+func Conn(ctx context.Context, db DBTX) DBTX {
+	if tx, ok := FromContext(ctx); ok {
+		return tx
+	}
+	return db
+}
+`
+
+const memTxTemplate = `// Package memtx runs units of work against in-memory repositories, for
+// tests and for running without a database.
+package memtx
+
+import (
+	"context"
+	"sync"
+
+	"{{.Ports}}"
+)
+
+// Participant is an in-memory store a unit of work can roll back.
+type Participant interface {
+	// Snapshot returns a function restoring the store to its current state.
+	Snapshot() (restore func())
+}
+
+type activeKey struct{}
+
+// UnitOfWork implements ports.UnitOfWork by running units of work one at a
+// time and restoring every participant when one fails. Writes made outside
+// a unit of work are not isolated from it.
+type UnitOfWork struct {
+	mu           sync.Mutex
+	participants []Participant
+}
+
+var _ ports.UnitOfWork = (*UnitOfWork)(nil)
+
+func NewUnitOfWork(participants ...Participant) *UnitOfWork {
+	return &UnitOfWork{participants: participants}
+}
+
+// Do runs fn and rolls back the participants when fn fails or panics.
+// Nested calls join the outer unit of work.
+func (u *UnitOfWork) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	if ctx.Value(activeKey{}) == u {
+		return fn(ctx)
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	restores := make([]func(), len(u.participants))
+	for i, p := range u.participants {
+		restores[i] = p.Snapshot()
+	}
+	rollback := func() {
+		for _, restore := range restores {
+			restore()
+		}
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			rollback()
+			panic(p)
+		}
+	}()
+
+	if err := fn(context.WithValue(ctx, activeKey{}, u)); err != nil {
+		rollback()
+		return err
+	}
+	return nil
+}
+`
+
+const memTxTestTemplate = `package memtx
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type counter struct{ n int }
+
+func (c *counter) Snapshot() func() {
+	n := c.n
+	return func() { c.n = n }
+}
+
+func TestUnitOfWork(t *testing.T) {
+	c := &counter{}
+	uow := NewUnitOfWork(c)
+
+	err := uow.Do(context.Background(), func(ctx context.Context) error {
+		c.n++
+		return uow.Do(ctx, func(ctx context.Context) error {
+			c.n++
+			return nil
+		})
+	})
+	if err != nil || c.n != 2 {
+		t.Fatalf("committed unit of work: n = %d, err = %v; want 2, nil", c.n, err)
+	}
+
+	failed := errors.New("failed")
+	err = uow.Do(context.Background(), func(ctx context.Context) error {
+		c.n = 100
+		return failed
+	})
+	if !errors.Is(err, failed) || c.n != 2 {
+		t.Fatalf("failed unit of work: n = %d, err = %v; want 2, %v", c.n, err, failed)
+	}
+}
+`
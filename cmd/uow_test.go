@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEnsureUnitOfWork(t *testing.T) {
+	inModule(t, "app")
+	ensureUnitOfWork()
+
+	// The generated packages compile against the port
+	fset := token.NewFileSet()
+	imp := importer.ForCompiler(fset, "source", nil)
+	for _, dir := range []string{sqlTxPath(), memTxPath()} {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var files []*ast.File
+		for _, entry := range entries {
+			file, err := parser.ParseFile(fset, filepath.Join(dir, entry.Name()), nil, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			files = append(files, file)
+		}
+		conf := types.Config{Importer: imp}
+		if _, err := conf.Check(importPath(dir), fset, files, nil); err != nil {
+			t.Errorf("%s does not compile: %v", dir, err)
+		}
+	}
+
+	// Files edited since are kept
+	path := filepath.Join(memTxPath(), "memtx.go")
+	edited := []byte("package memtx\n\n// Edited by hand\n")
+	if err := os.WriteFile(path, edited, 0644); err != nil {
+		t.Fatal(err)
+	}
+	ensureUnitOfWork()
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(edited) {
+		t.Errorf("ensureUnitOfWork() overwrote %s:\n%s", path, got)
+	}
+}
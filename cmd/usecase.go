@@ -2,29 +2,170 @@ package cmd
 
 import (
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/spf13/cobra"
 )
 
+var (
+	usecaseDomain string
+	usecaseDeps   string
+	usecaseKind   string
+
+	usecaseTransactional bool
+	usecaseDecorate      string
+)
+
 var usecaseCmd = &cobra.Command{
 	Use:   "usecase [name]",
 	Short: "Generate a new use case",
 	Long: `Creates a new use case with:
 - Service interface
 - Service implementation
-- Request/Response models`,
+- Request/Response models, with Request.Validate checking validate tags
+
+Execute takes a context.Context and validates the request before running
+the business logic. Invalid requests fail with a *validation.Error from
+internal/usecase/validation listing every invalid field, ready for handlers
+to render.
+
+Use --domain User --deps UserRepository,Clock,EventBus to inject
+dependencies through the constructor. Interfaces declared in the domain are
+imported from it, others come from the ports package, which gets Clock and
+EventBus or an empty interface for unknown names. A test with fakes for every
+dependency is generated alongside.
+
+Use --kind command or --kind query for CQRS: the message, its result and a
+handler go into internal/usecase/commands or internal/usecase/queries, and
+internal/usecase/bus gets a typed mediator that routes messages to their
+handlers through middleware. Query handlers can depend on read models
+instead of repositories, e.g. --deps UserReadModel creates a UserReadModel
+port returning UserView projections.
+
+Use --transactional to run the logic in a unit of work: a UnitOfWork port is
+injected and Execute wraps the logic in unitOfWork.Do, so repositories
+called with its context share one transaction. The ports package gets the
+port, with database/sql and in-memory implementations under
+adapters/persistence/sqltx and adapters/persistence/memtx.
+
+Use --decorate logging,metrics,tracing,retry to generate decorators around
+the service, applied by passing them to NewService as options. See the
+decorate command, which adds them to existing use cases.`,
 	Args: cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
-		usecaseName := args[0]
-		createUsecaseStructure(usecaseName)
+		if usecaseKind != "" && usecaseKind != "command" && usecaseKind != "query" {
+			fmt.Println("Unsupported kind. Use --kind [command|query]")
+			os.Exit(1)
+		}
+		if usecaseKind != "" && usecaseDecorate != "" {
+			fmt.Println("--decorate applies to services; wrap command and query handlers with bus middleware")
+			os.Exit(1)
+		}
+		decorateWith := splitList(usecaseDecorate)
+		validateDecorators(decorateWith)
+		names := mustNames(args[0])
+		depNames := splitList(usecaseDeps)
+		if usecaseTransactional && !containsString(depNames, "UnitOfWork") {
+			depNames = append(depNames, "UnitOfWork")
+		}
+		if containsString(depNames, "UnitOfWork") {
+			// With its implementations, for bootstrap.New to wire
+			ensureUnitOfWork()
+		}
+		deps := resolveUsecaseDeps(usecaseDomain, depNames)
+		if usecaseKind != "" {
+			createCQRSHandler(names, usecaseKind, deps)
+			return
+		}
+		createUsecaseStructure(names, deps)
+		if len(decorateWith) > 0 {
+			decorateUsecase(names, decorateWith)
+		}
 	},
 }
 
-func createUsecaseStructure(usecaseName string) {
+// usecaseDep is a constructor-injected dependency of a usecase.
+type usecaseDep struct {
+	Name  string
+	Field string
+	Type  string
+	pkg   *sourcePackage
+}
+
+// Fake is the expression building the dep's fake in generated tests. The
+// UnitOfWork fake runs the unit of work in place so the logic is exercised.
+func (d usecaseDep) Fake() string {
+	if d.Name == "UnitOfWork" {
+		return "&fakeUnitOfWork{doFunc: func(ctx context.Context, fn func(ctx context.Context) error) error { return fn(ctx) }}"
+	}
+	return "&fake" + d.Name + "{}"
+}
+
+// resolveUsecaseDeps finds each dependency in the domain package, in another
+// package of the core layer or else in the ports package, creating ports
+// that nothing declares.
+func resolveUsecaseDeps(domain string, deps []string) []usecaseDep {
+	var domainPkg *sourcePackage
+	if domain != "" {
+		_, domainPkg = loadDomainSpec(mustNames(domain))
+	}
+
+	var resolved []usecaseDep
+	for _, name := range deps {
+		depNames := mustNames(name)
+		dep := usecaseDep{Name: depNames.Pascal, Field: depNames.Camel}
+		if domainPkg != nil && domainPkg.isInterface(dep.Name) {
+			dep.pkg = domainPkg
+		} else if pkg := findCoreInterface(dep.Name); pkg != nil {
+			dep.pkg = pkg
+		} else {
+			ensurePort(dep.Name)
+			portsPkg, err := parseSourcePackage(portsPath())
+			if err != nil {
+				fmt.Printf("Error reading ports: %v\n", err)
+				os.Exit(1)
+			}
+			if !portsPkg.isInterface(dep.Name) {
+				fmt.Printf("%s in %s is not an interface\n", dep.Name, portsPkg.Dir)
+				os.Exit(1)
+			}
+			dep.pkg = portsPkg
+		}
+		dep.Type = dep.pkg.Name + "." + dep.Name
+		resolved = append(resolved, dep)
+	}
+	return resolved
+}
+
+// findCoreInterface returns the package of the core layer declaring the
+// interface name, or nil. A repository is looked for in the domain it is
+// named after before every domain is searched.
+func findCoreInterface(name string) *sourcePackage {
+	core := layerPath(boundedContext, "core")
+	var dirs []string
+	if entity := strings.TrimSuffix(name, "Repository"); entity != name && entity != "" {
+		dirs = append(dirs, filepath.Join(core, toPackage(entity)))
+	}
+	filepath.WalkDir(core, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && d.IsDir() {
+			dirs = append(dirs, path)
+		}
+		return nil
+	})
+	for _, dir := range dirs {
+		if pkg, err := parseSourcePackage(dir); err == nil && pkg.isInterface(name) {
+			return pkg
+		}
+	}
+	return nil
+}
+
+func createUsecaseStructure(names Names, deps []usecaseDep) {
 	// Create usecase directory
-	usecasePath := filepath.Join("internal/usecase", usecaseName)
+	usecasePath := filepath.Join(layerPath(boundedContext, "usecase"), names.Package)
 	if err := os.MkdirAll(usecasePath, 0755); err != nil {
 		fmt.Printf("Error creating usecase directory: %v\n", err)
 		os.Exit(1)
@@ -33,54 +174,190 @@ func createUsecaseStructure(usecaseName string) {
 	// Generate service interface
 	serviceTemplate := `package {{.Usecase}}
 
+import "context"
+
 type {{.Service}} interface {
-	Execute(req *Request) (*Response, error)
+	Execute(ctx context.Context, req *Request) (*Response, error)
 }
 `
 	generateFile(filepath.Join(usecasePath, "service.go"), serviceTemplate, map[string]string{
-		"Usecase": usecaseName,
-		"Service": usecaseName + "Service",
+		"Usecase": names.Package,
+		"Service": names.Pascal + "Service",
 	})
 
 	// Generate service implementation
 	implTemplate := `package {{.Usecase}}
 
+{{.Imports}}
 type service struct {
+{{- range .Deps}}
+	{{.Field}} {{.Type}}
+{{- else}}
 	// Add dependencies here
+{{- end}}
 }
 
-func NewService() {{.Service}} {
-	return &service{}
+func NewService({{range $i, $d := .Deps}}{{if $i}}, {{end}}{{$d.Field}} {{$d.Type}}{{end}}) {{.Service}} {
+	return &service{
+{{- range .Deps}}
+		{{.Field}}: {{.Field}},
+{{- end}}
+	}
 }
 
-func (s *service) Execute(req *Request) (*Response, error) {
+func (s *service) Execute(ctx context.Context, req *Request) (*Response, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+{{if .Transactional}}
+	resp := &Response{}
+	err := s.unitOfWork.Do(ctx, func(ctx context.Context) error {
+		// Implement use case logic here; repositories called with this ctx
+		// share the transaction, which is rolled back if an error is returned
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+{{- else}}
 	// Implement use case logic here
 	return &Response{}, nil
+{{- end}}
 }
 `
-	generateFile(filepath.Join(usecasePath, "service_impl.go"), implTemplate, map[string]string{
-		"Usecase": usecaseName,
-		"Service": usecaseName + "Service",
+	imports := []string{"context"}
+	for _, dep := range deps {
+		imports = append(imports, importPath(dep.pkg.Dir))
+	}
+	generateFile(filepath.Join(usecasePath, "service_impl.go"), implTemplate, map[string]interface{}{
+		"Usecase": names.Package,
+		"Service": names.Pascal + "Service",
+		"Deps":    deps,
+		"Imports": renderImports(imports),
+
+		"Transactional": usecaseTransactional,
 	})
 
 	// Generate request/response models
+	ensureValidationPackage()
 	modelsTemplate := `package {{.Usecase}}
 
+import "{{.Validation}}"
+
+// Request fields are checked by Validate against their validate tags, e.g.
+//
+//	Email string ` + "`json:\"email\" validate:\"required,email\"`" + `
 type Request struct {
 	// Add request fields here
 }
 
+// Validate returns a *validation.Error listing every invalid field.
+func (r *Request) Validate() error {
+	return validation.Struct(r)
+}
+
 type Response struct {
 	// Add response fields here
 }
 `
 	generateFile(filepath.Join(usecasePath, "models.go"), modelsTemplate, map[string]string{
-		"Usecase": usecaseName,
+		"Usecase":    names.Package,
+		"Validation": importPath(validationPath()),
+	})
+
+	if len(deps) > 0 {
+		generateUsecaseTest(usecasePath, names, deps)
+	}
+
+	fmt.Printf("Successfully created usecase %s in %s\n", names.Pascal, usecasePath)
+}
+
+// generateUsecaseTest writes a test constructing the service with fakes for
+// every dependency, and the fakes themselves.
+func generateUsecaseTest(usecasePath string, names Names, deps []usecaseDep) {
+	testTemplate := `package {{.Usecase}}
+
+import (
+	"context"
+	"testing"
+)
+
+func Test{{.Service}}(t *testing.T) {
+	svc := NewService({{range $i, $d := .Deps}}{{if $i}}, {{end}}{{$d.Fake}}{{end}})
+
+	resp, err := svc.Execute(context.Background(), &Request{})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if resp == nil {
+		t.Fatal("Execute() returned a nil response")
+	}
+}
+`
+	generateFile(filepath.Join(usecasePath, "service_test.go"), testTemplate, map[string]interface{}{
+		"Usecase": names.Package,
+		"Service": names.Pascal + "Service",
+		"Deps":    deps,
 	})
 
-	fmt.Printf("Successfully created usecase %s in %s\n", usecaseName, usecasePath)
+	generateFakes(usecasePath, names.Package, deps)
+}
+
+// generateFakes writes fakes_test.go with a fake for every dependency.
+func generateFakes(usecasePath, pkg string, deps []usecaseDep) {
+	writeFakes(filepath.Join(usecasePath, "fakes_test.go"), pkg, deps)
+}
+
+// ensureFakes writes each dependency's fake into its own file unless the
+// file exists, so handlers sharing a package share their fakes.
+func ensureFakes(dir, pkg string, deps []usecaseDep) {
+	for _, dep := range deps {
+		path := filepath.Join(dir, "fake_"+toSnake(dep.Name)+"_test.go")
+		if _, err := os.Stat(path); err == nil {
+			continue
+		}
+		writeFakes(path, pkg, []usecaseDep{dep})
+	}
+}
+
+// writeFakes writes the fakes of deps into the test file path.
+func writeFakes(path, pkg string, deps []usecaseDep) {
+	// The fakes may need any package the interfaces refer to
+	imports := map[string]bool{}
+	var fakes []string
+	for _, dep := range deps {
+		imports[importPath(dep.pkg.Dir)] = true
+		for _, path := range dep.pkg.imports {
+			imports[path] = true
+		}
+		fakes = append(fakes, renderFake(dep.pkg, dep.Name, dep.pkg.Name))
+	}
+	var importList []string
+	for path := range imports {
+		importList = append(importList, path)
+	}
+
+	fakesTemplate := `package {{.Usecase}}
+
+{{.Imports}}
+{{range .Fakes}}
+{{.}}
+{{end}}`
+	generateFile(path, fakesTemplate, map[string]interface{}{
+		"Usecase": pkg,
+		"Imports": renderImports(importList),
+		"Fakes":   fakes,
+	})
+	removeUnusedImports(path)
 }
 
 func InitGenUsecase(rootCmd *cobra.Command) {
 	rootCmd.AddCommand(usecaseCmd)
+	usecaseCmd.Flags().StringVar(&usecaseDomain, "domain", "", "Domain whose interfaces the usecase depends on")
+	usecaseCmd.Flags().StringVar(&usecaseDeps, "deps", "", "Dependencies to inject, e.g. UserRepository,Clock,EventBus")
+	usecaseCmd.Flags().StringVar(&usecaseKind, "kind", "", "Generate a CQRS handler instead of a service (command|query)")
+	usecaseCmd.Flags().StringVar(&usecaseDecorate, "decorate", "", "Decorators to wrap the service in (logging,metrics,tracing,retry)")
+	usecaseCmd.Flags().BoolVar(&usecaseTransactional, "transactional", false, "Run the use case logic in a UnitOfWork transaction")
+	addContextFlag(usecaseCmd)
 }
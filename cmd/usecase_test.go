@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeCore writes the domain packages of a project with an order and a
+// customer domain and a payment port declared in billing.
+func writeCore(t *testing.T) {
+	t.Helper()
+	files := map[string]string{
+		"order/entity.go": `package order
+
+type Order struct {
+	ID string
+}
+
+type OrderRepository interface {
+	Save(o *Order) error
+}
+
+// CustomerRepository is not an interface, so it is not a match.
+type CustomerRepository struct{}
+`,
+		"customer/repository.go": `package customer
+
+type Customer struct {
+	ID string
+}
+
+type CustomerRepository interface {
+	FindByID(id string) (*Customer, error)
+}
+`,
+		"billing/gateway.go": `package billing
+
+type Money string
+
+type PaymentGateway interface {
+	Charge(amount Money) error
+}
+`,
+	}
+	for name, src := range files {
+		path := filepath.Join("internal", "core", name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestFindCoreInterface(t *testing.T) {
+	inModule(t, "app")
+	writeCore(t)
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"CustomerRepository", "customer"},
+		{"OrderRepository", "order"},
+		{"PaymentGateway", "billing"},
+		{"ShippingRepository", ""},
+		{"Money", ""},
+	}
+	for _, tt := range tests {
+		got := ""
+		if pkg := findCoreInterface(tt.name); pkg != nil {
+			got = pkg.Name
+		}
+		if got != tt.want {
+			t.Errorf("findCoreInterface(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestResolveUsecaseDeps(t *testing.T) {
+	inModule(t, "app")
+	writeCore(t)
+	deps := resolveUsecaseDeps("Order", []string{"OrderRepository", "CustomerRepository", "PaymentGateway"})
+	var got []string
+	for _, d := range deps {
+		got = append(got, d.Field+" "+d.Type)
+	}
+	want := []string{"orderRepository order.OrderRepository", "customerRepository customer.CustomerRepository", "paymentGateway billing.PaymentGateway"}
+	if len(got) != len(want) {
+		t.Fatalf("resolveUsecaseDeps() = %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("resolveUsecaseDeps()[%d] = %q, want %q", i, got[i], want[i])
+		}
+	}
+	if _, err := os.Stat(portsPath()); !os.IsNotExist(err) {
+		t.Errorf("ports were created for dependencies declared in the core layer")
+	}
+}
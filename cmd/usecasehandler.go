@@ -0,0 +1,585 @@
+package cmd
+
+import (
+	"fmt"
+	"go/importer"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+)
+
+var (
+	handlerUsecase string
+	handlerMethod  string
+	handlerRoute   string
+	handlerPB      string
+)
+
+// usecaseEndpoint is a handler bound to the Execute method of a usecase.
+type usecaseEndpoint struct {
+	Package string
+	Handler string
+	Service string
+	Usecase mapperType
+	Router  httpRouter
+	Method  string
+	Path    string
+	// Status is the status code of a successful response
+	Status     string
+	Decode     bool
+	Bindings   []string
+	Sample     string
+	Validation string
+	imports    map[string]bool
+}
+
+func (e *usecaseEndpoint) qualifier(p *types.Package) string {
+	e.imports[p.Path()] = true
+	return p.Name()
+}
+
+// Imports renders the import block of the handler.
+func (e *usecaseEndpoint) Imports() string {
+	var paths []string
+	for p := range e.imports {
+		paths = append(paths, p)
+	}
+	return renderImports(paths)
+}
+
+// TestImports renders the import block of the handler's test.
+func (e *usecaseEndpoint) TestImports() string {
+	paths := []string{"context", "errors", "net/http", "net/http/httptest", "strings", "testing", e.UsecaseImport(), e.Validation}
+	return renderImports(append(paths, e.Router.Imports...))
+}
+
+// UsecaseImport is the import path of the usecase package.
+func (e *usecaseEndpoint) UsecaseImport() string {
+	return e.Usecase.pkg.Path()
+}
+
+// UsecasePkg is the name the handler refers to the usecase package by.
+func (e *usecaseEndpoint) UsecasePkg() string {
+	return e.qualifier(e.Usecase.pkg)
+}
+
+// loadUsecase type-checks the package of the usecase named by names and
+// returns its Request. The handler is bound to the usecase's Service.
+func loadUsecase(imp types.Importer, names Names) (mapperType, string) {
+	dir := filepath.Join(layerPath(boundedContext, "usecase"), names.Package)
+	if _, err := os.Stat(dir); err != nil {
+		fmt.Printf("Usecase %s not found in %s. Generate it with: go-ddd-skel usecase %s\n", names.Pascal, dir, names.Pascal)
+		os.Exit(1)
+	}
+	service := names.Pascal + "Service"
+	if pkg, err := parseSourcePackage(dir); err != nil || !pkg.isInterface(service) {
+		fmt.Printf("%s does not declare the interface %s\n", dir, service)
+		os.Exit(1)
+	}
+	return loadMapperType(imp, dir+".Request"), service
+}
+
+// generateUsecaseHTTPHandler writes a handler for method and path that
+// binds the request to the usecase's Request, executes it and encodes the
+// Response, with a test of its status codes.
+func generateUsecaseHTTPHandler(dir string, names, usecase Names, method, path string) {
+	router := projectRouter()
+	req, service := loadUsecase(importer.ForCompiler(token.NewFileSet(), "source", nil), usecase)
+	ensureValidationPackage()
+
+	e := &usecaseEndpoint{
+		Package:    names.Package,
+		Handler:    usecase.Pascal + "HTTPHandler",
+		Service:    service,
+		Usecase:    req,
+		Router:     router,
+		Method:     method,
+		Path:       path,
+		Status:     "http.StatusOK",
+		Decode:     method == "POST" || method == "PUT" || method == "PATCH",
+		Validation: importPath(validationPath()),
+		imports:    map[string]bool{"context": true},
+	}
+	switch method {
+	case "POST":
+		e.Status = "http.StatusCreated"
+	case "DELETE":
+		e.Status = "http.StatusNoContent"
+	}
+	for _, imp := range router.Imports {
+		e.imports[imp] = true
+	}
+	e.imports[e.Validation] = true
+	e.imports[req.pkg.Path()] = true
+
+	// Path parameters are bound to the field of the same name and, without
+	// a body, the remaining fields to query parameters
+	bound := map[string]bool{}
+	sample := strings.Split(path, "/")
+	for i, segment := range sample {
+		if !strings.HasPrefix(segment, "{") || !strings.HasSuffix(segment, "}") {
+			continue
+		}
+		param := strings.TrimSuffix(strings.TrimPrefix(segment, "{"), "}")
+		field, ok := requestField(req, param)
+		if !ok {
+			fmt.Printf("Path parameter {%s} matches no field of %s.Request\n", param, usecase.Package)
+			os.Exit(1)
+		}
+		bound[field.Name()] = true
+		e.Bindings = append(e.Bindings, e.bind(field, param, fmt.Sprintf(router.PathParam, param)))
+		sample[i] = sampleParam(field.Type())
+	}
+	e.Sample = strings.Join(sample, "/")
+	if !e.Decode {
+		for i := 0; i < req.fields.NumFields(); i++ {
+			field := req.fields.Field(i)
+			name := jsonName(field, req.fields.Tag(i))
+			if !field.Exported() || bound[field.Name()] || name == "-" {
+				continue
+			}
+			e.Bindings = append(e.Bindings, e.bind(field, name, fmt.Sprintf(router.Query, name)))
+		}
+	}
+
+	path = filepath.Join(dir, usecase.Snake+"_http.go")
+	generateFile(path, usecaseHTTPTemplate, e)
+	removeUnusedImports(path)
+	testPath := filepath.Join(dir, usecase.Snake+"_http_test.go")
+	generateFile(testPath, usecaseHTTPTestTemplate, e)
+	removeUnusedImports(testPath)
+	ensureHTTPHelpers(dir, names, router)
+	registerRoutes(usecaseRegistration(req.pkg, usecase, importPath(dir), names.Package+".New"+e.Handler, "%s.RegisterRoutes(router)"))
+}
+
+// requestField finds the field of req a parameter binds to, by its JSON
+// name or by its Go name ignoring case.
+func requestField(req mapperType, param string) (*types.Var, bool) {
+	for i := 0; i < req.fields.NumFields(); i++ {
+		field := req.fields.Field(i)
+		if field.Exported() && (jsonName(field, req.fields.Tag(i)) == param || strings.EqualFold(field.Name(), param)) {
+			return field, true
+		}
+	}
+	return nil, false
+}
+
+// jsonName returns the name encoding/json gives field.
+func jsonName(field *types.Var, tag string) string {
+	name, _, _ := strings.Cut(reflect.StructTag(tag).Get("json"), ",")
+	if name == "" {
+		return field.Name()
+	}
+	return name
+}
+
+// bind returns the statements storing the parameter read by src, named
+// param in errors, in the request field. Values that do not parse are an
+// invalid request.
+func (e *usecaseEndpoint) bind(field *types.Var, param, src string) string {
+	t := field.Type()
+	ptr, isPtr := t.(*types.Pointer)
+	if isPtr {
+		t = ptr.Elem()
+	}
+	fail := func(msg string) string {
+		return "if err != nil {\n" + e.Router.Fail(fmt.Sprintf("validation.NewError(%q, %q)", param, msg)) + "\n}\n"
+	}
+	typeName := types.TypeString(t, e.qualifier)
+
+	var parse, value string
+	if named, ok := t.(*types.Named); ok && named.Obj().Pkg() != nil && named.Obj().Pkg().Path() == "time" && named.Obj().Name() == "Time" {
+		parse = "t, err := time.Parse(time.RFC3339, v)\n" + fail("must be an RFC 3339 time")
+		value = "t"
+	} else if basic, ok := t.Underlying().(*types.Basic); ok {
+		convert := func(v string) string {
+			if types.Identical(t, basic) {
+				return v
+			}
+			return typeName + "(" + v + ")"
+		}
+		info := basic.Info()
+		switch {
+		case info&types.IsString != 0:
+			value = convert("v")
+		case info&types.IsBoolean != 0:
+			parse = "b, err := strconv.ParseBool(v)\n" + fail("must be true or false")
+			value = convert("b")
+		case info&types.IsInteger != 0:
+			fn, msg := "ParseInt", "must be an integer"
+			if info&types.IsUnsigned != 0 {
+				fn, msg = "ParseUint", "must be a positive integer"
+			}
+			parse = fmt.Sprintf("n, err := strconv.%s(v, 10, %d)\n", fn, bitSize(basic))
+			parse += fail(msg)
+			value = "n"
+			if basic.Kind() != types.Int64 && basic.Kind() != types.Uint64 || !types.Identical(t, basic) {
+				value = typeName + "(n)"
+			}
+		case info&types.IsFloat != 0:
+			parse = fmt.Sprintf("f, err := strconv.ParseFloat(v, %d)\n", bitSize(basic))
+			parse += fail("must be a number")
+			value = convert("f")
+			if basic.Kind() == types.Float32 {
+				value = typeName + "(f)"
+			}
+		}
+	}
+	if value == "" {
+		fmt.Printf("Bind %s (%s) by hand: its type cannot be read from a parameter\n", param, types.TypeString(field.Type(), e.qualifier))
+		return fmt.Sprintf("// Bind %s (%s) by hand", param, types.TypeString(field.Type(), e.qualifier))
+	}
+	if strings.Contains(parse, "strconv.") {
+		e.imports["strconv"] = true
+	}
+	if strings.Contains(parse, "time.") {
+		e.imports["time"] = true
+	}
+
+	assign := fmt.Sprintf("req.%s = %s", field.Name(), value)
+	switch {
+	case isPtr && token.IsIdentifier(value):
+		assign = fmt.Sprintf("req.%s = &%s", field.Name(), value)
+	case isPtr:
+		assign = fmt.Sprintf("value := %s\nreq.%s = &value", value, field.Name())
+	}
+	return fmt.Sprintf("if v := %s; v != \"\" {\n%s%s\n}", src, parse, assign)
+}
+
+// bitSize is the bit size strconv parses a number of kind basic with.
+func bitSize(basic *types.Basic) int {
+	switch basic.Kind() {
+	case types.Int8, types.Uint8:
+		return 8
+	case types.Int16, types.Uint16:
+		return 16
+	case types.Int32, types.Uint32, types.Float32:
+		return 32
+	case types.Int, types.Uint:
+		return 0
+	default:
+		return 64
+	}
+}
+
+// sampleParam returns a valid path parameter of type t for tests.
+func sampleParam(t types.Type) string {
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	if named, ok := t.(*types.Named); ok && named.Obj().Name() == "Time" && named.Obj().Pkg() != nil && named.Obj().Pkg().Path() == "time" {
+		return "2024-01-02T15:04:05Z"
+	}
+	if basic, ok := t.Underlying().(*types.Basic); ok {
+		switch {
+		case basic.Info()&types.IsBoolean != 0:
+			return "true"
+		case basic.Info()&types.IsNumeric != 0:
+			return "1"
+		}
+	}
+	return "sample"
+}
+
+const usecaseHTTPTemplate = `package {{.Package}}
+
+{{.Imports}}
+// {{.Handler}} serves {{.Method}} {{.Path}} with the {{.UsecasePkg}} usecase.
+type {{.Handler}} struct {
+	usecase {{.UsecasePkg}}.{{.Service}}
+}
+
+func New{{.Handler}}(usecase {{.UsecasePkg}}.{{.Service}}) *{{.Handler}} {
+	return &{{.Handler}}{usecase: usecase}
+}
+
+func (h *{{.Handler}}) RegisterRoutes(router {{.Router.Type}}) {
+	{{.Router.Route .Method .Path "h.handle"}}
+}
+
+func (h *{{.Handler}}) handle({{.Router.Params}}){{.Router.Result}} {
+	if h.usecase == nil {
+		{{.Router.Fail "errNotImplemented"}}
+	}
+	var req {{.UsecasePkg}}.Request
+{{- if .Decode}}
+	if err := decodeJSON({{.Router.Body}}, &req); err != nil {
+		{{.Router.Fail "err"}}
+	}
+{{- end}}
+{{- range .Bindings}}
+	{{.}}
+{{- end}}
+
+{{- if eq .Method "DELETE"}}
+	if _, err := h.usecase.Execute({{.Router.Ctx}}, &req); err != nil {
+		{{.Router.Fail "err"}}
+	}
+	{{.Router.NoContent .Status}}
+{{- else}}
+	resp, err := h.usecase.Execute({{.Router.Ctx}}, &req)
+	if err != nil {
+		{{.Router.Fail "err"}}
+	}
+	{{.Router.Respond .Status "resp"}}
+{{- end}}
+}
+`
+
+const usecaseHTTPTestTemplate = `package {{.Package}}
+
+{{.TestImports}}
+type fake{{.Service}} struct {
+	err error
+}
+
+func (f fake{{.Service}}) Execute(ctx context.Context, req *{{.UsecasePkg}}.Request) (*{{.UsecasePkg}}.Response, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &{{.UsecasePkg}}.Response{}, nil
+}
+
+func Test{{.Handler}}(t *testing.T) {
+	tests := []struct {
+		name    string
+		usecase {{.UsecasePkg}}.{{.Service}}
+		want    int
+	}{
+		{"ok", fake{{.Service}}{}, {{.Status}}},
+		{"invalid request", fake{{.Service}}{err: validation.NewError("field", "is required")}, http.StatusBadRequest},
+		{"failure", fake{{.Service}}{err: errors.New("failed")}, http.StatusInternalServerError},
+		{"not implemented", nil, http.StatusNotImplemented},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := {{.Router.New}}
+			New{{.Handler}}(tt.usecase).RegisterRoutes(router)
+
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest("{{.Method}}", "{{.Sample}}", strings.NewReader("{}")))
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d; body %s", rec.Code, tt.want, rec.Body)
+			}
+		})
+	}
+}
+`
+
+// grpcEndpoint is a gRPC method bound to the Execute method of a usecase.
+type grpcEndpoint struct {
+	Package    string
+	Handler    string
+	Server     string
+	Service    string
+	PBService  string
+	Method     string
+	UsecasePkg string
+	PB         string
+	Validation string
+	Domain     string
+	Versioned  bool
+	Imports    string
+}
+
+// generateUsecaseGRPCHandler writes a handler for the rpc named like the
+// usecase, with mappers between its messages and the usecase's Request and
+// Response. The rpc and its messages are added to the .proto of the
+// service, or taken from the Go code protoc generated into pbDir when one
+// is given. The handler is embedded in a server implementing the whole
+// service.
+func generateUsecaseGRPCHandler(dir string, names, usecase Names, pbDir string) {
+	imp := importer.ForCompiler(token.NewFileSet(), "source", nil)
+	req, service := loadUsecase(imp, usecase)
+	pb := ""
+	if pbDir == "" {
+		pbDir = defaultPBDir(names)
+		pb = writeProtoService(dir, names, pbDir, &usecase)
+	} else {
+		pb = mapProtoPackage(dir, names, usecase, req, pbDir)
+	}
+
+	ensureValidationPackage()
+	data := grpcEndpoint{
+		Package:    names.Package,
+		Handler:    usecase.Pascal + "GRPCHandler",
+		Server:     names.Pascal + "GRPCServer",
+		Service:    service,
+		PBService:  names.Pascal + "Service",
+		Method:     usecase.Pascal,
+		UsecasePkg: req.pkg.Name(),
+		PB:         pb,
+		Validation: importPath(validationPath()),
+		Imports:    renderImports([]string{"context", "google.golang.org/grpc/codes", "google.golang.org/grpc/status", importPath(req.Dir), importPath(pbDir), importPath(validationPath())}),
+	}
+	path := filepath.Join(dir, usecase.Snake+"_grpc.go")
+	generateFile(path, usecaseGRPCTemplate, data)
+	removeUnusedImports(path)
+
+	serverPath := ensureGRPCServer(dir, data, pbDir)
+	addStructField(serverPath, data.Server, "", "*"+data.Handler)
+
+	// The handlers of a service are set on one server, registered last
+	server := names.Package + "Server"
+	reg := usecaseRegistration(req.pkg, usecase, importPath(dir), names.Package+".New"+data.Handler, server+"."+data.Handler+" = %s")
+	reg.Before = server + ".RegisterService(server)"
+	reg.Stmts = append([]string{fmt.Sprintf("%s := &%s.%s{}", server, names.Package, data.Server), reg.Before}, reg.Stmts...)
+	registerService(reg)
+
+	if pkg, err := parseSourcePackage(dir); err != nil || !pkg.declares("grpcError") {
+		domainDir := filepath.Join(layerPath(boundedContext, "core"), names.Package)
+		imports := []string{"errors", "google.golang.org/grpc/codes", "google.golang.org/grpc/status", data.Validation}
+		if pkg, err := parseSourcePackage(domainDir); err == nil && pkg.declares("ErrNotFound") {
+			data.Domain = importPath(domainDir)
+			data.Versioned = pkg.declares("ErrConcurrentModification")
+		}
+		if data.Domain != "" {
+			imports = append(imports, data.Domain)
+		}
+		data.Imports = renderImports(imports)
+		if data.Domain != "" {
+			data.Imports = strings.Replace(data.Imports, fmt.Sprintf("\t%q\n", data.Domain), fmt.Sprintf("\tdomain %q\n", data.Domain), 1)
+		}
+		generateFile(filepath.Join(dir, "grpc_errors.go"), grpcErrorsTemplate, data)
+	}
+}
+
+// grpcHandlerPath is the package of the gRPC handlers of a service. It is
+// kept apart from the HTTP handlers, as it imports the Go code protoc is
+// still to generate.
+func grpcHandlerPath(names Names) string {
+	return filepath.Join(layerPath(boundedContext, "interfaces"), "grpc", names.Package)
+}
+
+// defaultPBDir is where the .proto of the service of a handler package and
+// the Go code protoc generates from it live.
+func defaultPBDir(names Names) string {
+	return filepath.Join(layerPath(boundedContext, "interfaces"), "grpc", names.Package+"pb")
+}
+
+// ensureGRPCServer writes the server implementing the service of the
+// handler package unless the package has it, and returns its file.
+func ensureGRPCServer(dir string, data grpcEndpoint, pbDir string) string {
+	serverPath := filepath.Join(dir, "grpc_server.go")
+	if pkg, err := parseSourcePackage(dir); err != nil || !pkg.declares(data.Server) {
+		data.Imports = renderImports([]string{"google.golang.org/grpc", importPath(pbDir)})
+		generateFile(serverPath, grpcServerTemplate, data)
+	}
+	return serverPath
+}
+
+// mapProtoPackage maps the messages of the rpc named like the usecase in
+// the Go code protoc generated into pbDir, matching their fields by name,
+// and returns the name of its package.
+func mapProtoPackage(dir string, names, usecase Names, req mapperType, pbDir string) string {
+	if _, err := os.Stat(pbDir); err != nil {
+		fmt.Printf("No generated protobuf package in %s. Generate the Go code for %sService into it with protoc\n", pbDir, names.Pascal)
+		os.Exit(1)
+	}
+	imp := importer.ForCompiler(token.NewFileSet(), "source", nil)
+	resp := loadMapperType(imp, req.Dir+".Response")
+	pbReq := loadMapperType(imp, pbDir+"."+usecase.Pascal+"Request")
+	pbResp := loadMapperType(imp, pbDir+"."+usecase.Pascal+"Response")
+
+	file := &mapperFile{
+		Package: names.Package,
+		outPath: importPath(dir),
+		aliases: map[string]string{},
+		imports: map[string]bool{},
+	}
+	requestFn := file.mapFields(usecase.Pascal+"RequestFromProto", pbReq, req, nil)
+	responseFn := file.mapFields(usecase.Pascal+"ResponseToProto", resp, pbResp, nil)
+	// The state protoc adds to messages is not for mapping
+	var unmapped []string
+	for _, u := range responseFn.Unmapped {
+		if !strings.HasSuffix(u, ": unexported") {
+			unmapped = append(unmapped, u)
+		}
+	}
+	responseFn.Unmapped = unmapped
+	file.Funcs = []mapperFunc{requestFn, responseFn}
+	file.addCheck(req)
+	file.addCheck(resp)
+	mapperPath := filepath.Join(dir, usecase.Snake+"_grpc_mapper.go")
+	generateFile(mapperPath, mapperTemplate, file)
+	removeUnusedImports(mapperPath)
+	for _, fn := range file.Funcs {
+		for _, u := range fn.Unmapped {
+			fmt.Printf("Unmapped %s.%s\n", fn.To, u)
+		}
+	}
+	return pbReq.pkg.Name()
+}
+
+const usecaseGRPCTemplate = `package {{.Package}}
+
+{{.Imports}}
+// {{.Handler}} serves the {{.Method}} rpc with the {{.UsecasePkg}}
+// usecase. It is embedded in {{.Server}}.
+type {{.Handler}} struct {
+	usecase {{.UsecasePkg}}.{{.Service}}
+}
+
+func New{{.Handler}}(usecase {{.UsecasePkg}}.{{.Service}}) *{{.Handler}} {
+	return &{{.Handler}}{usecase: usecase}
+}
+
+func (h *{{.Handler}}) {{.Method}}(ctx context.Context, in *{{.PB}}.{{.Method}}Request) (*{{.PB}}.{{.Method}}Response, error) {
+	if h == nil || h.usecase == nil {
+		return nil, status.Error(codes.Unimplemented, "method {{.Method}} not implemented")
+	}
+	resp, err := h.usecase.Execute(ctx, {{.Method}}RequestFromProto(in))
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return {{.Method}}ResponseToProto(resp), nil
+}
+`
+
+const grpcServerTemplate = `package {{.Package}}
+
+{{.Imports}}
+// {{.Server}} implements {{.PB}}.{{.PBService}}Server with one
+// embedded handler per rpc. Rpcs without a handler are unimplemented.
+type {{.Server}} struct {
+	unimplemented
+}
+
+// unimplemented keeps the methods of Unimplemented{{.PBService}}Server a
+// level below those of the handlers, so the handlers' take precedence.
+type unimplemented struct {
+	{{.PB}}.Unimplemented{{.PBService}}Server
+}
+
+// RegisterService registers the server with server.
+func (s *{{.Server}}) RegisterService(server *grpc.Server) {
+	{{.PB}}.Register{{.PBService}}Server(server, s)
+}
+`
+
+const grpcErrorsTemplate = `package {{.Package}}
+
+{{.Imports}}
+// grpcError maps usecase errors to gRPC status codes. Unexpected errors are
+// not shown to the client.
+func grpcError(err error) error {
+	var verr *validation.Error
+	switch {
+	case errors.As(err, &verr):
+		return status.Error(codes.InvalidArgument, verr.Error())
+{{- if .Domain}}
+	case errors.Is(err, domain.ErrNotFound):
+		return status.Error(codes.NotFound, err.Error())
+{{- if .Versioned}}
+	case errors.As(err, new(*domain.ErrConcurrentModification)):
+		return status.Error(codes.Aborted, err.Error())
+{{- end}}
+{{- end}}
+	default:
+		return status.Error(codes.Internal, "internal error")
+	}
+}
+`
@@ -0,0 +1,201 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// validationFiles are the files of the generated validation package, each
+// written only when the declaration it introduces is missing, so projects
+// generated before a file existed get it without losing their own changes.
+var validationFiles = []struct {
+	File string
+	Decl string
+	Code string
+}{
+	{File: "validation.go", Decl: "Error", Code: `// Package validation reports invalid usecase requests field by field, so
+// clients can fix every problem at once.
+package validation
+
+import "strings"
+
+// FieldError describes one invalid request field.
+type FieldError struct {
+	Field   string ` + "`json:\"field\"`" + `
+	Message string ` + "`json:\"message\"`" + `
+}
+
+// Error is returned by usecases when a request is invalid.
+type Error struct {
+	Fields []FieldError
+}
+
+// NewError returns an Error for a single invalid field.
+func NewError(field, message string) *Error {
+	return &Error{Fields: []FieldError{{Field: field, Message: message}}}
+}
+
+// Add records an invalid field.
+func (e *Error) Add(field, message string) {
+	e.Fields = append(e.Fields, FieldError{Field: field, Message: message})
+}
+
+// OrNil returns e if any field was added and nil otherwise.
+func (e *Error) OrNil() error {
+	if len(e.Fields) == 0 {
+		return nil
+	}
+	return e
+}
+
+func (e *Error) Error() string {
+	parts := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		parts[i] = f.Field + ": " + f.Message
+	}
+	return "invalid request: " + strings.Join(parts, "; ")
+}
+`},
+	{File: "struct.go", Decl: "Struct", Code: `package validation
+
+import (
+	"fmt"
+	"net/mail"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Struct checks the fields of the struct v points to against their validate
+// tags and returns an *Error listing every invalid field, or nil. Fields are
+// reported by their JSON name. Rules are separated by commas:
+//
+//	required   the field is not the zero value
+//	email      a non-empty string is an e-mail address
+//	min=N      a number is at least N, a string has at least N characters
+//	           and a slice or map at least N items
+//	max=N      the same with at most N
+//	oneof=a b  a non-empty string is one of the listed values
+//
+// Rules other than required skip nil pointers, so optional fields are only
+// checked when set. A malformed tag is a programming error and panics.
+func Struct(v interface{}) error {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	if rv.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("validation: Struct called with %T", v))
+	}
+
+	verr := &Error{}
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		tag := field.Tag.Get("validate")
+		if tag == "" || !field.IsExported() {
+			continue
+		}
+		for _, rule := range strings.Split(tag, ",") {
+			if msg := check(rv.Field(i), strings.TrimSpace(rule)); msg != "" {
+				verr.Add(jsonName(field), msg)
+				break
+			}
+		}
+	}
+	return verr.OrNil()
+}
+
+func jsonName(field reflect.StructField) string {
+	if name, _, _ := strings.Cut(field.Tag.Get("json"), ","); name != "" && name != "-" {
+		return name
+	}
+	return field.Name
+}
+
+// check returns why v breaks rule, or "" if it does not.
+func check(v reflect.Value, rule string) string {
+	name, param, _ := strings.Cut(rule, "=")
+	if name == "required" {
+		if v.IsZero() {
+			return "is required"
+		}
+		return ""
+	}
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+
+	switch name {
+	case "email":
+		if s := v.String(); s != "" {
+			if _, err := mail.ParseAddress(s); err != nil {
+				return "must be an email address"
+			}
+		}
+	case "min", "max":
+		limit, err := strconv.ParseFloat(param, 64)
+		if err != nil {
+			panic(fmt.Sprintf("validation: invalid rule %q", rule))
+		}
+		size, unit := measure(v)
+		if name == "min" && size < limit {
+			return fmt.Sprintf("must be at least %s%s", param, unit)
+		}
+		if name == "max" && size > limit {
+			return fmt.Sprintf("must be at most %s%s", param, unit)
+		}
+	case "oneof":
+		values := strings.Fields(param)
+		if s := v.String(); s != "" && !slices.Contains(values, s) {
+			return "must be one of " + strings.Join(values, ", ")
+		}
+	default:
+		panic(fmt.Sprintf("validation: unknown rule %q", rule))
+	}
+	return ""
+}
+
+// measure returns what min and max compare: the value of a number and the
+// length of anything else, with the unit to report it in.
+func measure(v reflect.Value) (float64, string) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), ""
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), ""
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), ""
+	case reflect.String:
+		return float64(utf8.RuneCountInString(v.String())), " characters"
+	default:
+		return float64(v.Len()), " items"
+	}
+}
+`},
+}
+
+// validationPath is the directory of the package describing invalid requests.
+func validationPath() string {
+	return filepath.Join(layerPath(boundedContext, "usecase"), "validation")
+}
+
+// ensureValidationPackage writes the parts of the validation package that
+// are not there yet.
+func ensureValidationPackage() {
+	dir := validationPath()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		fmt.Printf("Error creating validation directory: %v\n", err)
+		os.Exit(1)
+	}
+	pkg, err := parseSourcePackage(dir)
+	for _, f := range validationFiles {
+		if err == nil && pkg.declares(f.Decl) {
+			continue
+		}
+		generateFile(filepath.Join(dir, f.File), "{{.}}", f.Code)
+	}
+}
@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// valueObjectDef is a value object the generators know how to write.
+type valueObjectDef struct {
+	Imports []string
+	Code    string
+}
+
+var valueObjectDefs = map[string]valueObjectDef{
+	"Email": {
+		Imports: []string{"fmt", "net/mail"},
+		Code: `// Email is a validated e-mail address.
+type Email string
+
+// NewEmail validates s and returns it as an Email.
+func NewEmail(s string) (Email, error) {
+	if _, err := mail.ParseAddress(s); err != nil {
+		return "", fmt.Errorf("invalid email %q: %w", s, err)
+	}
+	return Email(s), nil
+}
+
+func (e Email) String() string {
+	return string(e)
+}
+`,
+	},
+	"Decimal": {
+		Imports: []string{"fmt", "math/big"},
+		Code: `// Decimal is an exact decimal number kept in its textual form.
+type Decimal string
+
+// NewDecimal validates s and returns it as a Decimal.
+func NewDecimal(s string) (Decimal, error) {
+	if _, ok := new(big.Rat).SetString(s); !ok {
+		return "", fmt.Errorf("invalid decimal %q", s)
+	}
+	return Decimal(s), nil
+}
+
+// Rat returns the decimal as an exact rational number.
+func (d Decimal) Rat() *big.Rat {
+	r, _ := new(big.Rat).SetString(string(d))
+	return r
+}
+
+func (d Decimal) String() string {
+	return string(d)
+}
+`,
+	},
+	"Money": {
+		Imports: []string{"fmt"},
+		Code: `// Money is an amount in minor currency units, e.g. cents, so arithmetic
+// on it is exact.
+type Money int64
+
+// String formats the amount with two decimal places.
+func (m Money) String() string {
+	sign := ""
+	if m < 0 {
+		sign, m = "-", -m
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, m/100, m%100)
+}
+`,
+	},
+}
+
+// lookupValueObjects returns the definitions of the named value objects.
+func lookupValueObjects(names []string) []valueObjectDef {
+	var defs []valueObjectDef
+	for _, name := range names {
+		def, ok := valueObjectDefs[name]
+		if !ok {
+			fmt.Printf("Unknown value object: %s\n", name)
+			os.Exit(1)
+		}
+		defs = append(defs, def)
+	}
+	return defs
+}
+
+// generateValueObjects writes value_objects.go with the given declarations.
+func generateValueObjects(domainPath, pkg string, defs []valueObjectDef) {
+	importSet := map[string]bool{}
+	var code []string
+	for _, def := range defs {
+		for _, imp := range def.Imports {
+			importSet[imp] = true
+		}
+		code = append(code, def.Code)
+	}
+
+	imports := make([]string, 0, len(importSet))
+	for imp := range importSet {
+		imports = append(imports, imp)
+	}
+	sort.Strings(imports)
+
+	voTemplate := `package {{.Package}}
+
+{{- with .Imports}}
+
+import (
+{{- range .}}
+	"{{.}}"
+{{- end}}
+)
+{{- end}}
+
+{{.Code}}`
+	generateFile(filepath.Join(domainPath, "value_objects.go"), voTemplate, map[string]interface{}{
+		"Package": pkg,
+		"Imports": imports,
+		"Code":    strings.Join(code, "\n"),
+	})
+}
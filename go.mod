@@ -2,10 +2,13 @@ module github.com/kanherepratik/go-ddd-skel
 
 go 1.21
 
-require github.com/spf13/cobra v1.8.0
+require (
+	github.com/AlecAivazis/survey/v2 v2.3.7
+	github.com/spf13/cobra v1.8.0
+	gopkg.in/yaml.v3 v3.0.1
+)
 
 require (
-	github.com/AlecAivazis/survey/v2 v2.3.7 // indirect
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
 	github.com/kballard/go-shellquote v0.0.0-20180428030007-95032a82bc51 // indirect
 	github.com/mattn/go-colorable v0.1.2 // indirect
@@ -16,9 +16,18 @@ Domain-Driven Design principles with opinionated structure.`,
 
 func main() {
 	cmd.Init(rootCmd)
+	cmd.InitGenContext(rootCmd)
 	cmd.InitGenDomain(rootCmd)
+	cmd.InitGenDomainService(rootCmd)
+	cmd.InitGenEnum(rootCmd)
+	cmd.InitGenMigration(rootCmd)
+	cmd.InitGenRepository(rootCmd)
 	cmd.InitGenUsecase(rootCmd)
+	cmd.InitGenDecorate(rootCmd)
+	cmd.InitGenMapper(rootCmd)
+	cmd.InitGenCrud(rootCmd)
 	cmd.InitGenHandler(rootCmd)
+	cmd.InitRemove(rootCmd)
 	cmd.InitGenTests(rootCmd)
 	cmd.InitGraphArch(rootCmd)
 	cmd.InitGenDocs(rootCmd)